@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -16,17 +17,53 @@ type VmImage struct {
 	Arch     string `yaml:"arch"`
 }
 
+type VmPortForward struct {
+	Guest int `yaml:"guest"`
+	Host  int `yaml:"host"`
+}
+
+type VmSshConfig struct {
+	Host         string `yaml:"host"`
+	Port         int    `yaml:"port"`
+	User         string `yaml:"user"`
+	Sync         string `yaml:"sync"`
+	MountCommand string `yaml:"mount_command"`
+}
+
 type VmConfig struct {
-	Manager         string    `yaml:"manager"`
-	HostsResolver   string    `yaml:"hosts_resolver"`
-	Images          []VmImage `yaml:"images"`
-	Ubuntu          string    `yaml:"ubuntu"`
-	InstanceName    string    `yaml:"instance_name"`
-	ForwardHttpPort bool      `yaml:"forward_http_port"`
+	Manager         string          `yaml:"manager"`
+	HostsResolver   string          `yaml:"hosts_resolver"`
+	Images          []VmImage       `yaml:"images"`
+	Ubuntu          string          `yaml:"ubuntu"`
+	InstanceName    string          `yaml:"instance_name"`
+	ForwardHttpPort bool            `yaml:"forward_http_port"`
+	Cpus            int             `yaml:"cpus"`
+	Memory          string          `yaml:"memory"`
+	Disk            string          `yaml:"disk"`
+	PortForwards    []VmPortForward `yaml:"port_forwards"`
+	Ssh             VmSshConfig     `yaml:"ssh"`
+	SyncMode        string          `yaml:"sync_mode"`
+	SyncExcludes    []string        `yaml:"sync_excludes"`
+}
+
+type ServerFirewallConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Name    string `yaml:"name"`
+	Ports   []int  `yaml:"ports"`
 }
 
 type ServerConfig struct {
-	Provider string `yaml:"provider"`
+	Provider         string               `yaml:"provider"`
+	DnsProvider      string               `yaml:"dns_provider"`
+	Profile          string               `yaml:"profile"`
+	CredentialHelper string               `yaml:"credential_helper"`
+	DynamicInventory bool                 `yaml:"dynamic_inventory"`
+	Firewall         ServerFirewallConfig `yaml:"firewall"`
+}
+
+type DnsConfig struct {
+	Resolver       string `yaml:"resolver"`
+	ProvisionCheck bool   `yaml:"provision_check"`
 }
 
 type Config struct {
@@ -39,8 +76,11 @@ type Config struct {
 	VirtualenvIntegration   bool              `yaml:"virtualenv_integration"`
 	Vm                      VmConfig          `yaml:"vm"`
 	Server                  ServerConfig      `yaml:"server"`
+	Dns                     DnsConfig         `yaml:"dns"`
 }
 
+var byteSizePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([kKmMgGtT]?)(?:[iI]?[bB])?$`)
+
 var (
 	UnsupportedTypeErr = errors.New("Invalid env var config setting: value is an unsupported type.")
 	CouldNotParseErr   = errors.New("Invalid env var config setting: failed to parse value")
@@ -62,24 +102,76 @@ func (c *Config) LoadFile(path string) error {
 		return fmt.Errorf("%w: %s", InvalidConfigErr, err)
 	}
 
-	if c.Vm.Manager != "" && c.Vm.Manager != "lima" && c.Vm.Manager != "auto" && c.Vm.Manager != "mock" {
-		return fmt.Errorf("%w: unsupported value for `vm.manager`. Must be one of: auto, lima", InvalidConfigErr)
+	if c.Vm.Manager != "" && c.Vm.Manager != "lima" && c.Vm.Manager != "ssh" && c.Vm.Manager != "auto" && c.Vm.Manager != "mock" {
+		return fmt.Errorf("%w: unsupported value for `vm.manager`. Must be one of: auto, lima, ssh", InvalidConfigErr)
+	}
+
+	if c.Vm.Manager == "ssh" && c.Vm.Ssh.Host == "" {
+		return fmt.Errorf("%w: `vm.ssh.host` is required when `vm.manager` is ssh", InvalidConfigErr)
+	}
+
+	if c.Vm.Ssh.Port < 0 || c.Vm.Ssh.Port > 65535 {
+		return fmt.Errorf("%w: invalid value for `vm.ssh.port`. Must be between 1 and 65535", InvalidConfigErr)
+	}
+
+	if c.Vm.Ssh.Sync != "" && c.Vm.Ssh.Sync != "rsync" && c.Vm.Ssh.Sync != "mount" && c.Vm.Ssh.Sync != "none" {
+		return fmt.Errorf("%w: unsupported value for `vm.ssh.sync`. Must be one of: rsync, mount, none", InvalidConfigErr)
+	}
+
+	if c.Vm.Ssh.Sync == "mount" && c.Vm.Ssh.MountCommand == "" {
+		return fmt.Errorf("%w: `vm.ssh.mount_command` is required when `vm.ssh.sync` is mount", InvalidConfigErr)
+	}
+
+	if c.Vm.SyncMode != "" && c.Vm.SyncMode != "mount" && c.Vm.SyncMode != "rsync" {
+		return fmt.Errorf("%w: unsupported value for `vm.sync_mode`. Must be one of: mount, rsync", InvalidConfigErr)
 	}
 
 	if c.Vm.Ubuntu != "" && c.Vm.Ubuntu != "22.04" && c.Vm.Ubuntu != "24.04" {
 		return fmt.Errorf("%w: unsupported value for `vm.ubuntu`. Must be one of: 22.04, 24.04", InvalidConfigErr)
 	}
 
-	if c.Vm.HostsResolver != "" && c.Vm.HostsResolver != "hosts_file" {
-		return fmt.Errorf("%w: unsupported value for `vm.hosts_resolver`. Must be one of: hosts_file", InvalidConfigErr)
+	if c.Vm.HostsResolver != "" && c.Vm.HostsResolver != "hosts_file" && c.Vm.HostsResolver != "dnsmasq" && c.Vm.HostsResolver != "resolved" && c.Vm.HostsResolver != "dns_server" {
+		return fmt.Errorf("%w: unsupported value for `vm.hosts_resolver`. Must be one of: hosts_file, dnsmasq, resolved, dns_server", InvalidConfigErr)
+	}
+
+	if c.Vm.Cpus < 0 {
+		return fmt.Errorf("%w: invalid value for `vm.cpus`. Must be a positive number", InvalidConfigErr)
+	}
+
+	if c.Vm.Memory != "" {
+		if _, err := ParseByteSize(c.Vm.Memory); err != nil {
+			return fmt.Errorf("%w: invalid value for `vm.memory`. %v", InvalidConfigErr, err)
+		}
+	}
+
+	if c.Vm.Disk != "" {
+		if _, err := ParseByteSize(c.Vm.Disk); err != nil {
+			return fmt.Errorf("%w: invalid value for `vm.disk`. %v", InvalidConfigErr, err)
+		}
+	}
+
+	for _, forward := range c.Vm.PortForwards {
+		if forward.Guest < 1 || forward.Guest > 65535 || forward.Host < 1 || forward.Host > 65535 {
+			return fmt.Errorf("%w: invalid port forward %d -> %d in `vm.port_forwards`. Ports must be between 1 and 65535", InvalidConfigErr, forward.Guest, forward.Host)
+		}
 	}
 
 	if c.DatabaseApp != "" && c.DatabaseApp != "tableplus" && c.DatabaseApp != "sequel-ace" {
 		return fmt.Errorf("%w: unsupported value for `database_app`. Must be one of: tableplus, sequel-ace", InvalidConfigErr)
 	}
 
-	if c.Server.Provider != "" && c.Server.Provider != "digitalocean" && c.Server.Provider != "hetzner" {
-		return fmt.Errorf("%w: unsupported value for `server.provider`. Must be one of: digitalocean, hetzner", InvalidConfigErr)
+	if c.Server.Provider != "" && c.Server.Provider != "digitalocean" && c.Server.Provider != "hetzner" && c.Server.Provider != "linode" && c.Server.Provider != "fake" {
+		return fmt.Errorf("%w: unsupported value for `server.provider`. Must be one of: digitalocean, hetzner, linode", InvalidConfigErr)
+	}
+
+	if c.Server.DnsProvider != "" && c.Server.DnsProvider != "digitalocean" && c.Server.DnsProvider != "hetzner" && c.Server.DnsProvider != "linode" && c.Server.DnsProvider != "cloudflare" && c.Server.DnsProvider != "fake" {
+		return fmt.Errorf("%w: unsupported value for `server.dns_provider`. Must be one of: digitalocean, hetzner, linode, cloudflare", InvalidConfigErr)
+	}
+
+	for _, port := range c.Server.Firewall.Ports {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("%w: invalid port %d in `server.firewall.ports`. Must be between 1 and 65535", InvalidConfigErr, port)
+		}
 	}
 
 	return nil
@@ -142,3 +234,29 @@ func (c *Config) LoadEnv(prefix string) error {
 
 	return nil
 }
+
+/*
+ParseByteSize parses a size like "4GiB", "512M" or "100GB" into bytes.
+Like Lima, units are case-insensitive and always binary (1G = 1GiB = 1024^3).
+*/
+func ParseByteSize(size string) (int64, error) {
+	matches := byteSizePattern.FindStringSubmatch(strings.TrimSpace(size))
+	if matches == nil {
+		return 0, fmt.Errorf("%q is not a valid size (eg: 4GiB, 512MiB)", size)
+	}
+
+	value, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a valid size (eg: 4GiB, 512MiB)", size)
+	}
+
+	multiplier := map[string]float64{
+		"":  1,
+		"k": 1 << 10,
+		"m": 1 << 20,
+		"g": 1 << 30,
+		"t": 1 << 40,
+	}[strings.ToLower(matches[2])]
+
+	return int64(value * multiplier), nil
+}
@@ -4,6 +4,7 @@ import (
 	_ "fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -109,3 +110,261 @@ TRELLIS_OPEN=foo setting of type map[string]string is unsupported.
 		t.Errorf("expected error %s got %s", expected, msg)
 	}
 }
+
+func TestLoadFileInvalidFirewallPort(t *testing.T) {
+	conf := Config{}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cli.yml")
+	content := `
+server:
+  firewall:
+    ports: [22, 80, 70000]
+`
+
+	if err := os.WriteFile(path, []byte(content), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	err := conf.LoadFile(path)
+	if err == nil {
+		t.Fatal("expected LoadFile to return an error")
+	}
+
+	expected := "Invalid config file: invalid port 70000 in `server.firewall.ports`. Must be between 1 and 65535"
+
+	if err.Error() != expected {
+		t.Errorf("expected error %q got %q", expected, err.Error())
+	}
+}
+
+func TestLoadFileInvalidDnsProvider(t *testing.T) {
+	conf := Config{}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cli.yml")
+	content := `
+server:
+  dns_provider: route53
+`
+
+	if err := os.WriteFile(path, []byte(content), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	err := conf.LoadFile(path)
+	if err == nil {
+		t.Fatal("expected LoadFile to return an error")
+	}
+
+	expected := "Invalid config file: unsupported value for `server.dns_provider`. Must be one of: digitalocean, hetzner, linode, cloudflare"
+
+	if err.Error() != expected {
+		t.Errorf("expected error %q got %q", expected, err.Error())
+	}
+}
+
+func TestLoadFileFakeProvider(t *testing.T) {
+	conf := Config{}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cli.yml")
+	content := `
+server:
+  provider: fake
+  dns_provider: fake
+`
+
+	if err := os.WriteFile(path, []byte(content), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conf.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if conf.Server.Provider != "fake" {
+		t.Errorf("expected provider to be fake, got %q", conf.Server.Provider)
+	}
+}
+
+func TestLoadFileInvalidVmSyncMode(t *testing.T) {
+	conf := Config{}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cli.yml")
+	content := `
+vm:
+  sync_mode: unison
+`
+
+	if err := os.WriteFile(path, []byte(content), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	err := conf.LoadFile(path)
+	if err == nil {
+		t.Fatal("expected LoadFile to return an error")
+	}
+
+	expected := "Invalid config file: unsupported value for `vm.sync_mode`. Must be one of: mount, rsync"
+
+	if err.Error() != expected {
+		t.Errorf("expected error %q got %q", expected, err.Error())
+	}
+}
+
+func TestLoadFileVmResources(t *testing.T) {
+	conf := Config{}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cli.yml")
+	content := `
+vm:
+  cpus: 6
+  memory: 8GiB
+  disk: 150GiB
+  port_forwards:
+    - guest: 8025
+      host: 8025
+    - guest: 443
+      host: 8443
+`
+
+	if err := os.WriteFile(path, []byte(content), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conf.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := VmConfig{
+		Cpus:         6,
+		Memory:       "8GiB",
+		Disk:         "150GiB",
+		PortForwards: []VmPortForward{{Guest: 8025, Host: 8025}, {Guest: 443, Host: 8443}},
+	}
+
+	if !reflect.DeepEqual(conf.Vm, expected) {
+		t.Errorf("expected %v got %v", expected, conf.Vm)
+	}
+}
+
+func TestLoadFileInvalidVmResources(t *testing.T) {
+	cases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			"cpus",
+			"vm:\n  cpus: -1\n",
+			"Invalid config file: invalid value for `vm.cpus`. Must be a positive number",
+		},
+		{
+			"memory",
+			"vm:\n  memory: lots\n",
+			"Invalid config file: invalid value for `vm.memory`. \"lots\" is not a valid size (eg: 4GiB, 512MiB)",
+		},
+		{
+			"disk",
+			"vm:\n  disk: 100XB\n",
+			"Invalid config file: invalid value for `vm.disk`. \"100XB\" is not a valid size (eg: 4GiB, 512MiB)",
+		},
+		{
+			"port_forwards",
+			"vm:\n  port_forwards:\n    - guest: 8025\n",
+			"Invalid config file: invalid port forward 8025 -> 0 in `vm.port_forwards`. Ports must be between 1 and 65535",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := Config{}
+			path := filepath.Join(t.TempDir(), "cli.yml")
+
+			if err := os.WriteFile(path, []byte(tc.content), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+
+			err := conf.LoadFile(path)
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("expected error %q got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestLoadFileInvalidVmSsh(t *testing.T) {
+	cases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			"missing_host",
+			"vm:\n  manager: ssh\n",
+			"Invalid config file: `vm.ssh.host` is required when `vm.manager` is ssh",
+		},
+		{
+			"port",
+			"vm:\n  manager: ssh\n  ssh:\n    host: devbox\n    port: 70000\n",
+			"Invalid config file: invalid value for `vm.ssh.port`. Must be between 1 and 65535",
+		},
+		{
+			"sync",
+			"vm:\n  manager: ssh\n  ssh:\n    host: devbox\n    sync: unison\n",
+			"Invalid config file: unsupported value for `vm.ssh.sync`. Must be one of: rsync, mount, none",
+		},
+		{
+			"missing_mount_command",
+			"vm:\n  manager: ssh\n  ssh:\n    host: devbox\n    sync: mount\n",
+			"Invalid config file: `vm.ssh.mount_command` is required when `vm.ssh.sync` is mount",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := Config{}
+			path := filepath.Join(t.TempDir(), "cli.yml")
+
+			if err := os.WriteFile(path, []byte(tc.content), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+
+			err := conf.LoadFile(path)
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("expected error %q got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	cases := map[string]int64{
+		"1024":   1024,
+		"512MiB": 512 << 20,
+		"4GiB":   4 << 30,
+		"4G":     4 << 30,
+		"4gb":    4 << 30,
+		"1.5GiB": 3 << 29,
+		"1TiB":   1 << 40,
+	}
+
+	for size, expected := range cases {
+		bytes, err := ParseByteSize(size)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", size, err)
+			continue
+		}
+
+		if bytes != expected {
+			t.Errorf("%s: expected %d got %d", size, expected, bytes)
+		}
+	}
+
+	if _, err := ParseByteSize("GiB"); err == nil {
+		t.Error("expected an error for a size without a number")
+	}
+}
@@ -79,6 +79,14 @@ func (c *DeployCommand) Run(args []string) int {
 		},
 	}
 
+	inventoryPath, err := playbookInventory(c.Trellis, environment)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	playbook.SetInventory(inventoryPath)
+
 	if environment == "development" {
 		if !c.Trellis.CliConfig.AllowDevelopmentDeploys {
 			c.UI.Error(`
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/posener/complete"
+	"github.com/roots/trellis-cli/dns"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func NewDnsCheckCommand(ui cli.Ui, trellis *trellis.Trellis) *DnsCheckCommand {
+	c := &DnsCheckCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+type DnsCheckCommand struct {
+	UI       cli.Ui
+	Trellis  *trellis.Trellis
+	flags    *flag.FlagSet
+	resolver string
+}
+
+func (c *DnsCheckCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+	c.flags.StringVar(&c.resolver, "resolver", "", "DNS server address to query (eg: 1.1.1.1:53). Defaults to the system resolver")
+}
+
+func (c *DnsCheckCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 1, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	environment := args[0]
+
+	environmentErr := c.Trellis.ValidateEnvironment(environment)
+	if environmentErr != nil {
+		c.UI.Error(environmentErr.Error())
+		return 1
+	}
+
+	resolver := c.resolver
+	if resolver == "" {
+		resolver = c.Trellis.CliConfig.Dns.Resolver
+	}
+
+	ok, err := checkDNS(c.UI, c.Trellis, environment, resolver)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error: %s", err))
+		return 1
+	}
+
+	if !ok {
+		return 1
+	}
+
+	return 0
+}
+
+/*
+checkDNS resolves every site host in the environment, compares the answers with
+the server IPs from the environment's inventory, and prints a report.
+It returns false if any host doesn't resolve to the server(s).
+*/
+func checkDNS(ui cli.Ui, t *trellis.Trellis, environment string, resolverAddress string) (bool, error) {
+	inventoryPath := t.InventoryPath(environment)
+
+	inventory, err := trellis.ReadInventory(inventoryPath)
+	if err != nil {
+		return false, err
+	}
+
+	inventoryHosts := inventory.GroupHosts(environment)
+	if len(inventoryHosts) == 0 {
+		inventoryHosts = inventory.GroupHosts("web")
+	}
+
+	if len(inventoryHosts) == 0 {
+		return false, fmt.Errorf("no servers found in hosts/%s", environment)
+	}
+
+	ctx := context.Background()
+	resolver := dns.NewResolver(resolverAddress)
+
+	serverIPs, unresolved := dns.ResolveServers(ctx, resolver, inventoryHosts)
+
+	for _, host := range unresolved {
+		ui.Warn(fmt.Sprintf("Warning: skipping %s in hosts/%s since it doesn't resolve (eg: an SSH config alias or placeholder)", host, environment))
+	}
+
+	if len(serverIPs) == 0 {
+		return false, fmt.Errorf("could not resolve any servers in hosts/%s", environment)
+	}
+
+	hosts := t.Environments[environment].AllHosts()
+	checks := dns.CheckHosts(ctx, resolver, hosts, serverIPs)
+	failed := 0
+
+	ui.Info(fmt.Sprintf("Checking DNS for %s sites (server IPs: %s)\n", environment, strings.Join(serverIPs, ", ")))
+
+	for _, check := range checks {
+		switch check.Status {
+		case dns.CheckOk:
+			ui.Info(fmt.Sprintf("%s %s => %s", color.GreenString("[✓]"), check.Host, strings.Join(check.Addresses, ", ")))
+		case dns.CheckMismatch:
+			failed++
+			ui.Info(fmt.Sprintf("%s %s => %s (expected %s)", color.RedString("[✗]"), check.Host, strings.Join(check.Addresses, ", "), strings.Join(serverIPs, ", ")))
+		case dns.CheckUnresolved:
+			failed++
+			ui.Info(fmt.Sprintf("%s %s does not resolve", color.RedString("[✗]"), check.Host))
+		}
+	}
+
+	if failed > 0 {
+		ui.Error(fmt.Sprintf("\n%d of %d host(s) don't point to the %s server(s).", failed, len(checks), environment))
+		return false, nil
+	}
+
+	ui.Info(fmt.Sprintf("\nAll %d host(s) point to the %s server(s).", len(checks), environment))
+	return true, nil
+}
+
+func (c *DnsCheckCommand) Synopsis() string {
+	return "Checks that all WordPress sites' hosts resolve to the environment's servers"
+}
+
+func (c *DnsCheckCommand) Help() string {
+	helpText := `
+Usage: trellis dns check [options] ENVIRONMENT
+
+Resolves every canonical and redirect host in the environment's wordpress_sites
+and compares the answers with the server IP(s) in the environment's inventory
+file (hosts/ENVIRONMENT).
+
+Hosts which don't resolve, or resolve to other IPs, are reported and the
+command exits with a non-zero status.
+
+Let's Encrypt can only issue certificates once DNS is correct. Set
+dns.provision_check: true in trellis.cli.yml to also run this check before
+'trellis provision' when any site uses ssl.provider: letsencrypt.
+
+Check the production environment:
+
+  $ trellis dns check production
+
+Query a specific DNS server:
+
+  $ trellis dns check --resolver 1.1.1.1 production
+
+The resolver can also be set via dns.resolver in trellis.cli.yml.
+
+Arguments:
+  ENVIRONMENT Name of environment (ie: production)
+
+Options:
+      --resolver  DNS server address to query (default: system resolver)
+  -h, --help      show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DnsCheckCommand) AutocompleteArgs() complete.Predictor {
+	return c.Trellis.AutocompleteEnvironment(c.flags)
+}
+
+func (c *DnsCheckCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"--resolver": complete.PredictNothing,
+	}
+}
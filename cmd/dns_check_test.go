@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/dns"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestDnsCheckRunValidations(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	cases := []struct {
+		name            string
+		projectDetected bool
+		args            []string
+		out             string
+		code            int
+	}{
+		{
+			"no_project",
+			false,
+			nil,
+			"No Trellis project detected",
+			1,
+		},
+		{
+			"no_args",
+			true,
+			nil,
+			"Error: missing arguments (expected exactly 1, got 0)",
+			1,
+		},
+		{
+			"too_many_args",
+			true,
+			[]string{"production", "foo"},
+			"Error: too many arguments",
+			1,
+		},
+		{
+			"invalid_env",
+			true,
+			[]string{"foo"},
+			"Error: foo is not a valid environment",
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			trellis := trellis.NewMockTrellis(tc.projectDetected)
+			dnsCheckCommand := NewDnsCheckCommand(ui, trellis)
+
+			code := dnsCheckCommand.Run(tc.args)
+
+			if code != tc.code {
+				t.Errorf("expected code %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected output %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+}
+
+func TestDnsCheckRun(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	cases := []struct {
+		name    string
+		records map[string][]string
+		out     []string
+		code    int
+	}{
+		{
+			"all_hosts_match",
+			map[string][]string{
+				"example.com":     {"1.2.3.4"},
+				"www.example.com": {"1.2.3.4"},
+			},
+			[]string{"example.com => 1.2.3.4", "All 2 host(s) point to the production server(s)."},
+			0,
+		},
+		{
+			"mismatch_and_unresolved",
+			map[string][]string{
+				"example.com": {"5.6.7.8"},
+			},
+			[]string{
+				"example.com => 5.6.7.8 (expected 1.2.3.4)",
+				"www.example.com does not resolve",
+				"2 of 2 host(s) don't point to the production server(s).",
+			},
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			trellis := trellis.NewTrellis()
+			dnsCheckCommand := NewDnsCheckCommand(ui, trellis)
+
+			code := dnsCheckCommand.Run([]string{"--resolver", dns.StartTestServer(t, tc.records), "production"})
+
+			if code != tc.code {
+				t.Errorf("expected code %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+			for _, out := range tc.out {
+				if !strings.Contains(combined, out) {
+					t.Errorf("expected output %q to contain %q", combined, out)
+				}
+			}
+		})
+	}
+}
+
+func TestProvisionDnsCheck(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	sitesPath := filepath.Join("group_vars", "production", "wordpress_sites.yml")
+	sites, err := os.ReadFile(sitesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sites = []byte(strings.Replace(string(sites), "enabled: false\n      provider: letsencrypt", "enabled: true\n      provider: letsencrypt", 1))
+	if err := os.WriteFile(sitesPath, sites, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	trellis := trellis.NewTrellis()
+	trellis.CliConfig.Dns.Resolver = dns.StartTestServer(t, map[string][]string{"example.com": {"5.6.7.8"}})
+
+	ui := cli.NewMockUi()
+	defer MockUiExec(t, ui)()
+
+	code := NewProvisionCommand(ui, trellis).Run([]string{"production"})
+	combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+	if code != 0 || strings.Contains(combined, "Checking DNS") {
+		t.Errorf("expected provision not to check DNS by default, got code %d and output %q", code, combined)
+	}
+
+	trellis.CliConfig.Dns.ProvisionCheck = true
+
+	ui = cli.NewMockUi()
+	defer MockUiExec(t, ui)()
+
+	code = NewProvisionCommand(ui, trellis).Run([]string{"production"})
+	combined = ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+	if code != 1 || !strings.Contains(combined, "Fix the DNS records above or skip this check with --skip-dns-check.") {
+		t.Errorf("expected provision to fail DNS check, got code %d and output %q", code, combined)
+	}
+
+	if strings.Contains(combined, "ansible-playbook") {
+		t.Errorf("expected provision not to run, got output %q", combined)
+	}
+
+	ui = cli.NewMockUi()
+	defer MockUiExec(t, ui)()
+
+	code = NewProvisionCommand(ui, trellis).Run([]string{"--skip-dns-check", "production"})
+	combined = ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+	if code != 0 || !strings.Contains(combined, "ansible-playbook server.yml -e env=production") {
+		t.Errorf("expected provision to skip DNS check, got code %d and output %q", code, combined)
+	}
+}
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/posener/complete"
+	"github.com/roots/trellis-cli/pkg/server"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func NewInventoryCommand(ui cli.Ui, trellis *trellis.Trellis) *InventoryCommand {
+	c := &InventoryCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+type InventoryCommand struct {
+	UI           cli.Ui
+	Trellis      *trellis.Trellis
+	flags        *flag.FlagSet
+	providerFlag string
+	list         bool
+	host         string
+}
+
+func (c *InventoryCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+	c.flags.StringVar(&c.providerFlag, "provider", "", "Cloud provider (digitalocean, hetzner, linode)")
+	c.flags.BoolVar(&c.list, "list", false, "Print the inventory of all servers (default)")
+	c.flags.StringVar(&c.host, "host", "", "Print the variables of a single host")
+}
+
+func (c *InventoryCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 1, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	environment := args[0]
+
+	environmentErr := c.Trellis.ValidateEnvironment(environment)
+	if environmentErr != nil {
+		c.UI.Error(environmentErr.Error())
+		return 1
+	}
+
+	if environment == "development" {
+		c.UI.Error("inventory command only supports non-development environments")
+		return 1
+	}
+
+	// host variables are included in the list's _meta so Ansible doesn't need them per host
+	if c.host != "" {
+		c.UI.Output("{}")
+		return 0
+	}
+
+	provider, err := c.newProvider()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	servers, err := provider.GetServers(context.Background())
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error fetching servers: %v", err))
+		return 1
+	}
+
+	jsonBytes, err := json.MarshalIndent(dynamicInventory(servers, environment), "", "  ")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error encoding JSON: %s", err))
+		return 1
+	}
+
+	c.UI.Output(string(jsonBytes))
+	return 0
+}
+
+// newProvider creates the cloud provider without prompting for a token since
+// the command's output is read by Ansible.
+func (c *InventoryCommand) newProvider() (server.Provider, error) {
+	providerName, err := resolveCredentialProvider(c.Trellis, c.providerFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := server.LookupProviderToken(providerName, serverTokenSource(c.Trellis))
+	if err != nil {
+		return nil, fmt.Errorf("Error: %v", err)
+	}
+
+	if envVar := server.TokenEnvVar(providerName); token == "" && envVar != "" {
+		return nil, fmt.Errorf("Error: no %s API token found. Set %s or run `trellis server login`.", providerName, envVar)
+	}
+
+	return server.NewProvider(providerName, token)
+}
+
+type inventoryGroup struct {
+	Hosts []string `json:"hosts"`
+}
+
+/*
+dynamicInventory builds an Ansible dynamic inventory from the servers tagged
+"type: trellis" and "env: ENV". Servers are added to the environment's group and
+the group of their "role" tag ([web] if they don't have one).
+*/
+func dynamicInventory(servers []server.Server, env string) map[string]any {
+	groups := map[string]*inventoryGroup{env: {Hosts: []string{}}}
+
+	for _, srv := range servers {
+		if srv.Tags["type"] != "trellis" || srv.Tags["env"] != env {
+			continue
+		}
+
+		host := srv.PublicIPv4
+		if host == "" {
+			host = srv.PublicIPv6
+		}
+		if host == "" {
+			continue
+		}
+
+		role := srv.Tags["role"]
+		if role == "" {
+			role = "web"
+		}
+
+		for _, name := range []string{env, role} {
+			if groups[name] == nil {
+				groups[name] = &inventoryGroup{Hosts: []string{}}
+			}
+
+			if !slices.Contains(groups[name].Hosts, host) {
+				groups[name].Hosts = append(groups[name].Hosts, host)
+			}
+		}
+	}
+
+	inventory := map[string]any{
+		"_meta": map[string]any{"hostvars": map[string]any{}},
+	}
+
+	for name, group := range groups {
+		slices.Sort(group.Hosts)
+		inventory[name] = group
+	}
+
+	return inventory
+}
+
+/*
+playbookInventory returns the dynamic inventory script to run a remote
+environment's playbooks with when server.dynamic_inventory is enabled.
+An empty path means Ansible's default inventory (hosts/ENV) is used.
+*/
+func playbookInventory(t *trellis.Trellis, env string) (string, error) {
+	if env == "development" || !t.CliConfig.Server.DynamicInventory {
+		return "", nil
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	path, err := t.WriteInventoryScript(env, executable)
+	if err != nil {
+		return "", fmt.Errorf("Error writing dynamic inventory script: %w", err)
+	}
+
+	return path, nil
+}
+
+func (c *InventoryCommand) Synopsis() string {
+	return "Prints an Ansible dynamic inventory of an environment's cloud servers"
+}
+
+func (c *InventoryCommand) Help() string {
+	helpText := `
+Usage: trellis inventory [options] ENVIRONMENT
+
+Prints an Ansible dynamic inventory (JSON) built from the cloud provider's
+servers instead of the static hosts/ENVIRONMENT file.
+
+Servers created by 'trellis server create' are tagged with "type: trellis", the
+environment ("env"), and their role ("role"). Servers are added to the
+environment's group and their role's group (eg: [web], [db]), so the inventory
+always contains their current IPs (eg: after a server was rebuilt).
+
+Print the production inventory:
+
+  $ trellis inventory --list production
+
+Use it with Ansible directly:
+
+  $ ansible-playbook server.yml -e env=production -i .trellis/inventory/production
+
+To run 'provision', 'deploy', and 'rollback' with the dynamic inventory, enable
+it in trellis.cli.yml:
+
+  server:
+    dynamic_inventory: true
+
+A script which runs this command is written to .trellis/inventory/ENVIRONMENT
+and passed to ansible-playbook as its inventory.
+
+The provider API token is never prompted for. Set the provider's environment
+variable or save it with 'trellis server login'.
+
+Arguments:
+  ENVIRONMENT Name of environment (ie: production)
+
+Options:
+      --list      Print the inventory of all servers (default)
+      --host      Print the variables of a single host (always empty)
+      --provider  Cloud provider (digitalocean, hetzner, linode). Defaults to server.provider
+  -h, --help      show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *InventoryCommand) AutocompleteArgs() complete.Predictor {
+	return c.Trellis.AutocompleteEnvironment(c.flags)
+}
+
+func (c *InventoryCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"--list":     complete.PredictNothing,
+		"--host":     complete.PredictNothing,
+		"--provider": complete.PredictSet("digitalocean", "hetzner", "linode"),
+	}
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/server"
+	"github.com/roots/trellis-cli/pkg/server/fake"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestInventoryRunValidations(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	cases := []struct {
+		name            string
+		projectDetected bool
+		args            []string
+		out             string
+		code            int
+	}{
+		{
+			"no_project",
+			false,
+			nil,
+			"No Trellis project detected",
+			1,
+		},
+		{
+			"no_args",
+			true,
+			nil,
+			"Error: missing arguments (expected exactly 1, got 0)",
+			1,
+		},
+		{
+			"too_many_args",
+			true,
+			[]string{"production", "foo"},
+			"Error: too many arguments",
+			1,
+		},
+		{
+			"invalid_env",
+			true,
+			[]string{"foo"},
+			"Error: foo is not a valid environment",
+			1,
+		},
+		{
+			"development_env",
+			true,
+			[]string{"development"},
+			"inventory command only supports non-development environments",
+			1,
+		},
+		{
+			"invalid_provider",
+			true,
+			[]string{"--provider", "foo", "production"},
+			"Error: unsupported provider foo",
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			trellis := trellis.NewMockTrellis(tc.projectDetected)
+			inventoryCommand := NewInventoryCommand(ui, trellis)
+
+			code := inventoryCommand.Run(tc.args)
+
+			if code != tc.code {
+				t.Errorf("expected code %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected output %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+}
+
+func TestInventoryRun(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	statePath := filepath.Join(t.TempDir(), "fake.json")
+	t.Setenv("TRELLIS_FAKE_PROVIDER_STATE", statePath)
+
+	provider := fake.New(statePath)
+	for _, opts := range []server.CreateServerOptions{
+		{Name: "web-1", Tags: map[string]string{"env": "production", "role": "web"}},
+		{Name: "db-1", Tags: map[string]string{"env": "production", "role": "db"}},
+		{Name: "staging", Tags: map[string]string{"env": "staging", "role": "web"}},
+	} {
+		if _, err := provider.CreateServer(context.Background(), opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ui := cli.NewMockUi()
+	trellis := trellis.NewMockTrellis(true)
+	trellis.CliConfig.Server.Provider = "fake"
+
+	code := NewInventoryCommand(ui, trellis).Run([]string{"--list", "production"})
+	if code != 0 {
+		t.Fatalf("expected code 0, got %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	inventory := map[string]any{}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &inventory); err != nil {
+		t.Fatalf("expected JSON output, got %q: %v", ui.OutputWriter.String(), err)
+	}
+
+	expected := map[string]any{
+		"_meta":      map[string]any{"hostvars": map[string]any{}},
+		"production": map[string]any{"hosts": []any{"203.0.113.1", "203.0.113.2"}},
+		"web":        map[string]any{"hosts": []any{"203.0.113.1"}},
+		"db":         map[string]any{"hosts": []any{"203.0.113.2"}},
+	}
+
+	if !reflect.DeepEqual(inventory, expected) {
+		t.Errorf("expected %v, got %v", expected, inventory)
+	}
+
+	ui = cli.NewMockUi()
+	code = NewInventoryCommand(ui, trellis).Run([]string{"--host", "203.0.113.1", "production"})
+
+	if code != 0 || strings.TrimSpace(ui.OutputWriter.String()) != "{}" {
+		t.Errorf("expected empty host vars, got code %d and output %q", code, ui.OutputWriter.String())
+	}
+}
+
+func TestDynamicInventory(t *testing.T) {
+	servers := []server.Server{
+		{PublicIPv4: "1.1.1.1", Tags: map[string]string{"type": "trellis", "env": "production"}},
+		{PublicIPv6: "2001:db8::1", Tags: map[string]string{"type": "trellis", "env": "production", "role": "db"}},
+		{PublicIPv4: "2.2.2.2", Tags: map[string]string{"env": "production"}},
+		{Tags: map[string]string{"type": "trellis", "env": "production"}},
+	}
+
+	inventory := dynamicInventory(servers, "production")
+
+	expected := map[string]any{
+		"_meta":      map[string]any{"hostvars": map[string]any{}},
+		"production": &inventoryGroup{Hosts: []string{"1.1.1.1", "2001:db8::1"}},
+		"web":        &inventoryGroup{Hosts: []string{"1.1.1.1"}},
+		"db":         &inventoryGroup{Hosts: []string{"2001:db8::1"}},
+	}
+
+	if !reflect.DeepEqual(inventory, expected) {
+		t.Errorf("expected %v, got %v", expected, inventory)
+	}
+
+	inventory = dynamicInventory(servers, "staging")
+
+	expected = map[string]any{
+		"_meta":   map[string]any{"hostvars": map[string]any{}},
+		"staging": &inventoryGroup{Hosts: []string{}},
+	}
+
+	if !reflect.DeepEqual(inventory, expected) {
+		t.Errorf("expected %v, got %v", expected, inventory)
+	}
+}
+
+func TestProvisionDynamicInventory(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	trellis := trellis.NewTrellis()
+	trellis.CliConfig.Server.DynamicInventory = true
+
+	ui := cli.NewMockUi()
+	defer MockUiExec(t, ui)()
+
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	code := NewProvisionCommand(ui, trellis).Run([]string{"--skip-dns-check", "production"})
+	combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+	expected := "ansible-playbook server.yml --inventory=" + trellis.InventoryScriptPath("production") + " -e env=production"
+
+	if code != 0 || !strings.Contains(combined, expected) {
+		t.Errorf("expected output %q to contain %q", combined, expected)
+	}
+}
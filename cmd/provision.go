@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"flag"
+	"fmt"
 	"os"
 	"strings"
 
@@ -26,6 +27,7 @@ type ProvisionCommand struct {
 	skipTags  string
 	Trellis   *trellis.Trellis
 	verbose   bool
+	skipDns   bool
 }
 
 func (c *ProvisionCommand) init() {
@@ -35,6 +37,7 @@ func (c *ProvisionCommand) init() {
 	c.flags.StringVar(&c.tags, "tags", "", "only run roles and tasks tagged with these values")
 	c.flags.StringVar(&c.skipTags, "skip-tags", "", "skip roles and tasks tagged with these values")
 	c.flags.BoolVar(&c.verbose, "verbose", false, "Enable Ansible's verbose mode")
+	c.flags.BoolVar(&c.skipDns, "skip-dns-check", false, "Skip checking DNS for Let's Encrypt sites before provisioning")
 }
 
 func (c *ProvisionCommand) Run(args []string) int {
@@ -67,6 +70,21 @@ func (c *ProvisionCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.shouldCheckDns(environment) {
+		ok, err := checkDNS(c.UI, c.Trellis, environment, c.Trellis.CliConfig.Dns.Resolver)
+
+		switch {
+		case err != nil:
+			c.UI.Warn(fmt.Sprintf("Warning: skipping the DNS check: %s", err))
+		case !ok:
+			c.UI.Error("Let's Encrypt certificates can't be issued until all site hosts point to the server.")
+			c.UI.Error("Fix the DNS records above or skip this check with --skip-dns-check.")
+			return 1
+		}
+
+		c.UI.Info("")
+	}
+
 	galaxyInstallCommand := &GalaxyInstallCommand{c.UI, c.Trellis}
 	galaxyInstallCommand.Run([]string{})
 
@@ -88,6 +106,14 @@ func (c *ProvisionCommand) Run(args []string) int {
 		playbook.AddArg("--skip-tags", c.skipTags)
 	}
 
+	inventoryPath, err := playbookInventory(c.Trellis, environment)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	playbook.SetInventory(inventoryPath)
+
 	if environment == "development" {
 		os.Setenv("ANSIBLE_HOST_KEY_CHECKING", "false")
 		playbook.SetName("dev.yml")
@@ -107,6 +133,21 @@ func (c *ProvisionCommand) Run(args []string) int {
 	return 0
 }
 
+// shouldCheckDns returns true when any site in a remote environment requests a Let's Encrypt certificate.
+func (c *ProvisionCommand) shouldCheckDns(environment string) bool {
+	if c.skipDns || environment == "development" || !c.Trellis.CliConfig.Dns.ProvisionCheck {
+		return false
+	}
+
+	for _, site := range c.Trellis.Environments[environment].WordPressSites {
+		if site.SslEnabled() && site.SslProvider() == "letsencrypt" {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (c *ProvisionCommand) Synopsis() string {
 	return "Provisions the specified environment"
 }
@@ -136,15 +177,21 @@ Provision and provide extra vars to Ansible:
 
   $ trellis provision --extra-vars key=value production
 
+With dns.provision_check: true in trellis.cli.yml, DNS is checked first when any
+site uses ssl.provider: letsencrypt (see 'trellis dns check') since certificates
+can't be issued until all site hosts point to the server. Skip the check once
+with --skip-dns-check.
+
 Arguments:
   ENVIRONMENT Name of environment (ie: production)
   
 Options:
-      --extra-vars  (multiple) Set additional variables as key=value or YAML/JSON, if filename prepend with @
-      --skip-tags   (multiple) Skip roles and tasks tagged with these values
-      --tags        (multiple) Only run roles and tasks tagged with these values
-      --verbose     Enable Ansible's verbose mode
-  -h, --help        Show this help
+      --extra-vars      (multiple) Set additional variables as key=value or YAML/JSON, if filename prepend with @
+      --skip-dns-check  Skip checking DNS for Let's Encrypt sites
+      --skip-tags       (multiple) Skip roles and tasks tagged with these values
+      --tags            (multiple) Only run roles and tasks tagged with these values
+      --verbose         Enable Ansible's verbose mode
+  -h, --help            Show this help
 `
 
 	return strings.TrimSpace(helpText)
@@ -156,9 +203,10 @@ func (c *ProvisionCommand) AutocompleteArgs() complete.Predictor {
 
 func (c *ProvisionCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
-		"--extra-vars": complete.PredictNothing,
-		"--skip-tags":  complete.PredictNothing,
-		"--tags":       complete.PredictNothing,
-		"--verbose":    complete.PredictNothing,
+		"--extra-vars":     complete.PredictNothing,
+		"--skip-dns-check": complete.PredictNothing,
+		"--skip-tags":      complete.PredictNothing,
+		"--tags":           complete.PredictNothing,
+		"--verbose":        complete.PredictNothing,
 	}
 }
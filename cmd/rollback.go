@@ -81,6 +81,14 @@ func (c *RollbackCommand) Run(args []string) int {
 		playbook.AddExtraVar("release", c.release)
 	}
 
+	inventoryPath, err := playbookInventory(c.Trellis, environment)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	playbook.SetInventory(inventoryPath)
+
 	rollback := command.WithOptions(
 		command.WithTermOutput(),
 		command.WithLogging(c.UI),
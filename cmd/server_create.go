@@ -4,8 +4,11 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"maps"
 	"os"
 	"os/user"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,8 +16,10 @@ import (
 	"github.com/hashicorp/cli"
 	"github.com/manifoldco/promptui"
 	"github.com/posener/complete"
+	"github.com/roots/trellis-cli/pkg/flags"
 	"github.com/roots/trellis-cli/pkg/server"
 	"github.com/roots/trellis-cli/trellis"
+	"golang.org/x/crypto/ssh"
 )
 
 func NewServerCreateCommand(ui cli.Ui, trellis *trellis.Trellis) *ServerCreateCommand {
@@ -32,17 +37,29 @@ type ServerCreateCommand struct {
 	region        string
 	image         string
 	size          string
+	userData      string
+	count         int
+	roles         flags.StringSliceVar
 	skipProvision bool
 }
 
+// plannedServer is a server to be created along with the inventory group (role) it belongs to.
+type plannedServer struct {
+	Name string
+	Role string
+}
+
 func (c *ServerCreateCommand) init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
 	c.flags.Usage = func() { c.UI.Info(c.Help()) }
-	c.flags.StringVar(&c.providerFlag, "provider", "", "Cloud provider (digitalocean, hetzner)")
+	c.flags.StringVar(&c.providerFlag, "provider", "", "Cloud provider (digitalocean, hetzner, linode)")
 	c.flags.StringVar(&c.sshKey, "ssh-key", "", "Path to SSH public key to automatically add to new server")
 	c.flags.StringVar(&c.region, "region", "", "Region to create the server in")
 	c.flags.StringVar(&c.image, "image", "", "Server image (default: Ubuntu 24.04)")
 	c.flags.StringVar(&c.size, "size", "", "Server size/type to create")
+	c.flags.StringVar(&c.userData, "user-data", "", "Path to a cloud-init user data file")
+	c.flags.IntVar(&c.count, "count", 1, "Number of servers to create for each role")
+	c.flags.Var(&c.roles, "role", "Inventory group(s) (role) of servers to create (default: web)")
 	c.flags.BoolVar(&c.skipProvision, "skip-provision", false, "Create the server but skip provisioning")
 }
 
@@ -81,11 +98,26 @@ func (c *ServerCreateCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.count < 1 {
+		c.UI.Error("Error: --count must be at least 1")
+		return 1
+	}
+
+	var userData string
+	if c.userData != "" {
+		contents, err := os.ReadFile(c.userData)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading user data file: %s", err))
+			return 1
+		}
+		userData = string(contents)
+	}
+
 	providerName := c.resolveProvider()
 
-	token, err := server.GetProviderToken(providerName, c.UI)
+	token, err := server.GetProviderToken(providerName, serverTokenSource(c.Trellis), c.UI)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error: %s API token is required.", providerName))
+		c.UI.Error(fmt.Sprintf("Error: %s API token is required. %v", providerName, err))
 		return 1
 	}
 
@@ -190,34 +222,66 @@ func (c *ServerCreateCommand) Run(args []string) int {
 		return 1
 	}
 
-	// Create server
-	srv, err := c.createServer(ctx, provider, name, environment, fingerprint)
-	if err != nil {
-		return 1
+	planned := planServers(name, c.serverRoles(), c.count)
+	if len(planned) > 1 {
+		c.UI.Info("\nThe following servers will be created:")
+		for _, p := range planned {
+			c.UI.Info(fmt.Sprintf("  %s [%s]", p.Name, p.Role))
+		}
 	}
 
-	// Wait for server to be ready
-	srv, err = c.waitForServer(ctx, provider, srv)
-	if err != nil {
-		return 1
+	// Create servers
+	created := []*server.Server{}
+	for _, p := range planned {
+		srv, err := c.createServer(ctx, provider, p, environment, fingerprint, userData)
+		if err != nil {
+			c.reportUnrecorded(environment, created)
+			return 1
+		}
+
+		created = append(created, srv)
+	}
+
+	// Wait for servers to be ready
+	groups := map[string][]string{}
+	hostKeys := map[string][]ssh.PublicKey{}
+	for i, p := range planned {
+		srv, keys, err := c.waitForServer(ctx, provider, created[i])
+		if err != nil {
+			c.reportUnrecorded(environment, created)
+			return 1
+		}
+
+		created[i] = srv
+		groups[p.Role] = append(groups[p.Role], srv.PublicIPv4)
+		hostKeys[srv.PublicIPv4] = keys
 	}
 
 	// Update hosts file
-	_, err = c.Trellis.UpdateHosts(environment, srv.PublicIPv4)
+	_, err = c.Trellis.UpdateHostGroups(environment, groups)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error updating Trellis hosts file: %s", err))
 		return 1
 	}
 
-	c.UI.Info(fmt.Sprintf("%s Updated hosts/%s with server IP: %s", color.GreenString("[✓]"), environment, srv.PublicIPv4))
+	for _, role := range c.serverRoles() {
+		c.UI.Info(fmt.Sprintf("%s Updated hosts/%s [%s] with server IP(s): %s", color.GreenString("[✓]"), environment, role, strings.Join(groups[role], ", ")))
+	}
+
+	if err := recordHostKeys(c.Trellis, environment, hostKeys); err != nil {
+		c.UI.Warn(fmt.Sprintf("Warning: could not record SSH host keys: %s", err))
+	} else {
+		c.UI.Info(fmt.Sprintf("%s Added SSH host keys to %s", color.GreenString("[✓]"), c.Trellis.KnownHostsPath()))
+	}
 
 	if c.skipProvision {
 		c.UI.Warn(fmt.Sprintf("Skipping provision. Run `trellis provision %s` to manually provision.", environment))
 	} else {
 		c.UI.Info("\nProvisioning server...\n")
 
+		// DNS can't point to a brand new server yet
 		provisionCmd := NewProvisionCommand(c.UI, c.Trellis)
-		return provisionCmd.Run([]string{environment})
+		return provisionCmd.Run([]string{"--skip-dns-check", environment})
 	}
 
 	return 0
@@ -252,6 +316,7 @@ Development should be managed separately through the VM commands.
 Supported providers:
   - digitalocean (default)
   - hetzner
+  - linode (API token via LINODE_TOKEN)
 
 The provider can be configured via:
   1. --provider flag
@@ -270,16 +335,49 @@ Create a server but skip provisioning:
 
   $ trellis server create --skip-provision production
 
+Create a server with a cloud-init user data file:
+
+  $ trellis server create --user-data cloud-init.yml production
+
+Create two web servers and a separate database server:
+
+  $ trellis server create --count 2 --role web production
+  $ trellis server create --role web --role db production
+
+Servers are added to hosts/ENVIRONMENT under the environment group and a group
+for their role (eg: [web], [db]). Existing groups, host variables, and comments
+in the inventory file are kept.
+
+Note: Trellis' server.yml playbook only provisions hosts in the [web] group.
+
+Each server's SSH host keys are recorded in .trellis/known_hosts. Ansible is
+configured to use it (ssh_common_args in ansible.cfg) and host key checking is
+turned on for the environment (ansible_host_key_checking in hosts/ENVIRONMENT),
+so host keys are verified from the first provision.
+See 'trellis server known-hosts' to refresh them after rebuilding a server.
+
+A cloud firewall allowing only inbound SSH, HTTP, and HTTPS (ports 22, 80, 443)
+is created (or reused if it already exists) and attached to the server.
+The ports can be changed, or the firewall disabled, in trellis.cli.yml:
+
+  server:
+    firewall:
+      enabled: true
+      ports: [22, 80, 443, 8080]
+
 Arguments:
   ENVIRONMENT Name of environment (ie: production)
 
 Options:
-      --provider        Cloud provider (digitalocean, hetzner)
+      --count           Number of servers to create for each role (default: 1)
+      --provider        Cloud provider (digitalocean, hetzner, linode)
       --region          Region to create the server in
+      --role            (multiple) Inventory group of servers to create (default: web)
       --image           Server image (default: Ubuntu 24.04)
       --size            Server size/type
       --skip-provision  Skip provision after server is created
       --ssh-key         Path to SSH public key to be added on the server
+      --user-data       Path to a cloud-init user data file
   -h, --help            show this help
 `
 
@@ -292,11 +390,14 @@ func (c *ServerCreateCommand) AutocompleteArgs() complete.Predictor {
 
 func (c *ServerCreateCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
-		"--provider":        complete.PredictSet("digitalocean", "hetzner"),
+		"--count":           complete.PredictNothing,
+		"--provider":        complete.PredictSet("digitalocean", "hetzner", "linode"),
 		"--region":          complete.PredictNothing,
+		"--role":            complete.PredictSet("web", "db"),
 		"--size":            complete.PredictNothing,
 		"--skip--provision": complete.PredictNothing,
 		"--ssh-key":         complete.PredictFiles("*.pub"),
+		"--user-data":       complete.PredictFiles("*"),
 	}
 }
 
@@ -361,32 +462,124 @@ func (c *ServerCreateCommand) selectSize(sizes []server.Size) (string, error) {
 	return sizes[i].Slug, nil
 }
 
-func (c *ServerCreateCommand) createServer(ctx context.Context, provider server.Provider, name, env, sshFingerprint string) (*server.Server, error) {
+func (c *ServerCreateCommand) serverRoles() []string {
+	if len(c.roles) == 0 {
+		return []string{"web"}
+	}
+
+	return c.roles
+}
+
+/*
+planServers returns the servers to create for each role.
+A single server keeps the base name as-is; otherwise names are suffixed with
+the role and a number (when there's more than one per role):
+
+	example.com-web-1, example.com-web-2, example.com-db-1
+*/
+func planServers(name string, roles []string, count int) []plannedServer {
+	if len(roles) == 1 && count == 1 {
+		return []plannedServer{{Name: name, Role: roles[0]}}
+	}
+
+	planned := []plannedServer{}
+	for _, role := range roles {
+		for i := 1; i <= count; i++ {
+			serverName := fmt.Sprintf("%s-%s", name, role)
+			if count > 1 {
+				serverName = fmt.Sprintf("%s-%d", serverName, i)
+			}
+
+			planned = append(planned, plannedServer{Name: serverName, Role: role})
+		}
+	}
+
+	return planned
+}
+
+func (c *ServerCreateCommand) createServer(ctx context.Context, provider server.Provider, planned plannedServer, env, sshFingerprint, userData string) (*server.Server, error) {
 	srv, err := provider.CreateServer(ctx, server.CreateServerOptions{
-		Name:      name,
+		Name:      planned.Name,
 		Region:    c.region,
 		Size:      c.size,
 		Image:     c.image,
 		SSHKeyIDs: []string{sshFingerprint},
-		Tags:      map[string]string{"env": env},
+		Tags:      map[string]string{"env": env, "role": planned.Role},
+		UserData:  userData,
+		Firewall:  c.firewall(),
 	})
+	if err != nil && srv != nil {
+		c.UI.Error(fmt.Sprintf("Error: server %s was created but %s\nFix or delete it from the provider's dashboard: %s", planned.Name, err, srv.DashboardURL))
+		return nil, err
+	}
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error creating server: %s", err))
 		return nil, err
 	}
 
 	if srv.DashboardURL != "" {
-		c.UI.Info(fmt.Sprintf("\n%s Server created => %s", color.GreenString("[✓]"), srv.DashboardURL))
+		c.UI.Info(fmt.Sprintf("\n%s Server %s created => %s", color.GreenString("[✓]"), planned.Name, srv.DashboardURL))
 	}
 
 	return srv, nil
 }
 
-func (c *ServerCreateCommand) waitForServer(ctx context.Context, provider server.Provider, srv *server.Server) (*server.Server, error) {
+// reportUnrecorded lists the servers which were created but not added to the inventory since a later step failed.
+func (c *ServerCreateCommand) reportUnrecorded(env string, created []*server.Server) {
+	if len(created) == 0 {
+		return
+	}
+
+	c.UI.Error(fmt.Sprintf("\nThe following servers were created but not added to hosts/%s:", env))
+	for _, srv := range created {
+		details := []string{}
+		if srv.PublicIPv4 != "" {
+			details = append(details, srv.PublicIPv4)
+		}
+		if srv.DashboardURL != "" {
+			details = append(details, srv.DashboardURL)
+		}
+
+		c.UI.Error(fmt.Sprintf("  %s %s", srv.Name, strings.Join(details, " ")))
+	}
+
+	c.UI.Error(fmt.Sprintf("\nRegister them with `trellis server register %s HOST` or delete them.", env))
+}
+
+func (c *ServerCreateCommand) firewall() *server.Firewall {
+	config := c.Trellis.CliConfig.Server.Firewall
+	if !config.Enabled {
+		return nil
+	}
+
+	if !slices.Contains(config.Ports, 22) {
+		c.UI.Warn("Warning: port 22 is not included in server.firewall.ports. SSH access (and provisioning) will be blocked.")
+	}
+
+	name := config.Name
+	if name == "" {
+		name = firewallName(config.Ports)
+	}
+
+	return &server.Firewall{Name: name, AllowedPorts: config.Ports}
+}
+
+// firewallName derives a default firewall name from its ports (eg: trellis-22-80-443)
+// so servers created with the same rules share a single firewall.
+func firewallName(ports []int) string {
+	parts := []string{"trellis"}
+	for _, port := range ports {
+		parts = append(parts, strconv.Itoa(port))
+	}
+
+	return strings.Join(parts, "-")
+}
+
+func (c *ServerCreateCommand) waitForServer(ctx context.Context, provider server.Provider, srv *server.Server) (*server.Server, []ssh.PublicKey, error) {
 	s := NewSpinner(
 		SpinnerCfg{
-			Message:     "Waiting for server to boot (this may take a minute)",
-			StopMessage: "Server booted",
+			Message:     fmt.Sprintf("Waiting for server %s to boot (this may take a minute)", srv.Name),
+			StopMessage: fmt.Sprintf("Server %s booted", srv.Name),
 			FailMessage: "Server did not become active (or timed out)",
 		},
 	)
@@ -396,7 +589,7 @@ func (c *ServerCreateCommand) waitForServer(ctx context.Context, provider server
 	if err != nil {
 		_ = s.StopFail()
 		c.UI.Error(err.Error())
-		return nil, err
+		return nil, nil, err
 	}
 	_ = s.Stop()
 
@@ -413,12 +606,40 @@ func (c *ServerCreateCommand) waitForServer(ctx context.Context, provider server
 	defer cancel()
 
 	_ = s.Start()
-	err = server.WaitForSSH(sshCtx, srv.PublicIPv4)
+
+	var keys []ssh.PublicKey
+	if hostKeyProvider, ok := provider.(server.HostKeyProvider); ok {
+		keys, err = hostKeyProvider.HostKeys(sshCtx, srv.ID)
+	} else {
+		keys, err = server.WaitForSSH(sshCtx, srv.PublicIPv4)
+	}
+
 	if err != nil {
 		_ = s.StopFail()
-		return nil, err
+		return nil, nil, err
 	}
 	_ = s.Stop()
 
-	return srv, nil
+	return srv, keys, nil
+}
+
+// recordHostKeys replaces the hosts' keys in the project's known_hosts file and makes sure Ansible uses it.
+func recordHostKeys(t *trellis.Trellis, env string, hostKeys map[string][]ssh.PublicKey) error {
+	path := t.KnownHostsPath()
+
+	knownHosts, err := server.ReadKnownHosts(path)
+	if err != nil {
+		return err
+	}
+
+	hosts := slices.Sorted(maps.Keys(hostKeys))
+	for _, host := range hosts {
+		knownHosts.SetHostKeys(host, hostKeys[host])
+	}
+
+	if err := knownHosts.Write(path); err != nil {
+		return err
+	}
+
+	return t.ConfigureKnownHosts(env)
 }
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/server/fake"
+	"github.com/roots/trellis-cli/trellis"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestServerCreateRunValidations(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	cases := []struct {
+		name            string
+		projectDetected bool
+		args            []string
+		out             string
+		code            int
+	}{
+		{
+			"no_project",
+			false,
+			nil,
+			"No Trellis project detected",
+			1,
+		},
+		{
+			"no_args",
+			true,
+			nil,
+			"Error: missing arguments (expected exactly 1, got 0)",
+			1,
+		},
+		{
+			"invalid_env",
+			true,
+			[]string{"foo"},
+			"Error: foo is not a valid environment",
+			1,
+		},
+		{
+			"development_env",
+			true,
+			[]string{"development"},
+			"server create command only supports staging/production environments",
+			1,
+		},
+		{
+			"invalid_count",
+			true,
+			[]string{"--count", "0", "production"},
+			"Error: --count must be at least 1",
+			1,
+		},
+		{
+			"missing_user_data_file",
+			true,
+			[]string{"--user-data", "missing.yml", "production"},
+			"Error reading user data file",
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			trellis := trellis.NewMockTrellis(tc.projectDetected)
+			serverCreateCommand := NewServerCreateCommand(ui, trellis)
+
+			code := serverCreateCommand.Run(tc.args)
+
+			if code != tc.code {
+				t.Errorf("expected code %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected output %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+}
+
+func TestServerCreateFirewall(t *testing.T) {
+	ui := cli.NewMockUi()
+	trellis := trellis.NewMockTrellis(true)
+	serverCreateCommand := NewServerCreateCommand(ui, trellis)
+
+	fw := serverCreateCommand.firewall()
+
+	if fw.Name != "trellis-22-80-443" {
+		t.Errorf("expected firewall name to be %q, got %q", "trellis-22-80-443", fw.Name)
+	}
+
+	trellis.CliConfig.Server.Firewall.Ports = []int{80, 443}
+	trellis.CliConfig.Server.Firewall.Name = "custom"
+	fw = serverCreateCommand.firewall()
+
+	if fw.Name != "custom" {
+		t.Errorf("expected firewall name to be %q, got %q", "custom", fw.Name)
+	}
+
+	if !strings.Contains(ui.ErrorWriter.String(), "port 22 is not included") {
+		t.Errorf("expected warning about missing SSH port, got %q", ui.ErrorWriter.String())
+	}
+
+	trellis.CliConfig.Server.Firewall.Enabled = false
+
+	if serverCreateCommand.firewall() != nil {
+		t.Error("expected no firewall when disabled")
+	}
+}
+
+func TestPlanServers(t *testing.T) {
+	cases := []struct {
+		name     string
+		roles    []string
+		count    int
+		expected []plannedServer
+	}{
+		{
+			"single",
+			[]string{"web"},
+			1,
+			[]plannedServer{{"example.com", "web"}},
+		},
+		{
+			"count",
+			[]string{"web"},
+			2,
+			[]plannedServer{{"example.com-web-1", "web"}, {"example.com-web-2", "web"}},
+		},
+		{
+			"roles",
+			[]string{"web", "db"},
+			1,
+			[]plannedServer{{"example.com-web", "web"}, {"example.com-db", "db"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			planned := planServers("example.com", tc.roles, tc.count)
+
+			if !reflect.DeepEqual(planned, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, planned)
+			}
+		})
+	}
+}
+
+func TestServerCreateFakeProvider(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	statePath := filepath.Join(t.TempDir(), "fake.json")
+	t.Setenv("TRELLIS_FAKE_PROVIDER_STATE", statePath)
+
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sshKey, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519.pub")
+	if err := os.WriteFile(keyPath, ssh.MarshalAuthorizedKey(sshKey), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the key must already exist since adding it to the account prompts for confirmation
+	provider := fake.New(statePath)
+	if _, err := provider.CreateSSHKey(context.Background(), "test", string(ssh.MarshalAuthorizedKey(sshKey))); err != nil {
+		t.Fatal(err)
+	}
+
+	ui := cli.NewMockUi()
+	ui.InputReader = strings.NewReader("\n")
+	trellis := trellis.NewMockTrellis(true)
+	trellis.CliConfig.Server.Provider = "fake"
+
+	serverCreateCommand := NewServerCreateCommand(ui, trellis)
+	code := serverCreateCommand.Run([]string{"--ssh-key", keyPath, "--region", "fake-1", "--size", "fake-small", "--skip-provision", "production"})
+
+	if code != 0 {
+		t.Fatalf("expected code 0, got %d\n%s%s", code, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+
+	servers, err := provider.GetServers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(servers) != 1 || servers[0].Name != "example.com" {
+		t.Fatalf("expected server example.com to be created, got %v", servers)
+	}
+
+	hosts, err := os.ReadFile("hosts/production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(hosts), servers[0].PublicIPv4) {
+		t.Errorf("expected hosts/production to contain %s, got\n%s", servers[0].PublicIPv4, hosts)
+	}
+
+	knownHosts, err := os.ReadFile(trellis.KnownHostsPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(knownHosts), servers[0].PublicIPv4+" ssh-ed25519 ") {
+		t.Errorf("expected known_hosts to contain the server's host key, got\n%s", knownHosts)
+	}
+
+	ui = cli.NewMockUi()
+	serverDnsCommand := NewServerDnsCommand(ui, trellis)
+	code = serverDnsCommand.Run([]string{"--auto-approve", "--ip", servers[0].PublicIPv4, "--ip-version", "v4", "production"})
+
+	if code != 0 {
+		t.Fatalf("expected code 0, got %d\n%s%s", code, ui.OutputWriter.String(), ui.ErrorWriter.String())
+	}
+
+	records, err := provider.ListRecords(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 2 {
+		t.Errorf("expected 2 records, got %v", records)
+	}
+
+	for _, record := range records {
+		if record.Type != "A" || record.Value != servers[0].PublicIPv4 {
+			t.Errorf("unexpected record %v", record)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/cli"
 	"github.com/manifoldco/promptui"
 	"github.com/posener/complete"
+	"github.com/roots/trellis-cli/dns"
 	"github.com/roots/trellis-cli/pkg/server"
 	"github.com/roots/trellis-cli/trellis"
 )
@@ -23,20 +24,36 @@ func NewServerDnsCommand(ui cli.Ui, trellis *trellis.Trellis) *ServerDnsCommand
 }
 
 type ServerDnsCommand struct {
-	UI           cli.Ui
-	Trellis      *trellis.Trellis
-	flags        *flag.FlagSet
-	providerFlag string
-	force        bool
-	ip           string
+	UI              cli.Ui
+	Trellis         *trellis.Trellis
+	flags           *flag.FlagSet
+	providerFlag    string
+	dnsProviderFlag string
+	autoApprove     bool
+	force           bool
+	prune           bool
+	ip              string
+	ipv6            string
+	ipVersion       string
+}
+
+// dnsTarget is an address DNS records are pointed to (type A for IPv4, AAAA for IPv6).
+type dnsTarget struct {
+	RecordType string
+	Value      string
 }
 
 func (c *ServerDnsCommand) init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
 	c.flags.Usage = func() { c.UI.Info(c.Help()) }
-	c.flags.StringVar(&c.providerFlag, "provider", "", "Cloud provider (digitalocean, hetzner)")
-	c.flags.BoolVar(&c.force, "force", false, "Force update of DNS records even if they exist")
-	c.flags.StringVar(&c.ip, "ip", "", "Host IP of DNS records")
+	c.flags.StringVar(&c.providerFlag, "provider", "", "Cloud provider (digitalocean, hetzner, linode)")
+	c.flags.StringVar(&c.dnsProviderFlag, "dns-provider", "", "DNS provider (digitalocean, hetzner, linode, cloudflare). Defaults to the cloud provider")
+	c.flags.BoolVar(&c.autoApprove, "auto-approve", false, "Apply DNS changes without confirmation")
+	c.flags.BoolVar(&c.force, "force", false, "Deprecated: existing records are now updated automatically")
+	c.flags.BoolVar(&c.prune, "prune", false, "Delete A/AAAA records which don't match any site host")
+	c.flags.StringVar(&c.ip, "ip", "", "Host IPv4 address of DNS records")
+	c.flags.StringVar(&c.ipv6, "ipv6", "", "Host IPv6 address of DNS records")
+	c.flags.StringVar(&c.ipVersion, "ip-version", "both", "IP version(s) of DNS records to create (v4, v6, both)")
 }
 
 func (c *ServerDnsCommand) Run(args []string) int {
@@ -74,15 +91,24 @@ func (c *ServerDnsCommand) Run(args []string) int {
 		return 1
 	}
 
-	providerName := c.resolveProvider()
+	if c.force {
+		c.UI.Warn("Warning: --force is deprecated and has no effect. Existing records are now updated automatically.\n")
+	}
+
+	if c.ipVersion != "v4" && c.ipVersion != "v6" && c.ipVersion != "both" {
+		c.UI.Error(fmt.Sprintf("Error: invalid --ip-version %q. Must be one of: v4, v6, both", c.ipVersion))
+		return 1
+	}
+
+	dnsProviderName := c.resolveDNSProvider()
 
-	token, err := server.GetProviderToken(providerName, c.UI)
+	dnsToken, err := server.GetProviderToken(dnsProviderName, serverTokenSource(c.Trellis), c.UI)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error: %s API token is required.", providerName))
+		c.UI.Error(fmt.Sprintf("Error: %s API token is required. %v", dnsProviderName, err))
 		return 1
 	}
 
-	provider, err := server.NewProviderWithDNS(providerName, token)
+	dnsProvider, err := server.NewDNSProvider(dnsProviderName, dnsToken)
 	if err != nil {
 		c.UI.Error(err.Error())
 		return 1
@@ -90,93 +116,194 @@ func (c *ServerDnsCommand) Run(args []string) int {
 
 	ctx := context.Background()
 
-	if c.ip == "" {
-		c.ip, err = c.selectIP(ctx, provider)
+	if c.needsServerSelection() {
+		providerName := c.resolveProvider()
+
+		token := dnsToken
+		if providerName != dnsProviderName {
+			token, err = server.GetProviderToken(providerName, serverTokenSource(c.Trellis), c.UI)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error: %s API token is required. %v", providerName, err))
+				return 1
+			}
+		}
+
+		provider, err := server.NewProvider(providerName, token)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+
+		srv, err := c.selectServer(ctx, provider)
 		c.UI.Info("")
 
 		if err != nil {
 			c.UI.Error("Error: can't continue without a host IP.")
 			return 1
 		}
+
+		if c.ip == "" {
+			c.ip = srv.PublicIPv4
+		}
+		if c.ipv6 == "" {
+			c.ipv6 = srv.PublicIPv6
+		}
 	}
 
-	c.UI.Info(fmt.Sprintf("DNS records for the following domains will be pointed to %s", c.ip))
+	targets, err := c.dnsTargets()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
 
 	hostsByDomain := c.Trellis.Environments[environment].AllHostsByDomain()
-	for _, hosts := range hostsByDomain {
-		for _, host := range hosts {
-			c.UI.Info("  " + host.Fqdn)
-		}
-	}
+	desired := desiredDNSRecords(hostsByDomain, targets)
 
-	c.UI.Info("")
+	plan, err := server.PlanDNS(ctx, dnsProvider, desired, c.prune)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error fetching existing DNS records: %v", err))
+		return 1
+	}
 
-	prompt := promptui.Prompt{Label: "Create DNS records", IsConfirm: true}
-	if _, err = prompt.Run(); err != nil {
+	if plan.Empty() {
+		c.UI.Info(fmt.Sprintf("%s No changes. DNS records are up to date.", color.GreenString("[✓]")))
 		return 0
 	}
 
-	for domain, hosts := range hostsByDomain {
-		// Check if zone exists, create if not
-		_, exists, err := provider.GetZone(ctx, domain)
-		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error checking domain %s: %v", domain, err))
+	c.printPlan(plan)
+
+	if !c.autoApprove {
+		prompt := promptui.Prompt{Label: "Apply DNS changes", IsConfirm: true}
+		if _, err = prompt.Run(); err != nil {
+			c.UI.Info("Aborted. No DNS changes were made.")
+			return 0
+		}
+	}
+
+	for _, domain := range plan.CreateZones {
+		if err := dnsProvider.CreateZone(ctx, domain); err != nil {
+			c.UI.Error(fmt.Sprintf("Error: could not create domain %s\n%v", domain, err))
 			return 1
 		}
 
-		if !exists {
-			if err := provider.CreateZone(ctx, domain); err != nil {
-				c.UI.Error(fmt.Sprintf("Error: could not create domain %s\n%v", domain, err))
-				return 1
-			}
+		c.UI.Info(fmt.Sprintf("%s zone %s", color.GreenString("[CREATED]"), domain))
+	}
+
+	failed := false
+
+	for _, change := range plan.Changes {
+		label := fmt.Sprintf("%s (%s)", recordFqdn(change.Domain, change.Record.Name), change.Record.Type)
+
+		if err := server.ApplyDNSChange(ctx, dnsProvider, change); err != nil {
+			failed = true
+			c.UI.Info(fmt.Sprintf("%s %s", color.RedString("[ERROR]"), label))
+			c.UI.Error(err.Error())
+			continue
 		}
 
-		// Get existing records
-		existingRecords, err := provider.ListRecords(ctx, domain)
-		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error listing records for %s: %v", domain, err))
-			return 1
+		switch change.Action {
+		case server.DNSChangeCreate:
+			c.UI.Info(fmt.Sprintf("%s %s", color.GreenString("[CREATED]"), label))
+		case server.DNSChangeUpdate:
+			c.UI.Info(fmt.Sprintf("%s %s", color.YellowString("[UPDATED]"), label))
+		case server.DNSChangeDelete:
+			c.UI.Info(fmt.Sprintf("%s %s", color.RedString("[DELETED]"), label))
 		}
+	}
+
+	if failed {
+		return 1
+	}
+
+	return 0
+}
+
+func desiredDNSRecords(hostsByDomain map[string][]dns.Host, targets []dnsTarget) map[string][]server.DNSRecord {
+	desired := map[string][]server.DNSRecord{}
 
+	for domain, hosts := range hostsByDomain {
 		for _, host := range hosts {
-			// Check if record exists
-			var existingRecord *server.DNSRecord
-			for _, r := range existingRecords {
-				if r.Type == "A" && r.Name == host.Name {
-					existingRecord = &r
-					break
-				}
+			for _, target := range targets {
+				desired[domain] = append(desired[domain], server.DNSRecord{
+					Type:  target.RecordType,
+					Name:  host.Name,
+					Value: target.Value,
+				})
 			}
+		}
+	}
 
-			if existingRecord != nil {
-				if c.force {
-					err := provider.DeleteRecord(ctx, domain, existingRecord.ID)
-					if err != nil {
-						c.UI.Error(fmt.Sprintf("Error: could not delete existing record %s\n%v", host.Fqdn, err))
-						continue
-					}
-				} else {
-					c.UI.Info(fmt.Sprintf("%s %s", color.YellowString("[SKIPPED]"), host.Fqdn))
-					continue
-				}
-			}
+	return desired
+}
 
-			_, err := provider.CreateRecord(ctx, domain, server.DNSRecord{
-				Type:  "A",
-				Name:  host.Name,
-				Value: c.ip,
-			})
-
-			if err == nil {
-				c.UI.Info(fmt.Sprintf("%s %s", color.GreenString("[CREATED]"), host.Fqdn))
-			} else {
-				c.UI.Info(fmt.Sprintf("%s %s", color.RedString("[ERROR]"), host.Fqdn))
-				c.UI.Error(err.Error())
-			}
+func recordFqdn(domain string, name string) string {
+	if name == "@" || name == "" {
+		return domain
+	}
+
+	return name + "." + domain
+}
+
+func (c *ServerDnsCommand) printPlan(plan *server.DNSPlan) {
+	c.UI.Info("The following DNS changes will be made:\n")
+
+	for _, domain := range plan.CreateZones {
+		c.UI.Info(fmt.Sprintf("  %s zone %s", color.GreenString("+"), domain))
+	}
+
+	for _, change := range plan.Changes {
+		fqdn := recordFqdn(change.Domain, change.Record.Name)
+
+		switch change.Action {
+		case server.DNSChangeCreate:
+			c.UI.Info(fmt.Sprintf("  %s %-4s %s => %s", color.GreenString("+"), change.Record.Type, fqdn, change.Record.Value))
+		case server.DNSChangeUpdate:
+			c.UI.Info(fmt.Sprintf("  %s %-4s %s => %s -> %s", color.YellowString("~"), change.Record.Type, fqdn, change.Current.Value, change.Record.Value))
+		case server.DNSChangeDelete:
+			c.UI.Info(fmt.Sprintf("  %s %-4s %s => %s", color.RedString("-"), change.Record.Type, fqdn, change.Record.Value))
 		}
 	}
 
-	return 0
+	c.UI.Info(fmt.Sprintf(
+		"\nPlan: %d to create, %d to update, %d to delete.\n",
+		plan.Count(server.DNSChangeCreate),
+		plan.Count(server.DNSChangeUpdate),
+		plan.Count(server.DNSChangeDelete),
+	))
+}
+
+func (c *ServerDnsCommand) needsServerSelection() bool {
+	switch c.ipVersion {
+	case "v4":
+		return c.ip == ""
+	case "v6":
+		return c.ipv6 == ""
+	default:
+		return c.ip == "" && c.ipv6 == ""
+	}
+}
+
+func (c *ServerDnsCommand) dnsTargets() ([]dnsTarget, error) {
+	targets := []dnsTarget{}
+
+	if c.ipVersion != "v6" && c.ip != "" {
+		targets = append(targets, dnsTarget{RecordType: "A", Value: c.ip})
+	}
+
+	if c.ipVersion != "v4" && c.ipv6 != "" {
+		targets = append(targets, dnsTarget{RecordType: "AAAA", Value: c.ipv6})
+	}
+
+	switch {
+	case c.ipVersion == "v4" && c.ip == "":
+		return nil, fmt.Errorf("Error: no IPv4 address found for server. Use --ip to set one.")
+	case c.ipVersion == "v6" && c.ipv6 == "":
+		return nil, fmt.Errorf("Error: no IPv6 address found for server. Use --ipv6 to set one.")
+	case len(targets) == 0:
+		return nil, fmt.Errorf("Error: can't continue without a host IP.")
+	}
+
+	return targets, nil
 }
 
 func (c *ServerDnsCommand) resolveProvider() server.ProviderName {
@@ -192,29 +319,77 @@ func (c *ServerDnsCommand) resolveProvider() server.ProviderName {
 	return server.ProviderDigitalOcean
 }
 
+func (c *ServerDnsCommand) resolveDNSProvider() server.ProviderName {
+	if c.dnsProviderFlag != "" {
+		return server.ProviderName(c.dnsProviderFlag)
+	}
+	if env := os.Getenv("TRELLIS_SERVER_DNS_PROVIDER"); env != "" {
+		return server.ProviderName(env)
+	}
+	if c.Trellis.CliConfig.Server.DnsProvider != "" {
+		return server.ProviderName(c.Trellis.CliConfig.Server.DnsProvider)
+	}
+	return c.resolveProvider()
+}
+
 func (c *ServerDnsCommand) Synopsis() string {
-	return "Creates DNS records for all WordPress sites' hosts in an environment"
+	return "Syncs DNS records for all WordPress sites' hosts in an environment"
 }
 
 func (c *ServerDnsCommand) Help() string {
 	helpText := `
 Usage: trellis server dns [options] ENVIRONMENT
 
-Creates DNS records for all WordPress sites' hosts in an environment.
-DNS records (type A) will be created for each host that all point to the
-server IP; the host IP can be manually overridden if need be.
+Syncs DNS records for all WordPress sites' hosts in an environment.
+DNS records for each host should all point to the server IP; the host IP can be
+manually overridden if need be.
+
+The desired records are compared against the provider's existing records and a
+plan of the records to create, update, or delete is shown. Changes are only
+applied after confirmation (or with --auto-approve).
+
+Existing records which trellis-cli doesn't manage are left alone, including
+other A/AAAA records for a site host (eg: round-robin DNS across servers). Use
+--prune to delete any A/AAAA records in the domains which don't match the
+desired records. Only the record types being synced are pruned (eg: AAAA
+records are kept with --ip-version v4). Other record types (MX, TXT, etc) are
+never changed.
+
+Type A records are created for the server's IPv4 address and type AAAA records
+for its IPv6 address (if it has one). Use --ip-version to only create one type.
 
 Supported providers:
   - digitalocean (default)
   - hetzner
+  - linode
 
 The provider can be configured via:
   1. --provider flag
   2. TRELLIS_SERVER_PROVIDER environment variable
   3. server.provider in trellis.cli.yml
 
-Note: this command assumes your domain's DNS is managed by the cloud provider
-and the nameservers have already been set appropriately.
+DNS can be managed by a different provider than the servers (eg: servers on
+Hetzner with DNS on Cloudflare). The cloud provider is then only used to select
+the server IP.
+
+Supported DNS providers:
+  - digitalocean
+  - hetzner
+  - linode
+  - cloudflare (API token via CLOUDFLARE_API_TOKEN)
+
+The DNS provider can be configured via:
+  1. --dns-provider flag
+  2. TRELLIS_SERVER_DNS_PROVIDER environment variable
+  3. server.dns_provider in trellis.cli.yml
+  4. the cloud provider (default)
+
+Creating new Cloudflare zones requires the CLOUDFLARE_ACCOUNT_ID environment
+variable when the API token has access to multiple accounts. New Linode zones use
+the account's email as their SOA email unless LINODE_SOA_EMAIL is set.
+
+Note: this command assumes your domain's nameservers have already been set
+appropriately for the DNS provider.
 
 This command only supports Trellis' standard setup of one server per environment.
 If your sites are split across multiple servers, then this command won't work and
@@ -224,22 +399,38 @@ Create DNS records for the production server:
 
   $ trellis server dns production
 
-Force re-creation of existing DNS records:
+Apply changes without confirmation:
 
-  $ trellis server dns --force production
+  $ trellis server dns --auto-approve production
+
+Also delete A/AAAA records which aren't for any site host:
+
+  $ trellis server dns --prune production
 
 Manually specify the host IP to use:
 
   $ trellis server dns --ip 1.2.3.4 production
 
+Manually specify both the IPv4 and IPv6 addresses to use:
+
+  $ trellis server dns --ip 1.2.3.4 --ipv6 2001:db8::1 production
+
+Only create AAAA (IPv6) records:
+
+  $ trellis server dns --ip-version v6 production
+
 Arguments:
   ENVIRONMENT Name of environment (ie: production)
 
 Options:
-      --provider  Cloud provider (digitalocean, hetzner)
-      --force     Force updating DNS records even if they already exist
-      --ip        Host IP of DNS records
-  -h, --help      Show this help
+      --provider      Cloud provider (digitalocean, hetzner, linode)
+      --dns-provider  DNS provider (digitalocean, hetzner, linode, cloudflare)
+      --auto-approve  Apply DNS changes without confirmation
+      --prune         Delete A/AAAA records which don't match any site host
+      --ip            Host IPv4 address of DNS records
+      --ipv6          Host IPv6 address of DNS records
+      --ip-version    IP version(s) of DNS records to create: v4, v6, both (default: both)
+  -h, --help          Show this help
 `
 
 	return strings.TrimSpace(helpText)
@@ -251,23 +442,27 @@ func (c *ServerDnsCommand) AutocompleteArgs() complete.Predictor {
 
 func (c *ServerDnsCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
-		"--provider": complete.PredictSet("digitalocean", "hetzner"),
-		"--ip":       complete.PredictNothing,
-		"--force":    complete.PredictNothing,
+		"--provider":     complete.PredictSet("digitalocean", "hetzner", "linode"),
+		"--dns-provider": complete.PredictSet("digitalocean", "hetzner", "linode", "cloudflare"),
+		"--auto-approve": complete.PredictNothing,
+		"--prune":        complete.PredictNothing,
+		"--ip":           complete.PredictNothing,
+		"--ipv6":         complete.PredictNothing,
+		"--ip-version":   complete.PredictSet("v4", "v6", "both"),
 	}
 }
 
-func (c *ServerDnsCommand) selectIP(ctx context.Context, provider server.Provider) (string, error) {
+func (c *ServerDnsCommand) selectServer(ctx context.Context, provider server.Provider) (*server.Server, error) {
 	servers, err := provider.GetServers(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if len(servers) == 0 {
-		return "", fmt.Errorf("no servers found")
+		return nil, fmt.Errorf("no servers found")
 	}
 
-	tpl := `{{.Name}} [{{.PublicIPv4 | faint}}]`
+	tpl := `{{.Name}} [{{.PublicIPv4 | faint}}{{ if .PublicIPv6 }} {{.PublicIPv6 | faint}}{{ end }}]`
 
 	templates := &promptui.SelectTemplates{
 		Active:   fmt.Sprintf("%s %s", promptui.IconSelect, tpl),
@@ -280,7 +475,7 @@ func (c *ServerDnsCommand) selectIP(ctx context.Context, provider server.Provide
 	}
 
 	prompt := promptui.Select{
-		Label:     "Select Server IP",
+		Label:     "Select Server",
 		Templates: templates,
 		Items:     servers,
 		Size:      len(servers),
@@ -288,8 +483,8 @@ func (c *ServerDnsCommand) selectIP(ctx context.Context, provider server.Provide
 
 	i, _, err := prompt.Run()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return servers[i].PublicIPv4, nil
+	return &servers[i], nil
 }
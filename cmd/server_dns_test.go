@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestServerDnsRunValidations(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	cases := []struct {
+		name            string
+		projectDetected bool
+		args            []string
+		out             string
+		code            int
+	}{
+		{
+			"no_project",
+			false,
+			nil,
+			"No Trellis project detected",
+			1,
+		},
+		{
+			"no_args",
+			true,
+			nil,
+			"Error: missing arguments (expected exactly 1, got 0)",
+			1,
+		},
+		{
+			"invalid_env",
+			true,
+			[]string{"foo"},
+			"Error: foo is not a valid environment",
+			1,
+		},
+		{
+			"development_env",
+			true,
+			[]string{"development"},
+			"dns command only supports non-development environments",
+			1,
+		},
+		{
+			"invalid_ip_version",
+			true,
+			[]string{"--ip-version", "v5", "production"},
+			`Error: invalid --ip-version "v5". Must be one of: v4, v6, both`,
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			trellis := trellis.NewMockTrellis(tc.projectDetected)
+			serverDnsCommand := NewServerDnsCommand(ui, trellis)
+
+			code := serverDnsCommand.Run(tc.args)
+
+			if code != tc.code {
+				t.Errorf("expected code %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected output %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+}
+
+func TestServerDnsTargets(t *testing.T) {
+	cases := []struct {
+		name      string
+		ipVersion string
+		ip        string
+		ipv6      string
+		expected  []dnsTarget
+		err       string
+	}{
+		{
+			"both",
+			"both",
+			"1.2.3.4",
+			"2001:db8::1",
+			[]dnsTarget{{"A", "1.2.3.4"}, {"AAAA", "2001:db8::1"}},
+			"",
+		},
+		{
+			"both_without_ipv6",
+			"both",
+			"1.2.3.4",
+			"",
+			[]dnsTarget{{"A", "1.2.3.4"}},
+			"",
+		},
+		{
+			"v4_only",
+			"v4",
+			"1.2.3.4",
+			"2001:db8::1",
+			[]dnsTarget{{"A", "1.2.3.4"}},
+			"",
+		},
+		{
+			"v6_only",
+			"v6",
+			"1.2.3.4",
+			"2001:db8::1",
+			[]dnsTarget{{"AAAA", "2001:db8::1"}},
+			"",
+		},
+		{
+			"v6_missing",
+			"v6",
+			"1.2.3.4",
+			"",
+			nil,
+			"Error: no IPv6 address found for server. Use --ipv6 to set one.",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewServerDnsCommand(cli.NewMockUi(), trellis.NewMockTrellis(true))
+			c.ipVersion = tc.ipVersion
+			c.ip = tc.ip
+			c.ipv6 = tc.ipv6
+
+			targets, err := c.dnsTargets()
+
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(targets, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, targets)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/posener/complete"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func NewServerImagesCommand(ui cli.Ui, trellis *trellis.Trellis) *ServerImagesCommand {
+	c := &ServerImagesCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+type ServerImagesCommand struct {
+	UI           cli.Ui
+	Trellis      *trellis.Trellis
+	flags        *flag.FlagSet
+	providerFlag string
+	json         bool
+}
+
+func (c *ServerImagesCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+	c.flags.StringVar(&c.providerFlag, "provider", "", "Cloud provider (digitalocean, hetzner, linode)")
+	c.flags.BoolVar(&c.json, "json", false, "Output as JSON")
+}
+
+func (c *ServerImagesCommand) Run(args []string) int {
+	// a project is optional; it's only loaded for its server config
+	_ = c.Trellis.LoadProject()
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	provider, err := newCatalogProvider(c.UI, c.Trellis, c.providerFlag)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	images, err := provider.GetImages(context.Background())
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error fetching images: %v", err))
+		return 1
+	}
+
+	if c.json {
+		jsonBytes, err := json.MarshalIndent(images, "", "  ")
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error encoding JSON: %s", err))
+			return 1
+		}
+		c.UI.Output(string(jsonBytes))
+		return 0
+	}
+
+	rows := make([][]string, len(images))
+	for i, img := range images {
+		rows[i] = []string{img.Slug, img.Name, img.Architecture, fmt.Sprintf("%d GB", img.MinDiskSize)}
+	}
+
+	c.UI.Output(formatTable([]string{"slug", "name", "arch", "min disk"}, rows))
+	return 0
+}
+
+func (c *ServerImagesCommand) Synopsis() string {
+	return "Lists the OS images of a cloud provider"
+}
+
+func (c *ServerImagesCommand) Help() string {
+	helpText := `
+Usage: trellis server images [options]
+
+Lists the public OS images servers can be created from.
+Use the slug with 'trellis server create --image'.
+
+Note: Trellis only supports Ubuntu LTS releases.
+
+List images of the configured provider:
+
+  $ trellis server images
+
+List Hetzner images as JSON:
+
+  $ trellis server images --provider hetzner --json
+
+Options:
+      --provider  Cloud provider (digitalocean, hetzner, linode). Defaults to server.provider
+      --json      Output as JSON
+  -h, --help      show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ServerImagesCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"--provider": complete.PredictSet("digitalocean", "hetzner", "linode"),
+		"--json":     complete.PredictNothing,
+	}
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/posener/complete"
+	"github.com/roots/trellis-cli/pkg/server"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func NewServerKnownHostsCommand(ui cli.Ui, trellis *trellis.Trellis) *ServerKnownHostsCommand {
+	c := &ServerKnownHostsCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+type ServerKnownHostsCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+	refresh bool
+	port    string
+}
+
+func (c *ServerKnownHostsCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+	c.flags.BoolVar(&c.refresh, "refresh", false, "Replace recorded host keys which have changed (eg: after a server was rebuilt)")
+	c.flags.StringVar(&c.port, "port", "22", "SSH port of the servers")
+}
+
+func (c *ServerKnownHostsCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 1, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	environment := args[0]
+
+	environmentErr := c.Trellis.ValidateEnvironment(environment)
+	if environmentErr != nil {
+		c.UI.Error(environmentErr.Error())
+		return 1
+	}
+
+	inventory, err := trellis.ReadInventory(c.Trellis.InventoryPath(environment))
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading hosts/%s: %s", environment, err))
+		return 1
+	}
+
+	hosts := inventory.GroupHosts(environment)
+	if len(hosts) == 0 {
+		hosts = inventory.GroupHosts("web")
+	}
+
+	if len(hosts) == 0 {
+		c.UI.Error(fmt.Sprintf("Error: no servers found in hosts/%s", environment))
+		return 1
+	}
+
+	path := c.Trellis.KnownHostsPath()
+
+	knownHosts, err := server.ReadKnownHosts(path)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading %s: %s", path, err))
+		return 1
+	}
+
+	failed := false
+
+	for _, host := range hosts {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		keys, err := server.ScanHostKeys(ctx, net.JoinHostPort(host, c.port))
+		cancel()
+
+		if err != nil {
+			failed = true
+			c.UI.Error(fmt.Sprintf("%s %s: %s", color.RedString("[✗]"), host, err))
+			continue
+		}
+
+		knownHost := host
+		if c.port != "22" {
+			knownHost = net.JoinHostPort(host, c.port)
+		}
+
+		recorded := knownHosts.HostKeys(knownHost)
+
+		switch {
+		case len(recorded) == 0:
+			knownHosts.SetHostKeys(knownHost, keys)
+			c.UI.Info(fmt.Sprintf("%s %s: added host keys", color.GreenString("[✓]"), host))
+		case server.SameHostKeys(recorded, keys):
+			c.UI.Info(fmt.Sprintf("%s %s: host keys unchanged", color.GreenString("[✓]"), host))
+			continue
+		case c.refresh:
+			knownHosts.SetHostKeys(knownHost, keys)
+			c.UI.Info(fmt.Sprintf("%s %s: replaced changed host keys", color.YellowString("[✓]"), host))
+		default:
+			failed = true
+			c.UI.Error(fmt.Sprintf("%s %s: host keys have changed!", color.RedString("[✗]"), host))
+			c.UI.Error("  This is expected if the server was rebuilt; otherwise someone may be intercepting the connection.")
+			c.UI.Error(fmt.Sprintf("  Run `trellis server known-hosts --refresh %s` to replace them.", environment))
+			continue
+		}
+
+		for _, fingerprint := range server.HostKeyFingerprints(keys) {
+			c.UI.Info(fmt.Sprintf("    %s", fingerprint))
+		}
+	}
+
+	if err := knownHosts.Write(path); err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing %s: %s", path, err))
+		return 1
+	}
+
+	if err := c.Trellis.ConfigureKnownHosts(environment); err != nil {
+		c.UI.Warn(fmt.Sprintf("Warning: could not configure Ansible to use %s: %s", path, err))
+	}
+
+	if failed {
+		return 1
+	}
+
+	return 0
+}
+
+func (c *ServerKnownHostsCommand) Synopsis() string {
+	return "Records the SSH host keys of an environment's servers"
+}
+
+func (c *ServerKnownHostsCommand) Help() string {
+	helpText := `
+Usage: trellis server known-hosts [options] ENVIRONMENT
+
+Connects to each server in the environment's inventory (hosts/ENVIRONMENT),
+records its SSH host keys in the project's known_hosts file (.trellis/known_hosts),
+and configures Ansible to verify servers against it (ssh_common_args in ansible.cfg
+and ansible_host_key_checking=true in the [ENVIRONMENT:vars] of hosts/ENVIRONMENT).
+
+'trellis server create' records host keys automatically. Use this command for
+existing servers or servers which were rebuilt.
+
+Host keys which don't match the recorded ones are reported and not replaced
+unless --refresh is used.
+
+Record host keys for production servers:
+
+  $ trellis server known-hosts production
+
+Replace host keys after a server was rebuilt:
+
+  $ trellis server known-hosts --refresh production
+
+Arguments:
+  ENVIRONMENT Name of environment (ie: production)
+
+Options:
+      --refresh  Replace recorded host keys which have changed
+      --port     SSH port of the servers (default: 22)
+  -h, --help     show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ServerKnownHostsCommand) AutocompleteArgs() complete.Predictor {
+	return c.Trellis.AutocompleteEnvironment(c.flags)
+}
+
+func (c *ServerKnownHostsCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"--refresh": complete.PredictNothing,
+		"--port":    complete.PredictNothing,
+	}
+}
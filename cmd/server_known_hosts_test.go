@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/server"
+	"github.com/roots/trellis-cli/trellis"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestServerKnownHostsRunValidations(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	cases := []struct {
+		name            string
+		projectDetected bool
+		args            []string
+		out             string
+		code            int
+	}{
+		{
+			"no_project",
+			false,
+			nil,
+			"No Trellis project detected",
+			1,
+		},
+		{
+			"no_args",
+			true,
+			nil,
+			"Error: missing arguments (expected exactly 1, got 0)",
+			1,
+		},
+		{
+			"invalid_env",
+			true,
+			[]string{"foo"},
+			"Error: foo is not a valid environment",
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			trellis := trellis.NewMockTrellis(tc.projectDetected)
+			serverKnownHostsCommand := NewServerKnownHostsCommand(ui, trellis)
+
+			code := serverKnownHostsCommand.Run(tc.args)
+
+			if code != tc.code {
+				t.Errorf("expected code %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected output %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+}
+
+func TestServerKnownHostsRun(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	addr, hostKey := server.StartTestSSHServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+
+	if err := os.WriteFile("hosts/production", []byte("[production]\n"+host+"\n\n[web]\n"+host+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("ansible.cfg", []byte("[defaults]\ninventory = hosts\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	trellis := trellis.NewTrellis()
+
+	ui := cli.NewMockUi()
+	code := NewServerKnownHostsCommand(ui, trellis).Run([]string{"--port", port, "production"})
+
+	if code != 0 {
+		t.Fatalf("expected code 0, got %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	if !strings.Contains(ui.OutputWriter.String(), host+": added host keys") {
+		t.Errorf("unexpected output %q", ui.OutputWriter.String())
+	}
+
+	knownHosts, err := server.ReadKnownHosts(trellis.KnownHostsPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !server.SameHostKeys(knownHosts.HostKeys(addr), []ssh.PublicKey{hostKey}) {
+		t.Errorf("expected host key to be recorded for %s", addr)
+	}
+
+	cfg, _ := os.ReadFile("ansible.cfg")
+	if !strings.Contains(string(cfg), ".trellis/known_hosts") {
+		t.Errorf("expected ansible.cfg to use known_hosts file, got:\n%s", cfg)
+	}
+
+	// simulate a rebuilt server with a different host key
+	_, otherKey := server.StartTestSSHServer(t)
+	knownHosts.SetHostKeys(addr, []ssh.PublicKey{otherKey})
+	if err := knownHosts.Write(trellis.KnownHostsPath()); err != nil {
+		t.Fatal(err)
+	}
+
+	ui = cli.NewMockUi()
+	code = NewServerKnownHostsCommand(ui, trellis).Run([]string{"--port", port, "production"})
+
+	if code != 1 || !strings.Contains(ui.ErrorWriter.String(), host+": host keys have changed!") {
+		t.Errorf("expected changed host key error, got %d: %q", code, ui.ErrorWriter.String())
+	}
+
+	ui = cli.NewMockUi()
+	code = NewServerKnownHostsCommand(ui, trellis).Run([]string{"--port", port, "--refresh", "production"})
+
+	if code != 0 || !strings.Contains(ui.OutputWriter.String(), host+": replaced changed host keys") {
+		t.Errorf("expected host keys to be refreshed, got %d: %q", code, ui.OutputWriter.String())
+	}
+}
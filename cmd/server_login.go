@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/posener/complete"
+	"github.com/roots/trellis-cli/pkg/server"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func NewServerLoginCommand(ui cli.Ui, trellis *trellis.Trellis) *ServerLoginCommand {
+	c := &ServerLoginCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+type ServerLoginCommand struct {
+	UI           cli.Ui
+	Trellis      *trellis.Trellis
+	flags        *flag.FlagSet
+	providerFlag string
+	profile      string
+}
+
+func (c *ServerLoginCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+	c.flags.StringVar(&c.providerFlag, "provider", "", "Provider to save a token for (digitalocean, hetzner, linode, cloudflare)")
+	c.flags.StringVar(&c.profile, "profile", "", "Profile to save the token in (default: active profile)")
+}
+
+func (c *ServerLoginCommand) Run(args []string) int {
+	// a project is optional; it's only loaded to find the profile it uses
+	_ = c.Trellis.LoadProject()
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	provider, err := resolveCredentialProvider(c.Trellis, c.providerFlag)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	profile := c.profile
+	if profile == "" {
+		profile = activeServerProfile(c.Trellis)
+	}
+
+	path := server.CredentialsPath()
+
+	credentials, err := server.LoadCredentials(path)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error: %s", err))
+		return 1
+	}
+
+	token, err := c.UI.AskSecret(fmt.Sprintf("Enter %s API token for the %s profile:", provider, profile))
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		c.UI.Error("Error: API token can't be empty.")
+		return 1
+	}
+
+	credentials.SetToken(profile, provider, token)
+
+	if err := credentials.Save(path); err != nil {
+		c.UI.Error(fmt.Sprintf("Error saving credentials file %s: %s", path, err))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("%s Saved %s token to the %s profile [%s]", color.GreenString("[✓]"), provider, profile, path))
+
+	if envVar := server.TokenEnvVar(provider); os.Getenv(envVar) != "" {
+		c.UI.Warn(fmt.Sprintf("Note: %s is set and takes precedence over saved profiles.", envVar))
+	}
+
+	return 0
+}
+
+// resolveCredentialProvider returns the provider from the flag, or the project's configured cloud provider.
+func resolveCredentialProvider(t *trellis.Trellis, flagValue string) (server.ProviderName, error) {
+	provider := server.ProviderName(flagValue)
+
+	if provider == "" {
+		provider = server.ProviderName(os.Getenv("TRELLIS_SERVER_PROVIDER"))
+	}
+	if provider == "" {
+		provider = server.ProviderName(t.CliConfig.Server.Provider)
+	}
+	if provider == "" {
+		provider = server.ProviderDigitalOcean
+	}
+
+	// the fake provider isn't listed as supported but can be selected for tests and demos
+	if provider != server.ProviderFake && !slices.Contains(server.SupportedProviders(), provider) && !slices.Contains(server.SupportedDNSProviders(), provider) {
+		return "", fmt.Errorf("Error: unsupported provider %s", provider)
+	}
+
+	return provider, nil
+}
+
+func (c *ServerLoginCommand) Synopsis() string {
+	return "Saves a cloud provider API token to a credentials profile"
+}
+
+func (c *ServerLoginCommand) Help() string {
+	helpText := `
+Usage: trellis server login [options]
+
+Saves a cloud provider API token to a named credentials profile so it doesn't
+need to be entered on every run. Profiles allow using multiple accounts (eg: an
+agency account and a client account).
+
+Tokens are stored in a global credentials file only readable by the current
+user (see 'trellis server profiles'). A project chooses which profile it uses
+with server.profile in trellis.cli.yml.
+
+Save a token for the configured provider in the active profile:
+
+  $ trellis server login
+
+Save a Hetzner token in the client profile:
+
+  $ trellis server login --provider hetzner --profile client
+
+Options:
+      --provider  Provider (digitalocean, hetzner, linode, cloudflare). Defaults to server.provider
+      --profile   Profile name (default: active profile)
+  -h, --help      show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ServerLoginCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"--provider": complete.PredictSet("digitalocean", "hetzner", "linode", "cloudflare"),
+		"--profile":  complete.PredictNothing,
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestServerLoginLogout(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	configDir := t.TempDir()
+	t.Setenv("TRELLIS_CONFIG_DIR", configDir)
+	t.Setenv("TRELLIS_SERVER_PROFILE", "")
+	t.Setenv("HCLOUD_TOKEN", "")
+
+	trellis := trellis.NewTrellis()
+	trellis.CliConfig.Server.Profile = "agency"
+
+	ui := cli.NewMockUi()
+	ui.InputReader = strings.NewReader("hetzner-token\n")
+
+	if code := NewServerLoginCommand(ui, trellis).Run([]string{"--provider", "hetzner"}); code != 0 {
+		t.Fatalf("expected login to succeed, got %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	if !strings.Contains(ui.OutputWriter.String(), "Saved hetzner token to the agency profile") {
+		t.Errorf("unexpected login output %q", ui.OutputWriter.String())
+	}
+
+	content, err := os.ReadFile(filepath.Join(configDir, "credentials.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(content), "hetzner: hetzner-token") {
+		t.Errorf("expected token to be saved, got %q", content)
+	}
+
+	ui = cli.NewMockUi()
+	if code := NewServerProfilesCommand(ui, trellis).Run(nil); code != 0 {
+		t.Fatalf("expected profiles to succeed, got %d", code)
+	}
+
+	if !strings.Contains(ui.OutputWriter.String(), "* agency (hetzner)") {
+		t.Errorf("expected active agency profile to be listed, got %q", ui.OutputWriter.String())
+	}
+
+	ui = cli.NewMockUi()
+	if code := NewServerLogoutCommand(ui, trellis).Run([]string{"--provider", "digitalocean"}); code != 1 {
+		t.Errorf("expected logout of missing token to fail, got %d", code)
+	}
+
+	if !strings.Contains(ui.ErrorWriter.String(), "Error: no digitalocean token saved in the agency profile.") {
+		t.Errorf("unexpected logout error %q", ui.ErrorWriter.String())
+	}
+
+	ui = cli.NewMockUi()
+	if code := NewServerLogoutCommand(ui, trellis).Run(nil); code != 0 {
+		t.Fatalf("expected logout to succeed, got %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	ui = cli.NewMockUi()
+	NewServerProfilesCommand(ui, trellis).Run(nil)
+
+	if !strings.Contains(ui.OutputWriter.String(), "No profiles saved.") {
+		t.Errorf("expected no profiles, got %q", ui.OutputWriter.String())
+	}
+}
+
+func TestServerLoginInvalidProvider(t *testing.T) {
+	ui := cli.NewMockUi()
+	trellis := trellis.NewMockTrellis(false)
+
+	code := NewServerLoginCommand(ui, trellis).Run([]string{"--provider", "aws"})
+
+	if code != 1 || !strings.Contains(ui.ErrorWriter.String(), "Error: unsupported provider aws") {
+		t.Errorf("expected unsupported provider error, got %d %q", code, ui.ErrorWriter.String())
+	}
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/posener/complete"
+	"github.com/roots/trellis-cli/pkg/server"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func NewServerLogoutCommand(ui cli.Ui, trellis *trellis.Trellis) *ServerLogoutCommand {
+	c := &ServerLogoutCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+type ServerLogoutCommand struct {
+	UI           cli.Ui
+	Trellis      *trellis.Trellis
+	flags        *flag.FlagSet
+	providerFlag string
+	profile      string
+}
+
+func (c *ServerLogoutCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+	c.flags.StringVar(&c.providerFlag, "provider", "", "Only remove the token for this provider (digitalocean, hetzner, linode, cloudflare)")
+	c.flags.StringVar(&c.profile, "profile", "", "Profile to remove tokens from (default: active profile)")
+}
+
+func (c *ServerLogoutCommand) Run(args []string) int {
+	// a project is optional; it's only loaded to find the profile it uses
+	_ = c.Trellis.LoadProject()
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	var provider server.ProviderName
+
+	if c.providerFlag != "" {
+		var err error
+		provider, err = resolveCredentialProvider(c.Trellis, c.providerFlag)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+	}
+
+	profile := c.profile
+	if profile == "" {
+		profile = activeServerProfile(c.Trellis)
+	}
+
+	path := server.CredentialsPath()
+
+	credentials, err := server.LoadCredentials(path)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error: %s", err))
+		return 1
+	}
+
+	if !credentials.Remove(profile, provider) {
+		if provider == "" {
+			c.UI.Error(fmt.Sprintf("Error: profile %s not found.", profile))
+		} else {
+			c.UI.Error(fmt.Sprintf("Error: no %s token saved in the %s profile.", provider, profile))
+		}
+		return 1
+	}
+
+	if err := credentials.Save(path); err != nil {
+		c.UI.Error(fmt.Sprintf("Error saving credentials file %s: %s", path, err))
+		return 1
+	}
+
+	if provider == "" {
+		c.UI.Info(fmt.Sprintf("%s Removed the %s profile", color.GreenString("[✓]"), profile))
+	} else {
+		c.UI.Info(fmt.Sprintf("%s Removed %s token from the %s profile", color.GreenString("[✓]"), provider, profile))
+	}
+
+	return 0
+}
+
+func (c *ServerLogoutCommand) Synopsis() string {
+	return "Removes saved cloud provider API tokens from a credentials profile"
+}
+
+func (c *ServerLogoutCommand) Help() string {
+	helpText := `
+Usage: trellis server logout [options]
+
+Removes API tokens saved with 'trellis server login'.
+By default the whole active profile is removed.
+
+Remove the active profile:
+
+  $ trellis server logout
+
+Remove only the Cloudflare token from the client profile:
+
+  $ trellis server logout --provider cloudflare --profile client
+
+Options:
+      --provider  Only remove the token for this provider
+      --profile   Profile name (default: active profile)
+  -h, --help      show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ServerLogoutCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"--provider": complete.PredictSet("digitalocean", "hetzner", "linode", "cloudflare"),
+		"--profile":  complete.PredictNothing,
+	}
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/posener/complete"
+	"github.com/roots/trellis-cli/pkg/server"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func NewServerProfilesCommand(ui cli.Ui, trellis *trellis.Trellis) *ServerProfilesCommand {
+	c := &ServerProfilesCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+type ServerProfilesCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+}
+
+func (c *ServerProfilesCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+}
+
+func (c *ServerProfilesCommand) Run(args []string) int {
+	// a project is optional; it's only loaded to find the profile it uses
+	_ = c.Trellis.LoadProject()
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	credentials, err := server.LoadCredentials(server.CredentialsPath())
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error: %s", err))
+		return 1
+	}
+
+	active := activeServerProfile(c.Trellis)
+
+	if helper := c.Trellis.CliConfig.Server.CredentialHelper; helper != "" {
+		c.UI.Info(fmt.Sprintf("Tokens are fetched with the credential helper: %s\n", helper))
+	}
+
+	names := credentials.ProfileNames()
+	if len(names) == 0 {
+		c.UI.Info("No profiles saved. Run `trellis server login` to save a provider token.")
+		return 0
+	}
+
+	for _, name := range names {
+		marker := " "
+		if name == active {
+			marker = "*"
+		}
+
+		c.UI.Info(fmt.Sprintf("%s %s (%s)", marker, name, strings.Join(credentials.ProfileProviders(name), ", ")))
+	}
+
+	return 0
+}
+
+// activeServerProfile returns the credentials profile to use for provider tokens.
+func activeServerProfile(t *trellis.Trellis) string {
+	if env := os.Getenv("TRELLIS_SERVER_PROFILE"); env != "" {
+		return env
+	}
+	if t.CliConfig.Server.Profile != "" {
+		return t.CliConfig.Server.Profile
+	}
+	return server.DefaultProfile
+}
+
+func serverTokenSource(t *trellis.Trellis) server.TokenSource {
+	return server.TokenSource{
+		Profile: activeServerProfile(t),
+		Helper:  t.CliConfig.Server.CredentialHelper,
+	}
+}
+
+func (c *ServerProfilesCommand) Synopsis() string {
+	return "Lists saved cloud provider credential profiles"
+}
+
+func (c *ServerProfilesCommand) Help() string {
+	helpText := `
+Usage: trellis server profiles [options]
+
+Lists the credential profiles saved with 'trellis server login' and the
+providers each one has a token for. The active profile is marked with *.
+
+Profiles are stored in a credentials file only readable by the current user
+in the global config directory ($HOME/.config/trellis/credentials.yml by default).
+
+The active profile is configured via (in order of precedence):
+  1. TRELLIS_SERVER_PROFILE environment variable
+  2. server.profile in trellis.cli.yml (or the global CLI config)
+  3. "default"
+
+Provider API tokens are looked up in this order:
+  1. Provider environment variable (DIGITALOCEAN_ACCESS_TOKEN, HCLOUD_TOKEN, LINODE_TOKEN, CLOUDFLARE_API_TOKEN)
+  2. server.credential_helper command (if configured)
+  3. The active profile in the credentials file
+
+Options:
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ServerProfilesCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/posener/complete"
+	"github.com/roots/trellis-cli/pkg/server"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func NewServerRegionsCommand(ui cli.Ui, trellis *trellis.Trellis) *ServerRegionsCommand {
+	c := &ServerRegionsCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+type ServerRegionsCommand struct {
+	UI           cli.Ui
+	Trellis      *trellis.Trellis
+	flags        *flag.FlagSet
+	providerFlag string
+	json         bool
+}
+
+func (c *ServerRegionsCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+	c.flags.StringVar(&c.providerFlag, "provider", "", "Cloud provider (digitalocean, hetzner, linode)")
+	c.flags.BoolVar(&c.json, "json", false, "Output as JSON")
+}
+
+func (c *ServerRegionsCommand) Run(args []string) int {
+	// a project is optional; it's only loaded for its server config
+	_ = c.Trellis.LoadProject()
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	provider, err := newCatalogProvider(c.UI, c.Trellis, c.providerFlag)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	regions, err := provider.GetRegions(context.Background())
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error fetching regions: %v", err))
+		return 1
+	}
+
+	if c.json {
+		jsonBytes, err := json.MarshalIndent(regions, "", "  ")
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error encoding JSON: %s", err))
+			return 1
+		}
+		c.UI.Output(string(jsonBytes))
+		return 0
+	}
+
+	rows := make([][]string, len(regions))
+	for i, r := range regions {
+		rows[i] = []string{r.Slug, r.Name, r.Country}
+	}
+
+	c.UI.Output(formatTable([]string{"slug", "name", "country"}, rows))
+	return 0
+}
+
+// newCatalogProvider creates the cloud provider used to list regions, sizes, and images.
+func newCatalogProvider(ui cli.Ui, t *trellis.Trellis, providerFlag string) (server.Provider, error) {
+	providerName, err := resolveCredentialProvider(t, providerFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := server.GetProviderToken(providerName, serverTokenSource(t), ui)
+	if err != nil {
+		return nil, fmt.Errorf("Error: %s API token is required. %v", providerName, err)
+	}
+
+	return server.NewProvider(providerName, token)
+}
+
+func (c *ServerRegionsCommand) Synopsis() string {
+	return "Lists the regions of a cloud provider"
+}
+
+func (c *ServerRegionsCommand) Help() string {
+	helpText := `
+Usage: trellis server regions [options]
+
+Lists the regions/locations servers can be created in.
+
+List regions of the configured provider:
+
+  $ trellis server regions
+
+List Hetzner regions as JSON:
+
+  $ trellis server regions --provider hetzner --json
+
+Options:
+      --provider  Cloud provider (digitalocean, hetzner, linode). Defaults to server.provider
+      --json      Output as JSON
+  -h, --help      show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ServerRegionsCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"--provider": complete.PredictSet("digitalocean", "hetzner", "linode"),
+		"--json":     complete.PredictNothing,
+	}
+}
@@ -0,0 +1,329 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/posener/complete"
+	"github.com/roots/trellis-cli/command"
+	"github.com/roots/trellis-cli/pkg/server"
+	"github.com/roots/trellis-cli/trellis"
+	"golang.org/x/crypto/ssh"
+)
+
+// supportedUbuntuVersions are the Ubuntu releases Trellis can provision.
+var supportedUbuntuVersions = []string{"22.04", "24.04"}
+
+func NewServerRegisterCommand(ui cli.Ui, trellis *trellis.Trellis) *ServerRegisterCommand {
+	c := &ServerRegisterCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+type ServerRegisterCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+	user    string
+	port    string
+	sshKey  string
+	// knownHostsPath is the known_hosts file SSH trusts while the server is checked
+	knownHostsPath string
+}
+
+func (c *ServerRegisterCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+	c.flags.StringVar(&c.user, "user", "root", "User to connect as")
+	c.flags.StringVar(&c.port, "port", "22", "SSH port of the server")
+	c.flags.StringVar(&c.sshKey, "ssh-key", "", "Path to an SSH public key to add to the user's authorized_keys")
+}
+
+func (c *ServerRegisterCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 2, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	environment := args[0]
+	host := args[1]
+
+	environmentErr := c.Trellis.ValidateEnvironment(environment)
+	if environmentErr != nil {
+		c.UI.Error(environmentErr.Error())
+		return 1
+	}
+
+	if environment == "development" {
+		c.UI.Error("server register command only supports staging/production environments")
+		return 1
+	}
+
+	var publicKey []byte
+	if c.sshKey != "" {
+		_, contents, _, err := server.LoadSSHKey([]string{c.sshKey})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error: %s", err))
+			return 1
+		}
+		publicKey = contents
+	}
+
+	// Host keys
+	addr := net.JoinHostPort(host, c.port)
+	knownHost := host
+	if c.port != "22" {
+		knownHost = addr
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	keys, err := server.ScanHostKeys(ctx, addr)
+	cancel()
+
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error: could not connect to %s: %s", host, err))
+		return 1
+	}
+
+	if err := c.checkRecordedHostKeys(knownHost, keys); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("%s Scanned SSH host keys of %s", color.GreenString("[✓]"), knownHost))
+	for _, fingerprint := range server.HostKeyFingerprints(keys) {
+		c.UI.Info(fmt.Sprintf("    %s", fingerprint))
+	}
+
+	// The scanned keys are only trusted for the checks below until the server is registered
+	c.knownHostsPath = c.Trellis.KnownHostsPath() + ".pending"
+	defer os.Remove(c.knownHostsPath)
+
+	pending := &server.KnownHosts{}
+	pending.SetHostKeys(knownHost, keys)
+	if err := pending.Write(c.knownHostsPath); err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing %s: %s", c.knownHostsPath, err))
+		return 1
+	}
+
+	// SSH access
+	if publicKey != nil {
+		if err := c.addAuthorizedKey(host, publicKey); err != nil {
+			c.UI.Error(fmt.Sprintf("Error adding SSH key to %s@%s: %s", c.user, host, err))
+			return 1
+		}
+
+		c.UI.Info(fmt.Sprintf("%s Added %s to %s@%s", color.GreenString("[✓]"), c.sshKey, c.user, host))
+	}
+
+	osRelease, err := c.sshOutput(host, []string{"-o", "BatchMode=yes"}, "cat /etc/os-release")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error: could not connect to %s@%s via SSH: %s", c.user, host, err))
+		c.UI.Error("Make sure one of your SSH keys is authorized for the user or add one with --ssh-key.")
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("%s Connected to %s@%s", color.GreenString("[✓]"), c.user, host))
+
+	// Operating system
+	name, err := checkOsRelease(osRelease)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error: %s", err))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("%s Operating system is supported: %s", color.GreenString("[✓]"), name))
+
+	// Host keys
+	if err := recordHostKeys(c.Trellis, environment, map[string][]ssh.PublicKey{knownHost: keys}); err != nil {
+		c.UI.Error(fmt.Sprintf("Error recording SSH host keys: %s", err))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("%s Added SSH host keys to %s", color.GreenString("[✓]"), c.Trellis.KnownHostsPath()))
+
+	// Inventory
+	if _, err := c.Trellis.UpdateHosts(environment, host); err != nil {
+		c.UI.Error(fmt.Sprintf("Error updating Trellis hosts file: %s", err))
+		return 1
+	}
+
+	ansiblePort := c.port
+	if ansiblePort == "22" {
+		ansiblePort = ""
+	}
+
+	if err := c.Trellis.SetHostVar(environment, host, "ansible_port", ansiblePort); err != nil {
+		c.UI.Error(fmt.Sprintf("Error updating Trellis hosts file: %s", err))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("%s Updated hosts/%s with server: %s", color.GreenString("[✓]"), environment, host))
+
+	// Trellis provisions as root, or as admin_user once root logins are disabled
+	if adminUser := c.Trellis.AdminUser(environment); c.user != "root" && c.user != adminUser {
+		c.UI.Warn(fmt.Sprintf("\nWarning: Trellis connects as root or admin_user (%s), not %s. Set `admin_user: %s` in group_vars/%s/main.yml before provisioning.", adminUser, c.user, c.user, environment))
+	}
+
+	c.UI.Info(fmt.Sprintf("\nServer is ready to be provisioned. Run `trellis provision %s`.", environment))
+
+	return 0
+}
+
+// checkRecordedHostKeys fails if the host already has different keys recorded.
+func (c *ServerRegisterCommand) checkRecordedHostKeys(knownHost string, keys []ssh.PublicKey) error {
+	knownHosts, err := server.ReadKnownHosts(c.Trellis.KnownHostsPath())
+	if err != nil {
+		return fmt.Errorf("Error reading %s: %s", c.Trellis.KnownHostsPath(), err)
+	}
+
+	recorded := knownHosts.HostKeys(knownHost)
+	if len(recorded) > 0 && !server.SameHostKeys(recorded, keys) {
+		return fmt.Errorf("Error: the host keys of %s don't match the ones recorded in %s.\nIf the server was rebuilt, run `trellis server known-hosts --refresh` first.", knownHost, c.Trellis.KnownHostsPath())
+	}
+
+	return nil
+}
+
+// addAuthorizedKey appends the public key to the user's authorized_keys (unless it's already there).
+// SSH runs interactively so a password can be entered.
+func (c *ServerRegisterCommand) addAuthorizedKey(host string, publicKey []byte) error {
+	key := command.ShellQuote(strings.TrimSpace(string(publicKey)))
+	script := fmt.Sprintf("umask 077 && mkdir -p ~/.ssh && (grep -qxF %s ~/.ssh/authorized_keys 2>/dev/null || echo %s >> ~/.ssh/authorized_keys)", key, key)
+
+	ssh := command.WithOptions(
+		command.WithTermOutput(),
+		command.WithLogging(c.UI),
+	).Cmd("ssh", c.sshArgs(host, nil, script))
+
+	return ssh.Run()
+}
+
+func (c *ServerRegisterCommand) sshOutput(host string, options []string, remoteCommand string) (string, error) {
+	ssh := command.WithOptions(
+		command.WithLogging(c.UI),
+	).Cmd("ssh", c.sshArgs(host, options, remoteCommand))
+
+	output, err := ssh.Output()
+	return string(output), err
+}
+
+// sshArgs only trusts the host keys scanned for the server.
+func (c *ServerRegisterCommand) sshArgs(host string, options []string, remoteCommand string) []string {
+	args := append([]string{}, options...)
+	args = append(args,
+		"-o", "StrictHostKeyChecking=yes",
+		"-o", "UserKnownHostsFile="+c.knownHostsPath,
+		"-p", c.port,
+		fmt.Sprintf("%s@%s", c.user, host),
+		remoteCommand,
+	)
+
+	return args
+}
+
+// checkOsRelease returns the OS name from /etc/os-release if it's a supported Ubuntu release.
+func checkOsRelease(content string) (string, error) {
+	values := map[string]string{}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "="); ok {
+			values[key] = strings.Trim(value, `"'`)
+		}
+	}
+
+	name := values["PRETTY_NAME"]
+	if name == "" {
+		name = strings.TrimSpace(values["NAME"] + " " + values["VERSION_ID"])
+	}
+	if name == "" {
+		name = "unknown"
+	}
+
+	if values["ID"] != "ubuntu" || !slices.Contains(supportedUbuntuVersions, values["VERSION_ID"]) {
+		return name, fmt.Errorf("%s is not supported. Trellis requires Ubuntu %s.", name, strings.Join(supportedUbuntuVersions, " or "))
+	}
+
+	return name, nil
+}
+
+func (c *ServerRegisterCommand) Synopsis() string {
+	return "Registers an existing server with an environment"
+}
+
+func (c *ServerRegisterCommand) Help() string {
+	helpText := `
+Usage: trellis server register [options] ENVIRONMENT HOST
+
+Registers an existing server (eg: a VPS created outside of trellis-cli) with an
+environment so it's ready to be provisioned, just like 'trellis server create'.
+
+The server is checked and registered in these steps:
+  1. Its SSH host keys are scanned (and only trusted for the following checks)
+  2. The public key from --ssh-key is added to the user's authorized_keys (optional)
+  3. SSH access is verified
+  4. The operating system must be a supported Ubuntu release (22.04 or 24.04)
+  5. Its SSH host keys are recorded in .trellis/known_hosts
+  6. hosts/ENVIRONMENT is updated with the server (and its --port)
+
+Trellis provisions as root, or as admin_user once root logins are disabled. If
+--user is neither, set admin_user to it before provisioning.
+
+Register a production server:
+
+  $ trellis server register production 203.0.113.10
+
+Register a server which only allows password logins for the ubuntu user and
+authorize your SSH key (the password is prompted for):
+
+  $ trellis server register --user ubuntu --ssh-key ~/.ssh/id_ed25519.pub production 203.0.113.10
+
+Arguments:
+  ENVIRONMENT Name of environment (ie: production)
+  HOST        IP address or host name of the server
+
+Options:
+      --user     User to connect as (default: root)
+      --port     SSH port of the server (default: 22)
+      --ssh-key  Path to an SSH public key to add to the user's authorized_keys
+  -h, --help     show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ServerRegisterCommand) AutocompleteArgs() complete.Predictor {
+	return c.Trellis.AutocompleteEnvironment(c.flags)
+}
+
+func (c *ServerRegisterCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"--user":    complete.PredictNothing,
+		"--port":    complete.PredictNothing,
+		"--ssh-key": complete.PredictFiles("*.pub"),
+	}
+}
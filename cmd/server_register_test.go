@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/command"
+	"github.com/roots/trellis-cli/pkg/server"
+	"github.com/roots/trellis-cli/trellis"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestServerRegisterRunValidations(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	cases := []struct {
+		name            string
+		projectDetected bool
+		args            []string
+		out             string
+		code            int
+	}{
+		{
+			"no_project",
+			false,
+			nil,
+			"No Trellis project detected",
+			1,
+		},
+		{
+			"no_args",
+			true,
+			nil,
+			"Error: missing arguments (expected exactly 2, got 0)",
+			1,
+		},
+		{
+			"too_many_args",
+			true,
+			[]string{"production", "1.2.3.4", "foo"},
+			"Error: too many arguments",
+			1,
+		},
+		{
+			"invalid_env",
+			true,
+			[]string{"foo", "1.2.3.4"},
+			"Error: foo is not a valid environment",
+			1,
+		},
+		{
+			"development_env",
+			true,
+			[]string{"development", "1.2.3.4"},
+			"server register command only supports staging/production environments",
+			1,
+		},
+		{
+			"missing_ssh_key",
+			true,
+			[]string{"--ssh-key", "missing.pub", "production", "1.2.3.4"},
+			"Error: ",
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			trellis := trellis.NewMockTrellis(tc.projectDetected)
+			serverRegisterCommand := NewServerRegisterCommand(ui, trellis)
+
+			code := serverRegisterCommand.Run(tc.args)
+
+			if code != tc.code {
+				t.Errorf("expected code %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected output %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+}
+
+func TestServerRegisterRun(t *testing.T) {
+	addr, hostKey := server.StartTestSSHServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+
+	ubuntu := "NAME=\"Ubuntu\"\nID=ubuntu\nVERSION_ID=\"24.04\"\nPRETTY_NAME=\"Ubuntu 24.04.1 LTS\"\n"
+
+	cases := []struct {
+		name      string
+		user      string
+		osRelease string
+		out       string
+		code      int
+	}{
+		{
+			"unsupported_os",
+			"root",
+			"NAME=\"Debian GNU/Linux\"\nID=debian\nVERSION_ID=\"12\"\nPRETTY_NAME=\"Debian GNU/Linux 12 (bookworm)\"\n",
+			"Error: Debian GNU/Linux 12 (bookworm) is not supported. Trellis requires Ubuntu 22.04 or 24.04.",
+			1,
+		},
+		{
+			"supported_os",
+			"root",
+			ubuntu,
+			"Operating system is supported: Ubuntu 24.04.1 LTS",
+			0,
+		},
+		{
+			"other_user",
+			"ubuntu",
+			ubuntu,
+			"Warning: Trellis connects as root or admin_user (admin), not ubuntu.",
+			0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer trellis.LoadFixtureProject(t)()
+
+			if err := os.WriteFile("ansible.cfg", []byte("[defaults]\ninventory = hosts\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			trellis := trellis.NewTrellis()
+			if err := trellis.LoadProject(); err != nil {
+				t.Fatal(err)
+			}
+
+			defer command.MockExecCommands(t, []command.MockCommand{
+				{
+					Command: "ssh",
+					Args:    []string{"-o", "BatchMode=yes", "-o", "StrictHostKeyChecking=yes", "-o", "UserKnownHostsFile=" + trellis.KnownHostsPath() + ".pending", "-p", port, tc.user + "@" + host, "cat /etc/os-release"},
+					Output:  tc.osRelease,
+				},
+			})()
+
+			// registering again doesn't duplicate the server
+			for range 2 {
+				ui := cli.NewMockUi()
+				code := NewServerRegisterCommand(ui, trellis).Run([]string{"--user", tc.user, "--port", port, "production", host})
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+				if code != tc.code || !strings.Contains(combined, tc.out) {
+					t.Fatalf("expected code %d and output to contain %q, got %d: %q", tc.code, tc.out, code, combined)
+				}
+			}
+
+			if _, err := os.Stat(trellis.KnownHostsPath() + ".pending"); !os.IsNotExist(err) {
+				t.Errorf("expected the pending known_hosts file to be removed")
+			}
+
+			knownHosts, err := server.ReadKnownHosts(trellis.KnownHostsPath())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorded := server.SameHostKeys(knownHosts.HostKeys(addr), []ssh.PublicKey{hostKey})
+			if recorded != (tc.code == 0) {
+				t.Errorf("expected host key to be recorded for %s: %v", addr, tc.code == 0)
+			}
+
+			hosts, err := os.ReadFile("hosts/production")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			registered := strings.Contains(string(hosts), "[production]\n"+host+" ansible_port="+port+"\n\n") &&
+				strings.Count(string(hosts), "ansible_port=") == 1 &&
+				strings.Contains(string(hosts), "ansible_host_key_checking=true")
+			if registered != (tc.code == 0) {
+				t.Errorf("unexpected hosts/production:\n%s", hosts)
+			}
+		})
+	}
+}
+
+func TestServerRegisterChangedHostKeys(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	addr, _ := server.StartTestSSHServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+	_, otherKey := server.StartTestSSHServer(t)
+
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	knownHosts, _ := server.ReadKnownHosts(trellis.KnownHostsPath())
+	knownHosts.SetHostKeys(addr, []ssh.PublicKey{otherKey})
+	if err := knownHosts.Write(trellis.KnownHostsPath()); err != nil {
+		t.Fatal(err)
+	}
+
+	ui := cli.NewMockUi()
+	code := NewServerRegisterCommand(ui, trellis).Run([]string{"--port", port, "production", host})
+
+	if code != 1 || !strings.Contains(ui.ErrorWriter.String(), "don't match the ones recorded") {
+		t.Errorf("expected changed host keys error, got %d: %q", code, ui.ErrorWriter.String())
+	}
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/posener/complete"
+	"github.com/roots/trellis-cli/pkg/server"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func NewServerSizesCommand(ui cli.Ui, trellis *trellis.Trellis) *ServerSizesCommand {
+	c := &ServerSizesCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+type ServerSizesCommand struct {
+	UI           cli.Ui
+	Trellis      *trellis.Trellis
+	flags        *flag.FlagSet
+	providerFlag string
+	region       string
+	maxPrice     float64
+	minMemory    float64
+	json         bool
+}
+
+func (c *ServerSizesCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+	c.flags.StringVar(&c.providerFlag, "provider", "", "Cloud provider (digitalocean, hetzner, linode)")
+	c.flags.StringVar(&c.region, "region", "", "Only list sizes available in this region")
+	c.flags.Float64Var(&c.maxPrice, "max-price", 0, "Only list sizes with a monthly price up to this amount")
+	c.flags.Float64Var(&c.minMemory, "min-memory", 0, "Only list sizes with at least this much memory (in GB)")
+	c.flags.BoolVar(&c.json, "json", false, "Output as JSON")
+}
+
+func (c *ServerSizesCommand) Run(args []string) int {
+	// a project is optional; it's only loaded for its server config
+	_ = c.Trellis.LoadProject()
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	if c.maxPrice < 0 || c.minMemory < 0 {
+		c.UI.Error("Error: --max-price and --min-memory can't be negative")
+		return 1
+	}
+
+	provider, err := newCatalogProvider(c.UI, c.Trellis, c.providerFlag)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	sizes, err := provider.GetSizes(context.Background(), c.region)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error fetching sizes: %v", err))
+		return 1
+	}
+
+	sizes = filterSizes(sizes, c.maxPrice, c.minMemory)
+
+	if c.json {
+		jsonBytes, err := json.MarshalIndent(sizes, "", "  ")
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error encoding JSON: %s", err))
+			return 1
+		}
+		c.UI.Output(string(jsonBytes))
+		return 0
+	}
+
+	if len(sizes) == 0 {
+		c.UI.Info("No sizes found matching the filters.")
+		return 0
+	}
+
+	c.UI.Output(sizesTable(sizes))
+	return 0
+}
+
+// filterSizes returns sizes up to maxPrice (monthly) with at least minMemory GB. Zero disables a filter.
+func filterSizes(sizes []server.Size, maxPrice float64, minMemory float64) []server.Size {
+	result := []server.Size{}
+
+	for _, size := range sizes {
+		if maxPrice > 0 && size.PriceMonthly > maxPrice {
+			continue
+		}
+
+		if minMemory > 0 && float64(size.Memory) < minMemory*1024 {
+			continue
+		}
+
+		result = append(result, size)
+	}
+
+	return result
+}
+
+func sizesTable(sizes []server.Size) string {
+	rows := make([][]string, len(sizes))
+
+	for i, s := range sizes {
+		rows[i] = []string{
+			s.Slug,
+			strconv.Itoa(s.VCPUs),
+			formatMemory(s.Memory),
+			fmt.Sprintf("%d GB", s.Disk),
+			fmt.Sprintf("%.2f", s.PriceMonthly),
+			fmt.Sprintf("%.4f", s.PriceHourly),
+		}
+	}
+
+	return formatTable([]string{"slug", "vcpus", "memory", "disk", "monthly", "hourly"}, rows)
+}
+
+func formatMemory(mb int) string {
+	if mb < 1024 {
+		return fmt.Sprintf("%d MB", mb)
+	}
+
+	return strconv.FormatFloat(float64(mb)/1024, 'f', -1, 64) + " GB"
+}
+
+func (c *ServerSizesCommand) Synopsis() string {
+	return "Lists the server sizes of a cloud provider"
+}
+
+func (c *ServerSizesCommand) Help() string {
+	helpText := `
+Usage: trellis server sizes [options]
+
+Lists the server sizes/types with their vCPUs, memory, disk, and price.
+Prices are in the provider's billing currency (USD for DigitalOcean, EUR for Hetzner).
+
+List sizes of the configured provider:
+
+  $ trellis server sizes
+
+List sizes available in a region:
+
+  $ trellis server sizes --region nyc3
+
+List sizes up to 20/month with at least 4 GB of memory:
+
+  $ trellis server sizes --max-price 20 --min-memory 4
+
+Compare providers:
+
+  $ trellis server sizes --provider hetzner --json
+
+Options:
+      --provider    Cloud provider (digitalocean, hetzner, linode). Defaults to server.provider
+      --region      Only list sizes available in this region
+      --max-price   Maximum monthly price
+      --min-memory  Minimum memory in GB
+      --json        Output as JSON
+  -h, --help        show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ServerSizesCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"--provider":   complete.PredictSet("digitalocean", "hetzner", "linode"),
+		"--region":     complete.PredictNothing,
+		"--max-price":  complete.PredictNothing,
+		"--min-memory": complete.PredictNothing,
+		"--json":       complete.PredictNothing,
+	}
+}
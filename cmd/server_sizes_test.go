@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/server"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestServerSizesRunValidations(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"too_many_args",
+			[]string{"foo"},
+			"Error: too many arguments",
+			1,
+		},
+		{
+			"negative_price",
+			[]string{"--max-price", "-5"},
+			"Error: --max-price and --min-memory can't be negative",
+			1,
+		},
+		{
+			"unsupported_provider",
+			[]string{"--provider", "aws"},
+			"Error: unsupported provider aws",
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			trellis := trellis.NewMockTrellis(false)
+			serverSizesCommand := NewServerSizesCommand(ui, trellis)
+
+			code := serverSizesCommand.Run(tc.args)
+
+			if code != tc.code {
+				t.Errorf("expected code %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected output %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+}
+
+func TestFilterSizes(t *testing.T) {
+	sizes := []server.Size{
+		{Slug: "small", Memory: 1024, PriceMonthly: 6},
+		{Slug: "medium", Memory: 4096, PriceMonthly: 24},
+		{Slug: "large", Memory: 8192, PriceMonthly: 48},
+	}
+
+	cases := []struct {
+		name      string
+		maxPrice  float64
+		minMemory float64
+		want      []string
+	}{
+		{"no_filters", 0, 0, []string{"small", "medium", "large"}},
+		{"max_price", 24, 0, []string{"small", "medium"}},
+		{"min_memory", 0, 4, []string{"medium", "large"}},
+		{"both", 30, 2, []string{"medium"}},
+		{"none_match", 5, 0, []string{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			slugs := []string{}
+			for _, s := range filterSizes(sizes, tc.maxPrice, tc.minMemory) {
+				slugs = append(slugs, s.Slug)
+			}
+
+			if !reflect.DeepEqual(slugs, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, slugs)
+			}
+		})
+	}
+}
+
+func TestSizesTable(t *testing.T) {
+	table := sizesTable([]server.Size{
+		{Slug: "s-1vcpu-512mb-10gb", VCPUs: 1, Memory: 512, Disk: 10, PriceMonthly: 4, PriceHourly: 0.00595},
+		{Slug: "cx22", VCPUs: 2, Memory: 4096, Disk: 40, PriceMonthly: 4.59, PriceHourly: 0.0074},
+	})
+
+	expected := `SLUG                VCPUS  MEMORY  DISK   MONTHLY  HOURLY
+s-1vcpu-512mb-10gb  1      512 MB  10 GB  4.00     0.0060
+cx22                2      4 GB    40 GB  4.59     0.0074`
+
+	if table != expected {
+		t.Errorf("expected table\n%s\ngot\n%s", expected, table)
+	}
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"text/tabwriter"
+)
+
+// formatTable aligns rows into columns under the given (upper-cased) headers.
+func formatTable(headers []string, rows [][]string) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+	upper := make([]string, len(headers))
+	for i, h := range headers {
+		upper[i] = strings.ToUpper(h)
+	}
+
+	_, _ = w.Write([]byte(strings.Join(upper, "\t") + "\n"))
+
+	for _, row := range rows {
+		_, _ = w.Write([]byte(strings.Join(row, "\t") + "\n"))
+	}
+
+	_ = w.Flush()
+
+	return strings.TrimRight(buf.String(), "\n")
+}
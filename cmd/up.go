@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+// upStateFile records the `trellis up` steps completed for the project (in its config directory).
+const upStateFile = "up.json"
+
+var errUpStepFailed = errors.New("step failed")
+
+type upState struct {
+	Completed []string `json:"completed"`
+}
+
+type upStep struct {
+	name  string
+	title string
+	// repeat steps run every time instead of being skipped once completed (eg: starting the VM).
+	repeat bool
+	// run returns a reason if the step was skipped.
+	run func() (skipped string, err error)
+}
+
+type UpCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+	noOpen  bool
+	restart bool
+	state   *upState
+}
+
+func NewUpCommand(ui cli.Ui, trellis *trellis.Trellis) *UpCommand {
+	c := &UpCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+func (c *UpCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+	c.flags.BoolVar(&c.noOpen, "no-open", false, "Don't open the site once it's ready")
+	c.flags.BoolVar(&c.restart, "restart", false, "Run every step again instead of continuing from the last completed step")
+}
+
+func (c *UpCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	c.state = &upState{}
+	if !c.restart {
+		state, err := readUpState(c.statePath())
+		if err != nil {
+			c.UI.Error("Error: " + err.Error())
+			return 1
+		}
+		c.state = state
+	}
+
+	steps := c.steps()
+
+	for i, step := range steps {
+		c.UI.Info(fmt.Sprintf("\n==> [%d/%d] %s", i+1, len(steps), step.title))
+
+		if !step.repeat && slices.Contains(c.state.Completed, step.name) {
+			c.UI.Info(fmt.Sprintf("%s Skipped (already done)", color.YellowString("[-]")))
+			continue
+		}
+
+		skipped, err := step.run()
+		if err != nil {
+			if !errors.Is(err, errUpStepFailed) {
+				c.UI.Error("Error: " + err.Error())
+			}
+			c.UI.Error(fmt.Sprintf("\n%s failed. Fix the error above and run `trellis up` again to continue from this step.", step.title))
+			return 1
+		}
+
+		if skipped != "" {
+			c.UI.Info(fmt.Sprintf("%s Skipped (%s)", color.YellowString("[-]"), skipped))
+			continue
+		}
+
+		c.UI.Info(fmt.Sprintf("%s Done", color.GreenString("[✓]")))
+
+		if !step.repeat {
+			c.state.Completed = append(c.state.Completed, step.name)
+		}
+
+		if err := c.saveState(); err != nil {
+			c.UI.Error(fmt.Sprintf("Error: could not save progress to %s: %v", c.statePath(), err))
+			return 1
+		}
+	}
+
+	c.UI.Info("\nYour local development site is ready!")
+	return 0
+}
+
+func (c *UpCommand) steps() []upStep {
+	return []upStep{
+		{name: "init", title: "Initialize project", repeat: true, run: c.initProject},
+		{name: "galaxy", title: "Install Galaxy roles", run: func() (string, error) {
+			return "", runSubcommand(&GalaxyInstallCommand{c.UI, c.Trellis}, nil)
+		}},
+		{name: "vm", title: "Start VM", repeat: true, run: c.startVm},
+		{name: "provision", title: "Provision VM", run: func() (string, error) {
+			return "", runSubcommand(NewProvisionCommand(c.UI, c.Trellis), []string{"development"})
+		}},
+		{name: "dotenv", title: "Generate .env files", run: func() (string, error) {
+			return "", runSubcommand(NewDotEnvCommand(c.UI, c.Trellis), nil)
+		}},
+		{name: "trust", title: "Trust self-signed certificates", run: c.trust},
+		{name: "open", title: "Open site", repeat: true, run: c.open},
+	}
+}
+
+func (c *UpCommand) initProject() (string, error) {
+	if !c.Trellis.CliConfig.VirtualenvIntegration {
+		return "virtualenv integration is disabled", nil
+	}
+
+	if c.Trellis.Virtualenv.Initialized() {
+		return "virtualenv exists", nil
+	}
+
+	if err := runSubcommand(NewInitCommand(c.UI, c.Trellis), nil); err != nil {
+		return "", err
+	}
+
+	// Later steps run Ansible from the new virtualenv
+	c.Trellis.VenvInitialized = true
+	c.Trellis.Virtualenv.Activate()
+
+	return "", nil
+}
+
+// startVm starts the VM, creating it first if needed. A new VM needs to be provisioned and trusted again.
+func (c *UpCommand) startVm() (string, error) {
+	instanceName, err := c.Trellis.GetVmInstanceName()
+	if err != nil {
+		return "", err
+	}
+
+	manager, err := newVmManager(c.Trellis, c.UI)
+	if err != nil {
+		return "", err
+	}
+
+	created, err := startVmInstance(c.UI, manager, instanceName)
+	if created {
+		c.state.Completed = slices.DeleteFunc(c.state.Completed, func(step string) bool {
+			return step == "provision" || step == "trust"
+		})
+	}
+
+	return "", err
+}
+
+func (c *UpCommand) trust() (string, error) {
+	trustCommand := NewVmTrustCommand(c.UI, c.Trellis)
+
+	if len(trustCommand.selectSites()) == 0 {
+		return "no sites use self-signed certificates", nil
+	}
+
+	return "", runSubcommand(trustCommand, nil)
+}
+
+func (c *UpCommand) open() (string, error) {
+	if c.noOpen {
+		return "--no-open", nil
+	}
+
+	return "", runSubcommand(&OpenCommand{c.UI, c.Trellis}, nil)
+}
+
+func (c *UpCommand) statePath() string {
+	return filepath.Join(c.Trellis.ConfigPath(), upStateFile)
+}
+
+func (c *UpCommand) saveState() error {
+	if err := c.Trellis.CreateConfigDir(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c.state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.statePath(), data, 0644)
+}
+
+func readUpState(path string) (*upState, error) {
+	state := &upState{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v. Run `trellis up --restart` to start over.", path, err)
+	}
+
+	return state, nil
+}
+
+// runSubcommand runs a command which reports its own errors.
+func runSubcommand(command cli.Command, args []string) error {
+	if code := command.Run(args); code != 0 {
+		return errUpStepFailed
+	}
+
+	return nil
+}
+
+func (c *UpCommand) Synopsis() string {
+	return "Sets up and starts the local development site in one step"
+}
+
+func (c *UpCommand) Help() string {
+	helpText := `
+Usage: trellis up [options]
+
+Brings the project to a working local development site by running each setup
+step in order:
+
+  1. init (only if the virtualenv is missing)
+  2. galaxy install
+  3. vm start (creating the VM if needed)
+  4. provision development
+  5. dotenv
+  6. vm trust (only if sites use self-signed certificates)
+  7. open
+
+Completed steps are recorded in .trellis/up.json. If a step fails, fix the
+error and run 'trellis up' again to continue from that step. Once everything
+is set up, 'trellis up' only starts the VM and opens the site. A newly created
+VM (eg: after 'trellis vm delete') is provisioned and trusted again.
+
+Set up a freshly cloned project:
+
+  $ trellis up
+
+Run every step again:
+
+  $ trellis up --restart
+
+Options:
+      --no-open  Don't open the site once it's ready
+      --restart  Run every step again instead of continuing from the last completed step
+  -h, --help     show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestUpRunValidations(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	cases := []struct {
+		name            string
+		projectDetected bool
+		args            []string
+		out             string
+		code            int
+	}{
+		{
+			"no_project",
+			false,
+			nil,
+			"No Trellis project detected",
+			1,
+		},
+		{
+			"too_many_args",
+			true,
+			[]string{"foo"},
+			"Error: too many arguments",
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			trellis := trellis.NewMockTrellis(tc.projectDetected)
+			upCommand := NewUpCommand(ui, trellis)
+
+			code := upCommand.Run(tc.args)
+
+			if code != tc.code {
+				t.Errorf("expected code %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected output %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+}
+
+func TestUpRunFailedStep(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+	trellis.CliConfig.VirtualenvIntegration = false
+
+	ui := cli.NewMockUi()
+	code := NewUpCommand(ui, trellis).Run([]string{"--no-open"})
+
+	if code != 1 {
+		t.Errorf("expected code 1, got %d", code)
+	}
+
+	output := ui.OutputWriter.String()
+	errors := ui.ErrorWriter.String()
+
+	if !strings.Contains(output, "[1/7] Initialize project") || !strings.Contains(output, "Skipped (virtualenv integration is disabled)") {
+		t.Errorf("expected the init step to be skipped, got %q", output)
+	}
+
+	// The fixture project has no Galaxy role file
+	expected := "Install Galaxy roles failed. Fix the error above and run `trellis up` again to continue from this step."
+	if !strings.Contains(errors, expected) {
+		t.Errorf("expected errors %q to contain %q", errors, expected)
+	}
+
+	if strings.Contains(output, "Start VM") {
+		t.Errorf("expected steps after the failed step not to run, got %q", output)
+	}
+}
+
+func TestUpRunResumes(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+	trellis.CliConfig.VirtualenvIntegration = false
+	trellis.CliConfig.Vm.Manager = "mock"
+
+	statePath := filepath.Join(trellis.ConfigPath(), "up.json")
+	if err := os.MkdirAll(filepath.Dir(statePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(statePath, []byte(`{"completed":["galaxy","provision","dotenv"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ui := cli.NewMockUi()
+	code := NewUpCommand(ui, trellis).Run([]string{"--no-open"})
+
+	if code != 0 {
+		t.Fatalf("expected code 0, got %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	output := ui.OutputWriter.String()
+
+	for _, expected := range []string{
+		"[2/7] Install Galaxy roles\n[-] Skipped (already done)",
+		"[3/7] Start VM\n[✓] Done",
+		"[4/7] Provision VM\n[-] Skipped (already done)",
+		"[6/7] Trust self-signed certificates\n[-] Skipped (no sites use self-signed certificates)",
+		"[7/7] Open site\n[-] Skipped (--no-open)",
+		"Your local development site is ready!",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output %q to contain %q", output, expected)
+		}
+	}
+
+	state, err := readUpState(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"galaxy", "provision", "dotenv"}
+	if !reflect.DeepEqual(state.Completed, expected) {
+		t.Errorf("expected completed steps %v got %v", expected, state.Completed)
+	}
+}
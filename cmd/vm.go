@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/cli"
 	"github.com/roots/trellis-cli/pkg/lima"
+	"github.com/roots/trellis-cli/pkg/ssh_vm"
 	"github.com/roots/trellis-cli/pkg/vm"
 	"github.com/roots/trellis-cli/trellis"
 )
@@ -16,6 +17,8 @@ func newVmManager(t *trellis.Trellis, ui cli.Ui) (vm.Manager, error) {
 	switch vmType {
 	case "lima":
 		return lima.NewManager(t, ui)
+	case "ssh":
+		return ssh_vm.NewManager(t, ui)
 	case "mock":
 		return vm.NewMockManager(t, ui)
 	case "":
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type VmConfigCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+}
+
+func NewVmConfigCommand(ui cli.Ui, trellis *trellis.Trellis) *VmConfigCommand {
+	c := &VmConfigCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+func (c *VmConfigCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+}
+
+func (c *VmConfigCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	instanceName, err := c.Trellis.GetVmInstanceName()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	manager, err := newVmManager(c.Trellis, c.UI)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	generator, ok := manager.(vm.ConfigGenerator)
+	if !ok {
+		c.UI.Error(fmt.Sprintf("Error: vm config is only supported by the Lima VM manager (vm.manager is %s).", c.Trellis.VmManagerType()))
+		return 1
+	}
+
+	config, err := generator.GenerateConfig(instanceName)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	if _, err := os.Stat(generator.OverridePath()); err == nil {
+		c.UI.Info(fmt.Sprintf("# Generated config merged with %s", generator.OverridePath()))
+	} else {
+		c.UI.Info(fmt.Sprintf("# Generated config (%s does not exist)", generator.OverridePath()))
+	}
+
+	c.UI.Output(strings.TrimSpace(string(config)))
+
+	return 0
+}
+
+func (c *VmConfigCommand) Synopsis() string {
+	return "Prints the effective config of the development virtual machine"
+}
+
+func (c *VmConfigCommand) Help() string {
+	helpText := `
+Usage: trellis vm config [options]
+
+Prints the Lima config used for the development VM: the config generated by
+trellis-cli deep-merged with the project's .trellis/lima/override.yml file (if
+it exists). This is what's written to the VM's lima.yaml on the next
+'trellis vm start'.
+
+The override file customizes the VM beyond trellis.cli.yml's vm settings.
+Mappings are merged key by key, lists are appended to, other values replace
+the generated ones, and null removes a setting:
+
+  # .trellis/lima/override.yml
+  mountType: "virtiofs"
+  nestedVirtualization: true
+  env:
+    COMPOSER_MEMORY_LIMIT: "-1"
+  mounts:
+  - location: "~/shared"
+    writable: true
+  provision:
+  - mode: user
+    script: |
+      #!/bin/bash
+      echo "custom provisioning"
+
+Print the config:
+
+  $ trellis vm config
+
+Options:
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/command"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestVmConfigRunValidations(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	cases := []struct {
+		name            string
+		projectDetected bool
+		manager         string
+		args            []string
+		out             string
+		code            int
+	}{
+		{
+			"no_project",
+			false,
+			"",
+			nil,
+			"No Trellis project detected",
+			1,
+		},
+		{
+			"too_many_args",
+			true,
+			"",
+			[]string{"foo"},
+			"Error: too many arguments",
+			1,
+		},
+		{
+			"unsupported_manager",
+			true,
+			"mock",
+			nil,
+			"Error: vm config is only supported by the Lima VM manager (vm.manager is mock).",
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			trellis := trellis.NewMockTrellis(tc.projectDetected)
+			trellis.CliConfig.Vm.Manager = tc.manager
+			vmConfigCommand := NewVmConfigCommand(ui, trellis)
+
+			code := vmConfigCommand.Run(tc.args)
+
+			if code != tc.code {
+				t.Errorf("expected code %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected output %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+}
+
+func TestVmConfigRun(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	t.Setenv("TRELLIS_BYPASS_LIMA_REQUIREMENTS", "1")
+
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+	trellis.CliConfig.Vm.Manager = "lima"
+
+	overridePath := filepath.Join(trellis.ConfigPath(), "lima", "override.yml")
+	if err := os.MkdirAll(filepath.Dir(overridePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(overridePath, []byte("nestedVirtualization: true\nmountType: 9p\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer command.MockExecCommands(t, []command.MockCommand{
+		{
+			Command: "limactl",
+			Args:    []string{"ls", "--format=json"},
+			Output:  fmt.Sprintf(`{"name":"example.com","status":"Stopped","dir":"%s","vmType":"vz","config":{"portForwards":[{"guestPort":80,"hostPort":60720}]}}`, t.TempDir()),
+		},
+	})()
+
+	ui := cli.NewMockUi()
+	code := NewVmConfigCommand(ui, trellis).Run(nil)
+	if code != 0 {
+		t.Fatalf("expected code 0, got %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	output := ui.OutputWriter.String()
+
+	for _, expected := range []string{"# Generated config merged with " + overridePath, "mountType: 9p", "nestedVirtualization: true", "hostPort: 60720"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output %q to contain %q", output, expected)
+		}
+	}
+
+	if strings.Contains(output, "virtiofs") {
+		t.Errorf("expected mountType to be overridden, got %q", output)
+	}
+}
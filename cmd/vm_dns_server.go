@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/dns"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type VmDnsServerCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+}
+
+func (c *VmDnsServerCommand) Run(args []string) int {
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	pidPath := vm.DnsServerPidPath()
+	if pid, running := vm.DnsServerPid(pidPath); running {
+		c.UI.Error(fmt.Sprintf("Error: the DNS server is already running (pid %d).", pid))
+		return 1
+	}
+
+	conn, err := net.ListenPacket("udp", vm.DnsServerAddress)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error: could not listen on %s: %v", vm.DnsServerAddress, err))
+		return 1
+	}
+
+	if err := os.MkdirAll(filepath.Dir(pidPath), 0755); err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	if err := os.WriteFile(pidPath, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		c.UI.Error(fmt.Sprintf("Error: could not write %s: %v", pidPath, err))
+		return 1
+	}
+	defer func() { _ = os.Remove(pidPath) }()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		_ = conn.Close()
+	}()
+
+	server := &dns.Server{
+		Records: func() (map[string]string, error) {
+			records, err := vm.ReadDnsServerRecords(vm.DnsServerRecordsPath())
+			if err != nil {
+				return nil, err
+			}
+
+			return records.Hosts(), nil
+		},
+	}
+
+	c.UI.Info(fmt.Sprintf("Listening on %s", vm.DnsServerAddress))
+
+	if err := server.Serve(conn); err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (c *VmDnsServerCommand) Synopsis() string {
+	return "Runs the development DNS server."
+}
+
+func (c *VmDnsServerCommand) Help() string {
+	helpText := `
+Usage: trellis vm dns-server
+
+Runs the development DNS server used by the dns_server hosts resolver
+(vm.hosts_resolver: dns_server). This shouldn't be manually run; it's started
+by 'trellis vm start' and stopped by 'trellis vm stop' once no VM needs it.
+
+Options:
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/lima"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type VmDoctorCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+}
+
+func NewVmDoctorCommand(ui cli.Ui, trellis *trellis.Trellis) *VmDoctorCommand {
+	c := &VmDoctorCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+func (c *VmDoctorCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+}
+
+func (c *VmDoctorCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	instanceName, err := c.Trellis.GetVmInstanceName()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	checks := []vm.Check{c.virtualenvCheck()}
+
+	if managerType := c.Trellis.VmManagerType(); managerType == "lima" {
+		checks = append(checks, lima.Doctor(c.Trellis, c.UI, instanceName)...)
+	} else {
+		checks = append(checks, vm.WarnCheck("VM manager", fmt.Sprintf("vm.manager is %q; only the Lima VM manager has detailed diagnostics", managerType), ""))
+	}
+
+	failed := 0
+	for _, check := range checks {
+		c.UI.Info(fmt.Sprintf("%s %s: %s", checkLabel(check.Status), check.Name, check.Message))
+
+		if check.Status != vm.CheckPass && check.Fix != "" {
+			c.UI.Info("    Fix: " + check.Fix)
+		}
+
+		if check.Status == vm.CheckFail {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		c.UI.Error(fmt.Sprintf("\n%d check(s) failed.", failed))
+		return 1
+	}
+
+	return 0
+}
+
+func (c *VmDoctorCommand) virtualenvCheck() vm.Check {
+	if !c.Trellis.CliConfig.VirtualenvIntegration {
+		return vm.PassCheck("Virtualenv", "virtualenv integration is disabled")
+	}
+
+	if !c.Trellis.Virtualenv.Initialized() {
+		return vm.FailCheck("Virtualenv", c.Trellis.Virtualenv.Path+" doesn't exist", "Run `trellis init` to create it.")
+	}
+
+	return vm.PassCheck("Virtualenv", c.Trellis.Virtualenv.Path)
+}
+
+func checkLabel(status vm.CheckStatus) string {
+	switch status {
+	case vm.CheckFail:
+		return color.RedString("[✗]")
+	case vm.CheckWarn:
+		return color.YellowString("[!]")
+	default:
+		return color.GreenString("[✓]")
+	}
+}
+
+func (c *VmDoctorCommand) Synopsis() string {
+	return "Diagnoses the development virtual machine's requirements"
+}
+
+func (c *VmDoctorCommand) Help() string {
+	helpText := `
+Usage: trellis vm doctor [options]
+
+Checks everything the VM depends on and suggests a fix for each problem:
+
+  * the project's virtualenv
+  * macOS version (macOS) or /dev/kvm access (Linux)
+  * Lima and QEMU (Linux) versions
+  * the VM's TAP device, its owner and the QEMU wrapper which attaches it (Linux)
+  * free host ports for the port forwards
+  * the hosts resolver's entries
+  * the Ansible inventory file
+
+Each check passes ([✓]), warns ([!]) or fails ([✗]). Exits with a non-zero
+status if any check fails. Detailed VM checks are only available for the Lima
+VM manager.
+
+Diagnose the VM:
+
+  $ trellis vm doctor
+
+Options:
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestVmDoctorRunValidations(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	cases := []struct {
+		name            string
+		projectDetected bool
+		manager         string
+		args            []string
+		out             string
+		code            int
+	}{
+		{
+			"no_project",
+			false,
+			"",
+			nil,
+			"No Trellis project detected",
+			1,
+		},
+		{
+			"too_many_args",
+			true,
+			"",
+			[]string{"foo"},
+			"Error: too many arguments",
+			1,
+		},
+		{
+			"other_manager",
+			true,
+			"mock",
+			nil,
+			`vm.manager is "mock"; only the Lima VM manager has detailed diagnostics`,
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			trellis := trellis.NewMockTrellis(tc.projectDetected)
+			trellis.CliConfig.Vm.Manager = tc.manager
+			vmDoctorCommand := NewVmDoctorCommand(ui, trellis)
+
+			code := vmDoctorCommand.Run(tc.args)
+
+			if code != tc.code {
+				t.Errorf("expected code %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected output %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+}
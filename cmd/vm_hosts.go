@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+// newHostsFileResolver returns the resolver managing the development hosts in
+// /etc/hosts. The `vm hosts` commands only support the hosts_file resolver.
+func newHostsFileResolver(t *trellis.Trellis) (*vm.HostsFileResolver, error) {
+	if t.CliConfig.Vm.HostsResolver != "hosts_file" {
+		return nil, fmt.Errorf("vm hosts manages /etc/hosts entries, which are only used by the hosts_file hosts resolver (vm.hosts_resolver is %s).", t.CliConfig.Vm.HostsResolver)
+	}
+
+	resolver := vm.NewHostsFileResolver(t.Environments["development"].AllHosts())
+	resolver.Manager = t.VmManagerType()
+
+	return resolver, nil
+}
+
+// warnHostsConflicts prints entries outside trellis-cli's blocks which override the development hosts.
+func warnHostsConflicts(ui cli.Ui, resolver *vm.HostsFileResolver) {
+	conflicts, err := resolver.Conflicts()
+	if err != nil || len(conflicts) == 0 {
+		return
+	}
+
+	ui.Warn(fmt.Sprintf("\nWarning: %s has entries for the development hosts outside trellis-cli's blocks:", resolver.Path()))
+	for _, conflict := range conflicts {
+		ui.Warn(fmt.Sprintf("  line %d: %s", conflict.Line, conflict.Content))
+	}
+	ui.Warn("Remove them so the hosts resolve to the VM.")
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/lima"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type VmHostsPruneCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+}
+
+func NewVmHostsPruneCommand(ui cli.Ui, trellis *trellis.Trellis) *VmHostsPruneCommand {
+	c := &VmHostsPruneCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+func (c *VmHostsPruneCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+}
+
+func (c *VmHostsPruneCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	resolver, err := newHostsFileResolver(c.Trellis)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	instanceName, err := c.Trellis.GetVmInstanceName()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	// without Lima installed, none of the Lima VMs the blocks were written for exist anymore
+	instanceNames := []string{}
+	if _, err := exec.LookPath("limactl"); err == nil {
+		instanceNames, err = lima.InstanceNames()
+		if err != nil {
+			c.UI.Error("Error: " + err.Error())
+			return 1
+		}
+	}
+
+	blocks, err := resolver.Blocks()
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	stale := []string{}
+	for _, block := range blocks {
+		if block.Name != instanceName && limaHostsBlock(block) && !slices.Contains(instanceNames, block.Name) {
+			stale = append(stale, block.Name)
+		}
+	}
+
+	if len(stale) == 0 {
+		c.UI.Info(fmt.Sprintf("%s No hosts entries for deleted VMs found", color.GreenString("[✓]")))
+		return 0
+	}
+
+	if err := resolver.RemoveBlocks(stale); err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("%s Removed hosts entries for deleted VMs: %s", color.GreenString("[✓]"), strings.Join(stale, ", ")))
+	return 0
+}
+
+// limaHostsBlock reports whether Lima wrote the block. Only Lima can tell whether
+// an instance still exists; other managers' machines (eg: ssh) aren't managed by trellis-cli.
+func limaHostsBlock(block vm.HostsBlock) bool {
+	return block.Manager == "" || block.Manager == "lima"
+}
+
+func (c *VmHostsPruneCommand) Synopsis() string {
+	return "Removes /etc/hosts entries left behind by deleted virtual machines"
+}
+
+func (c *VmHostsPruneCommand) Help() string {
+	helpText := `
+Usage: trellis vm hosts prune [options]
+
+Removes trellis-cli's /etc/hosts blocks (from any project) whose Lima VM no
+longer exists. This project's block is always kept, and so are blocks written by
+other VM managers (eg: ssh) since trellis-cli can't tell if their machines still
+exist. If Lima isn't installed, all blocks written by Lima are removed.
+
+Prune stale entries:
+
+  $ trellis vm hosts prune
+
+Options:
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
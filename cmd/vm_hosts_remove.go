@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type VmHostsRemoveCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+}
+
+func NewVmHostsRemoveCommand(ui cli.Ui, trellis *trellis.Trellis) *VmHostsRemoveCommand {
+	c := &VmHostsRemoveCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+func (c *VmHostsRemoveCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+}
+
+func (c *VmHostsRemoveCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	resolver, err := newHostsFileResolver(c.Trellis)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	instanceName, err := c.Trellis.GetVmInstanceName()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	exists, err := resolver.HasHosts(instanceName)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	if !exists {
+		c.UI.Info(fmt.Sprintf("No hosts entries for %s in %s.", instanceName, resolver.Path()))
+		return 0
+	}
+
+	if err := resolver.RemoveHosts(instanceName); err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("%s Removed hosts entries for %s", color.GreenString("[✓]"), instanceName))
+	return 0
+}
+
+func (c *VmHostsRemoveCommand) Synopsis() string {
+	return "Removes the /etc/hosts entries of the development virtual machine"
+}
+
+func (c *VmHostsRemoveCommand) Help() string {
+	helpText := `
+Usage: trellis vm hosts remove [options]
+
+Removes the block of /etc/hosts entries for this project's VM. The VM itself
+isn't changed; 'trellis vm start' or 'trellis vm hosts sync' adds them again.
+
+Remove the entries:
+
+  $ trellis vm hosts remove
+
+Options:
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type VmHostsShowCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+}
+
+func NewVmHostsShowCommand(ui cli.Ui, trellis *trellis.Trellis) *VmHostsShowCommand {
+	c := &VmHostsShowCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+func (c *VmHostsShowCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+}
+
+func (c *VmHostsShowCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	resolver, err := newHostsFileResolver(c.Trellis)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	instanceName, err := c.Trellis.GetVmInstanceName()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	blocks, err := resolver.Blocks()
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	found := false
+	for _, block := range blocks {
+		if block.Name == instanceName {
+			found = true
+			c.UI.Output(strings.TrimSpace(block.Content))
+		}
+	}
+
+	if !found {
+		c.UI.Info(fmt.Sprintf("No hosts entries for %s in %s. Run `trellis vm hosts sync` to add them.", instanceName, resolver.Path()))
+	}
+
+	warnHostsConflicts(c.UI, resolver)
+
+	return 0
+}
+
+func (c *VmHostsShowCommand) Synopsis() string {
+	return "Shows the /etc/hosts entries of the development virtual machine"
+}
+
+func (c *VmHostsShowCommand) Help() string {
+	helpText := `
+Usage: trellis vm hosts show [options]
+
+Shows the block of /etc/hosts entries managed by trellis-cli for this project's
+VM. Entries for the same hosts outside trellis-cli's blocks are reported since
+they override the VM's entries.
+
+Show the entries:
+
+  $ trellis vm hosts show
+
+Options:
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
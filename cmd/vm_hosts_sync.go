@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type VmHostsSyncCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+}
+
+func NewVmHostsSyncCommand(ui cli.Ui, trellis *trellis.Trellis) *VmHostsSyncCommand {
+	c := &VmHostsSyncCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+func (c *VmHostsSyncCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+}
+
+func (c *VmHostsSyncCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	resolver, err := newHostsFileResolver(c.Trellis)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	instanceName, err := c.Trellis.GetVmInstanceName()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	manager, err := newVmManager(c.Trellis, c.UI)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	ipProvider, ok := manager.(vm.IPProvider)
+	if !ok {
+		c.UI.Error("Error: the VM manager can't look up the VM's IP address.")
+		return 1
+	}
+
+	ip, err := ipProvider.InstanceIP(instanceName)
+	if err != nil {
+		c.UI.Error("Error: could not get the VM's IP address: " + err.Error())
+		return 1
+	}
+
+	if err := resolver.AddHosts(instanceName, ip); err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("%s Synced hosts entries for %s (%s)", color.GreenString("[✓]"), instanceName, ip))
+	warnHostsConflicts(c.UI, resolver)
+
+	return 0
+}
+
+func (c *VmHostsSyncCommand) Synopsis() string {
+	return "Rewrites the /etc/hosts entries of the development virtual machine"
+}
+
+func (c *VmHostsSyncCommand) Help() string {
+	helpText := `
+Usage: trellis vm hosts sync [options]
+
+Rewrites the block of /etc/hosts entries for this project's VM from the
+development hosts in the current config and the running VM's IP address.
+Use it to fix a stale or missing block without restarting the VM.
+
+Sync the entries:
+
+  $ trellis vm hosts sync
+
+Options:
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
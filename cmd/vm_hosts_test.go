@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestVmHostsRunValidations(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	commands := map[string]func(cli.Ui, *trellis.Trellis) cli.Command{
+		"show":   func(ui cli.Ui, t *trellis.Trellis) cli.Command { return NewVmHostsShowCommand(ui, t) },
+		"sync":   func(ui cli.Ui, t *trellis.Trellis) cli.Command { return NewVmHostsSyncCommand(ui, t) },
+		"remove": func(ui cli.Ui, t *trellis.Trellis) cli.Command { return NewVmHostsRemoveCommand(ui, t) },
+		"prune":  func(ui cli.Ui, t *trellis.Trellis) cli.Command { return NewVmHostsPruneCommand(ui, t) },
+	}
+
+	cases := []struct {
+		name            string
+		projectDetected bool
+		hostsResolver   string
+		args            []string
+		out             string
+		code            int
+	}{
+		{
+			"no_project",
+			false,
+			"hosts_file",
+			nil,
+			"No Trellis project detected",
+			1,
+		},
+		{
+			"too_many_args",
+			true,
+			"hosts_file",
+			[]string{"foo"},
+			"Error: too many arguments",
+			1,
+		},
+		{
+			"unsupported_hosts_resolver",
+			true,
+			"dns_server",
+			nil,
+			"Error: vm hosts manages /etc/hosts entries, which are only used by the hosts_file hosts resolver (vm.hosts_resolver is dns_server).",
+			1,
+		},
+	}
+
+	for commandName, newCommand := range commands {
+		for _, tc := range cases {
+			t.Run(commandName+"_"+tc.name, func(t *testing.T) {
+				ui := cli.NewMockUi()
+				trellis := trellis.NewMockTrellis(tc.projectDetected)
+				trellis.CliConfig.Vm.HostsResolver = tc.hostsResolver
+
+				code := newCommand(ui, trellis).Run(tc.args)
+
+				if code != tc.code {
+					t.Errorf("expected code %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected output %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	}
+}
+
+func TestLimaHostsBlock(t *testing.T) {
+	cases := []struct {
+		manager  string
+		expected bool
+	}{
+		{"", true},
+		{"lima", true},
+		{"ssh", false},
+	}
+
+	for _, tc := range cases {
+		if got := limaHostsBlock(vm.HostsBlock{Name: "example", Manager: tc.manager}); got != tc.expected {
+			t.Errorf("expected block with manager %q to be prunable: %v, got %v", tc.manager, tc.expected, got)
+		}
+	}
+}
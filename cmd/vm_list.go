@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/posener/complete"
+	"github.com/roots/trellis-cli/pkg/lima"
+	"github.com/roots/trellis-cli/pkg/trust"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type VmListCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+	json    bool
+}
+
+func NewVmListCommand(ui cli.Ui, trellis *trellis.Trellis) *VmListCommand {
+	c := &VmListCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+func (c *VmListCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+	c.flags.BoolVar(&c.json, "json", false, "Output as JSON")
+}
+
+func (c *VmListCommand) Run(args []string) int {
+	// a project is optional; it's only loaded for its VM config
+	_ = c.Trellis.LoadProject()
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	if c.Trellis.VmManagerType() != "lima" {
+		c.UI.Error("Error: vm list is only supported by the lima VM manager.")
+		return 1
+	}
+
+	if os.Getenv("TRELLIS_BYPASS_LIMA_REQUIREMENTS") != "1" {
+		if err := lima.Installed(); err != nil {
+			c.UI.Error("Error: " + err.Error())
+			return 1
+		}
+	}
+
+	trustState, err := trust.Load()
+	if err != nil {
+		c.UI.Error("Error reading trust state: " + err.Error())
+		return 1
+	}
+
+	hostsResolver, _ := vm.NewHostsResolver(c.Trellis.CliConfig.Vm.HostsResolver, nil)
+
+	statuses := []vmStatus{}
+	for _, instance := range lima.TrellisInstances() {
+		statuses = append(statuses, newVmStatus(instance, hostsResolver, trustState))
+	}
+
+	if c.json {
+		jsonBytes, err := json.MarshalIndent(statuses, "", "  ")
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error encoding JSON: %s", err))
+			return 1
+		}
+		c.UI.Output(string(jsonBytes))
+		return 0
+	}
+
+	if len(statuses) == 0 {
+		c.UI.Info("No Trellis VMs found.")
+		return 0
+	}
+
+	rows := make([][]string, len(statuses))
+	for i, status := range statuses {
+		project := valueOrNone(status.Project)
+		if status.ProjectMissing {
+			project += " (missing)"
+		}
+
+		rows[i] = []string{status.Name, status.State, project}
+	}
+
+	c.UI.Output(formatTable([]string{"name", "state", "project"}, rows))
+	c.UI.Output("\nDelete a VM with `trellis vm delete` from its project, or `limactl delete NAME` if the project is gone.")
+	return 0
+}
+
+func (c *VmListCommand) Synopsis() string {
+	return "Lists the development virtual machines of all projects."
+}
+
+func (c *VmListCommand) Help() string {
+	helpText := `
+Usage: trellis vm list [options]
+
+Lists every development virtual machine created by trellis-cli on this machine
+(from any project) with its state and project path. Use it to find and clean
+up VMs of projects you're no longer working on.
+
+VMs created before trellis-cli recorded project paths show a project of "none"
+until they're started again.
+
+List VMs:
+
+  $ trellis vm list
+
+List VMs as JSON:
+
+  $ trellis vm list --json
+
+Options:
+      --json  Output as JSON
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VmListCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"--json": complete.PredictNothing,
+	}
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/command"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestVmListRun(t *testing.T) {
+	t.Setenv("TRELLIS_BYPASS_LIMA_REQUIREMENTS", "1")
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	projectDir := t.TempDir()
+	missingProjectDir := filepath.Join(t.TempDir(), "gone")
+
+	dirs := map[string]string{}
+	for name, project := range map[string]string{"current": projectDir, "forgotten": missingProjectDir, "legacy": "", "other": ""} {
+		dirs[name] = t.TempDir()
+
+		if project != "" {
+			if err := os.WriteFile(filepath.Join(dirs[name], "trellis-project"), []byte(project), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	defer command.MockExecCommands(t, []command.MockCommand{
+		{
+			Command: "limactl",
+			Args:    []string{"ls", "--format=json"},
+			Output: fmt.Sprintf(`{"name":"forgotten","status":"Stopped","dir":"%s"}
+{"name":"current","status":"Running","dir":"%s","sshLocalPort":60720}
+{"name":"legacy","status":"Stopped","dir":"%s","config":{"mounts":[{"location":"/old/site","mountPoint":"/srv/www/old.test/current"}]}}
+{"name":"other","status":"Running","dir":"%s"}`, dirs["forgotten"], dirs["current"], dirs["legacy"], dirs["other"]),
+		},
+	})()
+
+	trellis := trellis.NewTrellis()
+	trellis.CliConfig.Vm.Manager = "lima"
+
+	ui := cli.NewMockUi()
+	code := NewVmListCommand(ui, trellis).Run([]string{"--json"})
+	if code != 0 {
+		t.Fatalf("expected code 0, got %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	statuses := []vmStatus{}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &statuses); err != nil {
+		t.Fatalf("expected JSON output, got %q: %v", ui.OutputWriter.String(), err)
+	}
+
+	names := []string{}
+	for _, status := range statuses {
+		names = append(names, status.Name)
+	}
+
+	if strings.Join(names, ",") != "current,forgotten,legacy" {
+		t.Fatalf("expected trellis VMs sorted by name, got %v", names)
+	}
+
+	if statuses[0].Project != projectDir || statuses[0].ProjectMissing || statuses[0].SshPort != 60720 {
+		t.Errorf("unexpected status for current VM: %+v", statuses[0])
+	}
+
+	if statuses[1].Project != missingProjectDir || !statuses[1].ProjectMissing {
+		t.Errorf("expected forgotten VM's project to be missing: %+v", statuses[1])
+	}
+
+	ui = cli.NewMockUi()
+	if code := NewVmListCommand(ui, trellis).Run(nil); code != 0 {
+		t.Fatalf("expected code 0, got %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	output := ui.OutputWriter.String()
+	for _, expected := range []string{"NAME", "current    running  " + projectDir, missingProjectDir + " (missing)", "legacy     stopped  none"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output %q to contain %q", output, expected)
+		}
+	}
+}
+
+func TestVmListRunValidations(t *testing.T) {
+	ui := cli.NewMockUi()
+	trellis := trellis.NewMockTrellis(false)
+
+	code := NewVmListCommand(ui, trellis).Run([]string{"foo"})
+
+	if code != 1 || !strings.Contains(ui.ErrorWriter.String(), "Error: too many arguments") {
+		t.Errorf("expected too many arguments error, got %d: %q", code, ui.ErrorWriter.String())
+	}
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"sort"
+
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+// newSnapshotter uses the VM manager's native snapshots if the instance
+// supports them. Otherwise snapshots contain the databases and uploads of the
+// development sites.
+func newSnapshotter(t *trellis.Trellis, manager vm.Manager, instanceName string) vm.Snapshotter {
+	if native, ok := manager.(vm.NativeSnapshotter); ok && native.SnapshotsSupported(instanceName) {
+		return native
+	}
+
+	sites := []string{}
+	for name := range t.Environments["development"].WordPressSites {
+		sites = append(sites, name)
+	}
+	sort.Strings(sites)
+
+	return vm.NewDataSnapshotter(manager, sites)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type VmSnapshotCreateCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+}
+
+func NewVmSnapshotCreateCommand(ui cli.Ui, trellis *trellis.Trellis) *VmSnapshotCreateCommand {
+	c := &VmSnapshotCreateCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+func (c *VmSnapshotCreateCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+}
+
+func (c *VmSnapshotCreateCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 1, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	name := args[0]
+	if err := vm.ValidateSnapshotName(name); err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	instanceName, err := c.Trellis.GetVmInstanceName()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	manager, err := newVmManager(c.Trellis, c.UI)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	snapshotter := newSnapshotter(c.Trellis, manager, instanceName)
+	c.UI.Info(fmt.Sprintf("Creating %s snapshot %s...", snapshotter.SnapshotKind(), name))
+
+	if err := snapshotter.CreateSnapshot(instanceName, name); err != nil {
+		c.UI.Error(fmt.Sprintf("Error: could not create snapshot %s: %s", name, err))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("%s Created snapshot %s", color.GreenString("[✓]"), name))
+	return 0
+}
+
+func (c *VmSnapshotCreateCommand) Synopsis() string {
+	return "Creates a snapshot of the development virtual machine"
+}
+
+func (c *VmSnapshotCreateCommand) Help() string {
+	helpText := `
+Usage: trellis vm snapshot create [options] NAME
+
+Creates a snapshot of the development virtual machine to restore later with
+'trellis vm snapshot restore'. Use it as a checkpoint before risky changes like
+plugin upgrades or migrations.
+
+Lima VMs using QEMU (Linux) with vm.sync_mode: rsync get a snapshot of the VM's
+disk and memory. Since mounted sites (the default sync mode) live on the host
+and aren't part of a VM snapshot, all other VMs (eg: macOS) get a snapshot of
+each site's database and uploads (web/app/uploads), which is stored inside the
+VM. The VM must be running for these.
+
+Create a snapshot:
+
+  $ trellis vm snapshot create before-upgrade
+
+Arguments:
+  NAME  Name of the snapshot (letters, numbers, dots, dashes and underscores)
+
+Options:
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
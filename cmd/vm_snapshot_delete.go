@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type VmSnapshotDeleteCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+}
+
+func NewVmSnapshotDeleteCommand(ui cli.Ui, trellis *trellis.Trellis) *VmSnapshotDeleteCommand {
+	c := &VmSnapshotDeleteCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+func (c *VmSnapshotDeleteCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+}
+
+func (c *VmSnapshotDeleteCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 1, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	name := args[0]
+	if err := vm.ValidateSnapshotName(name); err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	instanceName, err := c.Trellis.GetVmInstanceName()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	manager, err := newVmManager(c.Trellis, c.UI)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	snapshotter := newSnapshotter(c.Trellis, manager, instanceName)
+	c.UI.Info(fmt.Sprintf("Deleting %s snapshot %s...", snapshotter.SnapshotKind(), name))
+
+	if err := snapshotter.DeleteSnapshot(instanceName, name); err != nil {
+		c.UI.Error(fmt.Sprintf("Error: could not delete snapshot %s: %s", name, err))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("%s Deleted snapshot %s", color.GreenString("[✓]"), name))
+	return 0
+}
+
+func (c *VmSnapshotDeleteCommand) Synopsis() string {
+	return "Deletes a snapshot of the development virtual machine"
+}
+
+func (c *VmSnapshotDeleteCommand) Help() string {
+	helpText := `
+Usage: trellis vm snapshot delete [options] NAME
+
+Deletes a snapshot of the development virtual machine.
+
+Delete a snapshot:
+
+  $ trellis vm snapshot delete before-upgrade
+
+Arguments:
+  NAME  Name of the snapshot
+
+Options:
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/posener/complete"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type VmSnapshotListCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+	json    bool
+}
+
+func NewVmSnapshotListCommand(ui cli.Ui, trellis *trellis.Trellis) *VmSnapshotListCommand {
+	c := &VmSnapshotListCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+func (c *VmSnapshotListCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+	c.flags.BoolVar(&c.json, "json", false, "Output as JSON")
+}
+
+func (c *VmSnapshotListCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	instanceName, err := c.Trellis.GetVmInstanceName()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	manager, err := newVmManager(c.Trellis, c.UI)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	snapshotter := newSnapshotter(c.Trellis, manager, instanceName)
+
+	snapshots, err := snapshotter.ListSnapshots(instanceName)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error: could not list snapshots: %s", err))
+		return 1
+	}
+
+	if c.json {
+		jsonBytes, err := json.MarshalIndent(snapshots, "", "  ")
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error encoding JSON: %s", err))
+			return 1
+		}
+		c.UI.Output(string(jsonBytes))
+		return 0
+	}
+
+	if len(snapshots) == 0 {
+		c.UI.Info("No snapshots found. Create one with `trellis vm snapshot create NAME`.")
+		return 0
+	}
+
+	rows := make([][]string, len(snapshots))
+	for i, snapshot := range snapshots {
+		rows[i] = []string{snapshot.Name, valueOrNone(snapshot.CreatedAt), snapshotter.SnapshotKind()}
+	}
+
+	c.UI.Output(formatTable([]string{"name", "created", "contents"}, rows))
+	return 0
+}
+
+func (c *VmSnapshotListCommand) Synopsis() string {
+	return "Lists the snapshots of the development virtual machine"
+}
+
+func (c *VmSnapshotListCommand) Help() string {
+	helpText := `
+Usage: trellis vm snapshot list [options]
+
+Lists the snapshots of the development virtual machine.
+
+List snapshots:
+
+  $ trellis vm snapshot list
+
+Options:
+      --json  Output as JSON
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VmSnapshotListCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"--json": complete.PredictNothing,
+	}
+}
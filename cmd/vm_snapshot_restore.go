@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type VmSnapshotRestoreCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+}
+
+func NewVmSnapshotRestoreCommand(ui cli.Ui, trellis *trellis.Trellis) *VmSnapshotRestoreCommand {
+	c := &VmSnapshotRestoreCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+func (c *VmSnapshotRestoreCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+}
+
+func (c *VmSnapshotRestoreCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 1, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	name := args[0]
+	if err := vm.ValidateSnapshotName(name); err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	instanceName, err := c.Trellis.GetVmInstanceName()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	manager, err := newVmManager(c.Trellis, c.UI)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	snapshotter := newSnapshotter(c.Trellis, manager, instanceName)
+	c.UI.Info(fmt.Sprintf("Restoring %s snapshot %s...", snapshotter.SnapshotKind(), name))
+
+	if err := snapshotter.RestoreSnapshot(instanceName, name); err != nil {
+		c.UI.Error(fmt.Sprintf("Error: could not restore snapshot %s: %s", name, err))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("%s Restored snapshot %s", color.GreenString("[✓]"), name))
+	return 0
+}
+
+func (c *VmSnapshotRestoreCommand) Synopsis() string {
+	return "Restores a snapshot of the development virtual machine"
+}
+
+func (c *VmSnapshotRestoreCommand) Help() string {
+	helpText := `
+Usage: trellis vm snapshot restore [options] NAME
+
+Restores a snapshot of the development virtual machine.
+
+VM snapshots replace the VM's disk and memory state. Database and uploads snapshots
+replace each site's database and uploads directory (web/app/uploads); any
+uploads added since the snapshot are removed.
+
+Restore a snapshot:
+
+  $ trellis vm snapshot restore before-upgrade
+
+Arguments:
+  NAME  Name of the snapshot
+
+Options:
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestVmSnapshotRunValidations(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	cases := []struct {
+		name            string
+		command         func(cli.Ui, *trellis.Trellis) cli.Command
+		projectDetected bool
+		args            []string
+		out             string
+		code            int
+	}{
+		{
+			"create_no_project",
+			func(ui cli.Ui, t *trellis.Trellis) cli.Command { return NewVmSnapshotCreateCommand(ui, t) },
+			false,
+			[]string{"before-upgrade"},
+			"No Trellis project detected",
+			1,
+		},
+		{
+			"create_no_args",
+			func(ui cli.Ui, t *trellis.Trellis) cli.Command { return NewVmSnapshotCreateCommand(ui, t) },
+			true,
+			nil,
+			"Error: missing arguments (expected exactly 1, got 0)",
+			1,
+		},
+		{
+			"create_invalid_name",
+			func(ui cli.Ui, t *trellis.Trellis) cli.Command { return NewVmSnapshotCreateCommand(ui, t) },
+			true,
+			[]string{"../foo"},
+			"Error: invalid snapshot name",
+			1,
+		},
+		{
+			"restore_too_many_args",
+			func(ui cli.Ui, t *trellis.Trellis) cli.Command { return NewVmSnapshotRestoreCommand(ui, t) },
+			true,
+			[]string{"foo", "bar"},
+			"Error: too many arguments",
+			1,
+		},
+		{
+			"delete_no_args",
+			func(ui cli.Ui, t *trellis.Trellis) cli.Command { return NewVmSnapshotDeleteCommand(ui, t) },
+			true,
+			nil,
+			"Error: missing arguments (expected exactly 1, got 0)",
+			1,
+		},
+		{
+			"list_too_many_args",
+			func(ui cli.Ui, t *trellis.Trellis) cli.Command { return NewVmSnapshotListCommand(ui, t) },
+			true,
+			[]string{"foo"},
+			"Error: too many arguments",
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			trellis := trellis.NewMockTrellis(tc.projectDetected)
+
+			code := tc.command(ui, trellis).Run(tc.args)
+
+			if code != tc.code {
+				t.Errorf("expected code %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected output %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+}
+
+func TestVmSnapshotCreateRun(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	ui := cli.NewMockUi()
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+	trellis.CliConfig.Vm.Manager = "mock"
+
+	code := NewVmSnapshotCreateCommand(ui, trellis).Run([]string{"before-upgrade"})
+	output := ui.OutputWriter.String()
+
+	if code != 0 || !strings.Contains(output, "Creating database and uploads snapshot before-upgrade") || !strings.Contains(output, "Created snapshot before-upgrade") {
+		t.Errorf("expected snapshot to be created, got %d: %q %q", code, output, ui.ErrorWriter.String())
+	}
+}
@@ -3,9 +3,11 @@ package cmd
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"strings"
 
 	"github.com/hashicorp/cli"
+	"github.com/manifoldco/promptui"
 	"github.com/roots/trellis-cli/pkg/vm"
 	"github.com/roots/trellis-cli/trellis"
 )
@@ -61,41 +63,85 @@ func (c *VmStartCommand) Run(args []string) int {
 		return 1
 	}
 
-	err = manager.StartInstance(instanceName)
-	if err == nil {
+	created, err := startVmInstance(c.UI, manager, instanceName)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	if !created {
 		c.printInstanceInfo()
 		return 0
 	}
 
+	c.UI.Info("\nProvisioning VM...")
+
+	provisionCmd := NewProvisionCommand(c.UI, c.Trellis)
+	code := provisionCmd.Run([]string{"development"})
+
+	if code == 0 {
+		c.printInstanceInfo()
+	}
+
+	return code
+}
+
+/*
+startVmInstance starts the VM, offering to apply changed VM settings from the
+CLI config first. If the VM doesn't exist yet, it's created and started instead
+and created is true so callers know it still needs to be provisioned.
+*/
+func startVmInstance(ui cli.Ui, manager vm.Manager, instanceName string) (created bool, err error) {
+	if updater, ok := manager.(vm.ConfigUpdater); ok {
+		if err := applyConfigChanges(ui, updater, instanceName); err != nil {
+			return false, fmt.Errorf("could not update the VM configuration: %w", err)
+		}
+	}
+
+	err = manager.StartInstance(instanceName)
+	if err == nil {
+		return false, nil
+	}
+
 	if !errors.Is(err, vm.ErrVmNotFound) {
-		c.UI.Error("Error starting VM.")
-		c.UI.Error(err.Error())
-		return 1
+		return false, fmt.Errorf("could not start the VM: %w", err)
 	}
 
 	// VM doesn't exist yet, create and start it
 	if err = manager.CreateInstance(instanceName); err != nil {
-		c.UI.Error("Error creating VM.")
-		c.UI.Error(err.Error())
-		return 1
+		return false, fmt.Errorf("could not create the VM: %w", err)
 	}
 
 	if err = manager.StartInstance(instanceName); err != nil {
-		c.UI.Error("Error starting VM.")
-		c.UI.Error(err.Error())
-		return 1
+		return true, fmt.Errorf("could not start the VM: %w", err)
 	}
 
-	c.UI.Info("\nProvisioning VM...")
+	return true, nil
+}
 
-	provisionCmd := NewProvisionCommand(c.UI, c.Trellis)
-	code := provisionCmd.Run([]string{"development"})
+// applyConfigChanges offers to apply VM settings from the CLI config which differ from the existing VM.
+func applyConfigChanges(ui cli.Ui, updater vm.ConfigUpdater, instanceName string) error {
+	changes, err := updater.ConfigChanges(instanceName)
+	if err != nil {
+		return err
+	}
 
-	if code == 0 {
-		c.printInstanceInfo()
+	if len(changes) == 0 {
+		return nil
 	}
 
-	return code
+	ui.Info("VM settings in the CLI config differ from the existing VM:")
+	for _, change := range changes {
+		ui.Info(fmt.Sprintf("  %s", change))
+	}
+
+	prompt := promptui.Prompt{Label: "Apply changes (a running VM will be restarted)", IsConfirm: true}
+	if _, err = prompt.Run(); err != nil {
+		ui.Info("Skipped. The VM will start with its existing settings.")
+		return nil
+	}
+
+	return updater.ApplyConfigChanges(instanceName)
 }
 
 func (c *VmStartCommand) Synopsis() string {
@@ -109,9 +155,16 @@ Usage: trellis vm start [options]
 Starts a development virtual machine.
 If a VM doesn't exist yet, it will be created. If a VM already exists, it will be started.
 
+VM settings (vm.cpus, vm.memory, vm.disk and vm.port_forwards in the CLI config)
+are applied when the VM is created. If they've changed since, you'll be asked
+whether to apply them to the existing VM.
+
 Lima (https://lima-vm.io/) is the underlying VM manager.
 Local VM support requires macOS 13.0+ or Linux with Lima and QEMU/KVM.
 
+With vm.manager set to ssh, an existing machine is used instead (see vm.ssh in
+the CLI config). Starting it syncs the sites and adds the hosts entries.
+
 Options:
   -h, --help show this help
 `
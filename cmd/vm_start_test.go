@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
 	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/vm"
 	"github.com/roots/trellis-cli/trellis"
 )
 
@@ -54,3 +56,48 @@ func TestVmStartRunValidations(t *testing.T) {
 		})
 	}
 }
+
+type configUpdaterVmManager struct {
+	vm.MockVmManager
+	changesErr error
+	started    bool
+}
+
+func (m *configUpdaterVmManager) ConfigChanges(name string) ([]string, error) {
+	return nil, m.changesErr
+}
+
+func (m *configUpdaterVmManager) ApplyConfigChanges(name string) error {
+	return nil
+}
+
+func (m *configUpdaterVmManager) StartInstance(name string) error {
+	m.started = true
+	return nil
+}
+
+func TestStartVmInstanceChecksConfigChanges(t *testing.T) {
+	ui := cli.NewMockUi()
+	manager := &configUpdaterVmManager{changesErr: errors.New("limactl failed")}
+
+	_, err := startVmInstance(ui, manager, "example.com")
+
+	if err == nil || !strings.Contains(err.Error(), "could not update the VM configuration: limactl failed") {
+		t.Errorf("expected a config update error, got %v", err)
+	}
+
+	if manager.started {
+		t.Error("expected the VM not to be started after a config update error")
+	}
+
+	manager.changesErr = nil
+	created, err := startVmInstance(ui, manager, "example.com")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if created || !manager.started {
+		t.Errorf("expected the existing VM to be started, got created=%v started=%v", created, manager.started)
+	}
+}
@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/posener/complete"
+	"github.com/roots/trellis-cli/pkg/lima"
+	"github.com/roots/trellis-cli/pkg/trust"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type VmStatusCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+	json    bool
+}
+
+type vmStatus struct {
+	Name           string          `json:"name"`
+	State          string          `json:"state"`
+	Project        string          `json:"project,omitempty"`
+	ProjectMissing bool            `json:"project_missing,omitempty"`
+	IP             string          `json:"ip,omitempty"`
+	SshPort        int             `json:"ssh_port,omitempty"`
+	PortForwards   []vmPortForward `json:"port_forwards"`
+	Mounts         []vmMount       `json:"mounts"`
+	HostsEntry     bool            `json:"hosts_entry"`
+	TrustedSites   []string        `json:"trusted_sites"`
+}
+
+type vmPortForward struct {
+	Guest int `json:"guest"`
+	Host  int `json:"host"`
+}
+
+type vmMount struct {
+	Location   string `json:"location"`
+	MountPoint string `json:"mount_point"`
+}
+
+func NewVmStatusCommand(ui cli.Ui, trellis *trellis.Trellis) *VmStatusCommand {
+	c := &VmStatusCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+func (c *VmStatusCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+	c.flags.BoolVar(&c.json, "json", false, "Output as JSON")
+}
+
+func (c *VmStatusCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	if c.Trellis.VmManagerType() != "lima" {
+		c.UI.Error("Error: vm status is only supported by the lima VM manager.")
+		return 1
+	}
+
+	instanceName, err := c.Trellis.GetVmInstanceName()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	manager, err := lima.NewManager(c.Trellis, c.UI)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	status := vmStatus{Name: instanceName, State: "not created"}
+
+	if instance, ok := manager.GetInstance(instanceName); ok {
+		trustState, err := trust.Load()
+		if err != nil {
+			c.UI.Error("Error reading trust state: " + err.Error())
+			return 1
+		}
+
+		status = newVmStatus(instance, manager.HostsResolver, trustState)
+
+		if instance.Running() {
+			status.IP, _ = instance.IP()
+		}
+	}
+
+	if c.json {
+		jsonBytes, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error encoding JSON: %s", err))
+			return 1
+		}
+		c.UI.Output(string(jsonBytes))
+		return 0
+	}
+
+	c.printStatus(status)
+	return 0
+}
+
+// newVmStatus describes a Lima instance. The IP address requires a running VM so it's left to the caller.
+func newVmStatus(instance lima.Instance, hostsResolver vm.HostsResolver, trustState *trust.State) vmStatus {
+	status := vmStatus{
+		Name:         instance.Name,
+		State:        strings.ToLower(instance.Status),
+		Project:      instance.ProjectPath,
+		PortForwards: []vmPortForward{},
+		Mounts:       []vmMount{},
+		TrustedSites: []string{},
+	}
+
+	if instance.Running() {
+		status.SshPort = instance.SshLocalPort
+	}
+
+	if status.Project != "" {
+		if _, err := os.Stat(status.Project); err != nil {
+			status.ProjectMissing = true
+		}
+
+		for _, entry := range trustState.EntriesForProject(status.Project) {
+			status.TrustedSites = append(status.TrustedSites, entry.Site)
+		}
+	}
+
+	for _, forward := range instance.Config.PortForwards {
+		status.PortForwards = append(status.PortForwards, vmPortForward{Guest: forward.GuestPort, Host: forward.HostPort})
+	}
+
+	for _, mount := range instance.Config.Mounts {
+		status.Mounts = append(status.Mounts, vmMount{Location: mount.Location, MountPoint: mount.MountPoint})
+	}
+
+	if hostsResolver != nil {
+		status.HostsEntry, _ = hostsResolver.HasHosts(instance.Name)
+	}
+
+	return status
+}
+
+func (c *VmStatusCommand) printStatus(status vmStatus) {
+	bold := color.New(color.Bold).SprintFunc()
+
+	c.UI.Output(fmt.Sprintf("%s %s", bold("Name:"), status.Name))
+	c.UI.Output(fmt.Sprintf("%s %s", bold("State:"), status.State))
+
+	if status.State == "not created" {
+		c.UI.Output("\nRun `trellis vm start` to create it.")
+		return
+	}
+
+	c.UI.Output(fmt.Sprintf("%s %s", bold("IP:"), valueOrNone(status.IP)))
+
+	sshPort := "none"
+	if status.SshPort != 0 {
+		sshPort = fmt.Sprint(status.SshPort)
+	}
+	c.UI.Output(fmt.Sprintf("%s %s", bold("SSH port:"), sshPort))
+
+	forwards := []string{}
+	for _, forward := range status.PortForwards {
+		forwards = append(forwards, fmt.Sprintf("%d → %d", forward.Guest, forward.Host))
+	}
+	c.UI.Output(fmt.Sprintf("%s %s", bold("Port forwards:"), valueOrNone(strings.Join(forwards, ", "))))
+
+	c.UI.Output(bold("Mounts:"))
+	if len(status.Mounts) == 0 {
+		c.UI.Output("  none")
+	}
+	for _, mount := range status.Mounts {
+		c.UI.Output(fmt.Sprintf("  %s → %s", mount.Location, mount.MountPoint))
+	}
+
+	hostsEntry := "missing"
+	if status.HostsEntry {
+		hostsEntry = "present"
+	}
+	c.UI.Output(fmt.Sprintf("%s %s", bold("Hosts entry:"), hostsEntry))
+	c.UI.Output(fmt.Sprintf("%s %s", bold("Trusted certs:"), valueOrNone(strings.Join(status.TrustedSites, ", "))))
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+
+	return value
+}
+
+func (c *VmStatusCommand) Synopsis() string {
+	return "Shows the status of the development virtual machine."
+}
+
+func (c *VmStatusCommand) Help() string {
+	helpText := `
+Usage: trellis vm status [options]
+
+Shows the status of this project's development virtual machine: its state, IP
+address, SSH port, forwarded ports, mounts, whether its hosts entry is present,
+and which sites have trusted certificates (see 'trellis vm trust').
+
+Show the status:
+
+  $ trellis vm status
+
+Show the status as JSON:
+
+  $ trellis vm status --json
+
+Options:
+      --json  Output as JSON
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VmStatusCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"--json": complete.PredictNothing,
+	}
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/command"
+	"github.com/roots/trellis-cli/pkg/trust"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestVmStatusRunValidations(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	cases := []struct {
+		name            string
+		projectDetected bool
+		args            []string
+		out             string
+		code            int
+	}{
+		{
+			"no_project",
+			false,
+			nil,
+			"No Trellis project detected",
+			1,
+		},
+		{
+			"too_many_args",
+			true,
+			[]string{"foo"},
+			"Error: too many arguments",
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			trellis := trellis.NewMockTrellis(tc.projectDetected)
+			vmStatusCommand := NewVmStatusCommand(ui, trellis)
+
+			code := vmStatusCommand.Run(tc.args)
+
+			if code != tc.code {
+				t.Errorf("expected code %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected output %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+}
+
+func TestVmStatusRun(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	t.Setenv("TRELLIS_BYPASS_LIMA_REQUIREMENTS", "1")
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+	trellis.CliConfig.Vm.Manager = "lima"
+
+	state := &trust.State{}
+	state.Upsert(trust.Entry{Project: trellis.Path, Site: "example.com"})
+	if err := state.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	instanceDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(instanceDir, "trellis-project"), []byte(trellis.Path+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer command.MockExecCommands(t, []command.MockCommand{
+		{
+			Command: "limactl",
+			Args:    []string{"ls", "--format=json"},
+			Output:  fmt.Sprintf(`{"name":"example.com","status":"Stopped","dir":"%s","vmType":"vz","config":{"portForwards":[{"guestPort":80,"hostPort":60720}],"mounts":[{"location":"/projects/example.com/site","mountPoint":"/srv/www/example.com/current"}]}}`, instanceDir),
+		},
+	})()
+
+	ui := cli.NewMockUi()
+	code := NewVmStatusCommand(ui, trellis).Run([]string{"--json"})
+	if code != 0 {
+		t.Fatalf("expected code 0, got %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	status := vmStatus{}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &status); err != nil {
+		t.Fatalf("expected JSON output, got %q: %v", ui.OutputWriter.String(), err)
+	}
+
+	expected := vmStatus{
+		Name:         "example.com",
+		State:        "stopped",
+		Project:      trellis.Path,
+		PortForwards: []vmPortForward{{Guest: 80, Host: 60720}},
+		Mounts:       []vmMount{{Location: "/projects/example.com/site", MountPoint: "/srv/www/example.com/current"}},
+		TrustedSites: []string{"example.com"},
+	}
+
+	if !reflect.DeepEqual(status, expected) {
+		t.Errorf("expected %+v, got %+v", expected, status)
+	}
+
+	ui = cli.NewMockUi()
+	if code := NewVmStatusCommand(ui, trellis).Run(nil); code != 0 {
+		t.Fatalf("expected code 0, got %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	for _, expected := range []string{"stopped", "80 → 60720", "/projects/example.com/site → /srv/www/example.com/current", "example.com"} {
+		if !strings.Contains(ui.OutputWriter.String(), expected) {
+			t.Errorf("expected output %q to contain %q", ui.OutputWriter.String(), expected)
+		}
+	}
+}
+
+func TestVmStatusRunNotCreated(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	t.Setenv("TRELLIS_BYPASS_LIMA_REQUIREMENTS", "1")
+
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+	trellis.CliConfig.Vm.Manager = "lima"
+
+	defer command.MockExecCommands(t, []command.MockCommand{
+		{Command: "limactl", Args: []string{"ls", "--format=json"}},
+	})()
+
+	ui := cli.NewMockUi()
+	code := NewVmStatusCommand(ui, trellis).Run(nil)
+
+	if code != 0 || !strings.Contains(ui.OutputWriter.String(), "not created") {
+		t.Errorf("expected not created status, got %d: %q", code, ui.OutputWriter.String())
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"runtime"
 	"strings"
 
 	"github.com/hashicorp/cli"
@@ -32,12 +33,27 @@ func (c *VmSudoersCommand) Run(args []string) int {
 		return 1
 	}
 
-	hostResolver := vm.NewHostsFileResolver([]string{})
-	cmd := hostResolver.SudoersCommand()
-	line := fmt.Sprintf("%%staff ALL=(root:wheel) NOPASSWD:NOSETENV: %s", strings.Join(cmd, " "))
+	hostsResolver, err := vm.NewHostsResolver(c.Trellis.CliConfig.Vm.HostsResolver, []string{})
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	sudoersCommander, ok := hostsResolver.(vm.SudoersCommander)
+	if !ok {
+		c.UI.Info(fmt.Sprintf("The %s hosts resolver doesn't need any sudoers rules.", c.Trellis.CliConfig.Vm.HostsResolver))
+		return 0
+	}
+
+	commands := []string{}
+	for _, cmd := range sudoersCommander.SudoersCommands() {
+		commands = append(commands, strings.Join(cmd, " "))
+	}
+
+	line := fmt.Sprintf("%s NOPASSWD:NOSETENV: %s", sudoersSpec(), strings.Join(commands, ", "))
 
 	if stdoutIsTerminal() {
-		c.UI.Warn("The following sudoers rule lets trellis-cli update /etc/hosts without prompting for your password.")
+		c.UI.Warn("The following sudoers rule lets trellis-cli update your development hosts without prompting for your password.")
 		c.UI.Warn("")
 		c.UI.Warn("To install it, re-run this command and pipe the output to tee:")
 		c.UI.Warn("")
@@ -52,13 +68,22 @@ func (c *VmSudoersCommand) Run(args []string) int {
 	return 0
 }
 
+// sudoersSpec is the user and runas part of the rule for the admin group of the OS.
+func sudoersSpec() string {
+	if runtime.GOOS == "linux" {
+		return "%sudo ALL=(root)"
+	}
+
+	return "%staff ALL=(root:wheel)"
+}
+
 func stdoutIsTerminal() bool {
 	fd := os.Stdout.Fd()
 	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
 }
 
 func (c *VmSudoersCommand) Synopsis() string {
-	return "Generates sudoers content for passwordless updating of /etc/hosts"
+	return "Generates sudoers content for passwordless updating of development hosts"
 }
 
 func (c *VmSudoersCommand) Help() string {
@@ -66,7 +91,10 @@ func (c *VmSudoersCommand) Help() string {
 Usage: trellis vm sudoers [options]
 
 Generates the content of the /etc/sudoers.d/trellis file.
-This allows trellis-cli to update your /etc/hosts file without having to enter your sudo password.
+This allows trellis-cli to update your development hosts without having to enter your sudo password.
+The rule matches the configured hosts resolver (vm.hosts_resolver): /etc/hosts for hosts_file,
+or the dnsmasq (and systemd-resolved) configs and service restarts for dnsmasq and resolved.
+On Linux the rule applies to the sudo group; change %sudo to %wheel on distros which use it.
 
 The content is written to stdout, NOT to the file. This command must not run as the root as shown below.
 
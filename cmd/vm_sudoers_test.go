@@ -32,6 +32,13 @@ func TestVmSudoersRunValidations(t *testing.T) {
 			"Error: too many arguments",
 			1,
 		},
+		{
+			"hosts_file",
+			true,
+			nil,
+			"NOPASSWD:NOSETENV: /bin/cp",
+			0,
+		},
 	}
 
 	for _, tc := range cases {
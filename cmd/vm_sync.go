@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+// newFileSyncer returns the VM manager if it supports syncing site files (`vm.sync_mode`).
+func newFileSyncer(t *trellis.Trellis, ui cli.Ui) (vm.FileSyncer, error) {
+	manager, err := newVmManager(t, ui)
+	if err != nil {
+		return nil, err
+	}
+
+	syncer, ok := manager.(vm.FileSyncer)
+	if !ok {
+		return nil, fmt.Errorf("vm.sync_mode is only supported by the Lima VM manager (vm.manager is %s).", t.VmManagerType())
+	}
+
+	return syncer, nil
+}
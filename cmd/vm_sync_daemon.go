@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type VmSyncDaemonCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+}
+
+func (c *VmSyncDaemonCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	instanceName, err := c.Trellis.GetVmInstanceName()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	syncer, err := newFileSyncer(c.Trellis, c.UI)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	if status, _ := syncer.SyncStatus(instanceName); status != nil && status.Running() && status.Pid != os.Getpid() {
+		c.UI.Error(fmt.Sprintf("Error: the sync daemon is already running (pid %d).", status.Pid))
+		return 1
+	}
+
+	daemon, err := syncer.SyncDaemon(instanceName)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	flush := make(chan os.Signal, 1)
+	signal.Notify(flush, syscall.SIGUSR1)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	c.UI.Info(fmt.Sprintf("Syncing site files with %s", instanceName))
+
+	if err := daemon.Run(flush, stop); err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func (c *VmSyncDaemonCommand) Synopsis() string {
+	return "Runs the VM's file sync daemon."
+}
+
+func (c *VmSyncDaemonCommand) Help() string {
+	helpText := `
+Usage: trellis vm sync daemon
+
+Keeps the site directories and their copies in the VM in sync when
+vm.sync_mode is rsync. This shouldn't be manually run; it's started by
+'trellis vm start' and stopped by 'trellis vm stop'.
+
+Options:
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type VmSyncFlushCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+}
+
+func NewVmSyncFlushCommand(ui cli.Ui, trellis *trellis.Trellis) *VmSyncFlushCommand {
+	c := &VmSyncFlushCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+func (c *VmSyncFlushCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+}
+
+func (c *VmSyncFlushCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	instanceName, err := c.Trellis.GetVmInstanceName()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	syncer, err := newFileSyncer(c.Trellis, c.UI)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	if syncer.SyncMode() != "rsync" {
+		c.UI.Error("Error: site directories are mounted in the VM so there's nothing to flush (vm.sync_mode is mount).")
+		return 1
+	}
+
+	status, err := syncer.FlushSync(instanceName)
+	if errors.Is(err, vm.ErrVmNotFound) {
+		c.UI.Error("Error: VM does not exist for this project. Run `trellis vm start` to create it.")
+		return 1
+	}
+	if err != nil {
+		c.UI.Error("Error: could not sync files: " + err.Error())
+		return 1
+	}
+
+	if status.LastError != "" {
+		c.UI.Error("Error: could not sync files: " + status.LastError)
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("%s Site files synced", color.GreenString("[✓]")))
+	return 0
+}
+
+func (c *VmSyncFlushCommand) Synopsis() string {
+	return "Syncs the site files with the VM immediately"
+}
+
+func (c *VmSyncFlushCommand) Help() string {
+	helpText := `
+Usage: trellis vm sync flush [options]
+
+Syncs the site directories with the VM immediately and waits until it's done
+(when vm.sync_mode is rsync). Changes are otherwise synced every few seconds by
+the sync daemon, which is restarted if it isn't running.
+
+Useful before running a command in the VM which depends on a file just changed:
+
+  $ trellis vm sync flush
+
+Options:
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
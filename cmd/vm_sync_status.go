@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type VmSyncStatusCommand struct {
+	UI      cli.Ui
+	Trellis *trellis.Trellis
+	flags   *flag.FlagSet
+}
+
+func NewVmSyncStatusCommand(ui cli.Ui, trellis *trellis.Trellis) *VmSyncStatusCommand {
+	c := &VmSyncStatusCommand{UI: ui, Trellis: trellis}
+	c.init()
+	return c
+}
+
+func (c *VmSyncStatusCommand) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Usage = func() { c.UI.Info(c.Help()) }
+}
+
+func (c *VmSyncStatusCommand) Run(args []string) int {
+	if err := c.Trellis.LoadProject(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+
+	commandArgumentValidator := &CommandArgumentValidator{required: 0, optional: 0}
+	commandArgumentErr := commandArgumentValidator.validate(args)
+	if commandArgumentErr != nil {
+		c.UI.Error(commandArgumentErr.Error())
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	instanceName, err := c.Trellis.GetVmInstanceName()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	syncer, err := newFileSyncer(c.Trellis, c.UI)
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	c.UI.Output(fmt.Sprintf("Sync mode: %s", syncer.SyncMode()))
+
+	if syncer.SyncMode() != "rsync" {
+		c.UI.Output("Site directories are mounted in the VM. Set `vm.sync_mode: rsync` in trellis.cli.yml to sync them instead.")
+		return 0
+	}
+
+	c.UI.Output(fmt.Sprintf("Excludes: %s", strings.Join(syncer.SyncExcludes(), ", ")))
+
+	status, err := syncer.SyncStatus(instanceName)
+	if errors.Is(err, vm.ErrVmNotFound) {
+		c.UI.Output("Daemon: not running (the VM does not exist. Run `trellis vm start` to create it.)")
+		return 0
+	}
+	if err != nil {
+		c.UI.Error("Error: " + err.Error())
+		return 1
+	}
+
+	if status == nil {
+		c.UI.Output("Daemon: not running (it's started by `trellis vm start`)")
+		return 0
+	}
+
+	if status.Running() {
+		c.UI.Output(fmt.Sprintf("Daemon: %s (pid %d, since %s)", color.GreenString("running"), status.Pid, status.StartedAt.Format(time.DateTime)))
+	} else {
+		c.UI.Output(fmt.Sprintf("Daemon: %s (it's started by `trellis vm start`)", color.YellowString("not running")))
+	}
+
+	if !status.LastSync.IsZero() {
+		c.UI.Output(fmt.Sprintf("Last sync: %s (%s ago)", status.LastSync.Format(time.DateTime), time.Since(status.LastSync).Round(time.Second)))
+	}
+
+	c.UI.Output(fmt.Sprintf("Synced: %d pushed, %d pulled, %d deleted", status.Pushed, status.Pulled, status.Deleted))
+
+	if status.LastError != "" {
+		c.UI.Error(fmt.Sprintf("Last error: %s", status.LastError))
+		return 1
+	}
+
+	return 0
+}
+
+func (c *VmSyncStatusCommand) Synopsis() string {
+	return "Shows the status of the VM's file sync"
+}
+
+func (c *VmSyncStatusCommand) Help() string {
+	helpText := `
+Usage: trellis vm sync status [options]
+
+Shows the status of the file sync between the site directories and the VM when
+vm.sync_mode is rsync: whether the sync daemon is running, when it last synced,
+the number of files synced since it started, and the last error.
+
+Show the status:
+
+  $ trellis vm sync status
+
+Options:
+  -h, --help  show this help
+`
+
+	return strings.TrimSpace(helpText)
+}
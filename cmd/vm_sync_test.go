@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestVmSyncRunValidations(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	commands := map[string]func(cli.Ui, *trellis.Trellis) cli.Command{
+		"status": func(ui cli.Ui, t *trellis.Trellis) cli.Command { return NewVmSyncStatusCommand(ui, t) },
+		"flush":  func(ui cli.Ui, t *trellis.Trellis) cli.Command { return NewVmSyncFlushCommand(ui, t) },
+		"daemon": func(ui cli.Ui, t *trellis.Trellis) cli.Command { return &VmSyncDaemonCommand{ui, t} },
+	}
+
+	cases := []struct {
+		name            string
+		projectDetected bool
+		args            []string
+		out             string
+		code            int
+	}{
+		{
+			"no_project",
+			false,
+			nil,
+			"No Trellis project detected",
+			1,
+		},
+		{
+			"too_many_args",
+			true,
+			[]string{"foo"},
+			"Error: too many arguments",
+			1,
+		},
+		{
+			"unsupported_manager",
+			true,
+			nil,
+			"Error: vm.sync_mode is only supported by the Lima VM manager (vm.manager is mock).",
+			1,
+		},
+	}
+
+	for commandName, newCommand := range commands {
+		for _, tc := range cases {
+			t.Run(commandName+"_"+tc.name, func(t *testing.T) {
+				ui := cli.NewMockUi()
+				trellis := trellis.NewMockTrellis(tc.projectDetected)
+				trellis.CliConfig.Vm.Manager = "mock"
+
+				code := newCommand(ui, trellis).Run(tc.args)
+
+				if code != tc.code {
+					t.Errorf("expected code %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected output %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package command
+
+import "strings"
+
+// ShellQuote quotes a string as a single argument for a POSIX shell (eg: a remote command run over SSH).
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ShellJoin quotes each argument and joins them into a POSIX shell command.
+func ShellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = ShellQuote(arg)
+	}
+
+	return strings.Join(quoted, " ")
+}
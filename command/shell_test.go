@@ -0,0 +1,12 @@
+package command
+
+import "testing"
+
+func TestShellJoin(t *testing.T) {
+	got := ShellJoin([]string{"wp", "option", "get", "it's"})
+	expected := `'wp' 'option' 'get' 'it'\''s'`
+
+	if got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
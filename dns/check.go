@@ -0,0 +1,148 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"slices"
+	"time"
+)
+
+type CheckStatus string
+
+const (
+	CheckOk         CheckStatus = "ok"
+	CheckMismatch   CheckStatus = "mismatch"
+	CheckUnresolved CheckStatus = "unresolved"
+)
+
+// HostCheck is the result of resolving a single host and comparing it against the expected server IPs.
+type HostCheck struct {
+	Host      string
+	Addresses []string
+	Status    CheckStatus
+	Err       error
+}
+
+/*
+NewResolver returns a resolver which sends all queries to the given DNS server
+address (eg: "1.1.1.1:53"). The system resolver is returned if address is empty.
+*/
+func NewResolver(address string) *net.Resolver {
+	if address == "" {
+		return net.DefaultResolver
+	}
+
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "53")
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{Timeout: 5 * time.Second}
+			return d.DialContext(ctx, network, address)
+		},
+	}
+}
+
+/*
+ResolveServers returns the IP addresses of the given inventory hosts.
+Hosts which are already IP addresses are returned as-is and host names are
+resolved. Hosts which don't resolve (eg: SSH config aliases or the
+your_server_hostname placeholder) are returned as unresolved.
+*/
+func ResolveServers(ctx context.Context, resolver *net.Resolver, hosts []string) (ips []string, unresolved []string) {
+	ips = []string{}
+	unresolved = []string{}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			ips = appendIP(ips, ip)
+			continue
+		}
+
+		resolved, err := resolver.LookupIP(ctx, "ip", host)
+		if err != nil || len(resolved) == 0 {
+			unresolved = append(unresolved, host)
+			continue
+		}
+
+		for _, ip := range resolved {
+			ips = appendIP(ips, ip)
+		}
+	}
+
+	return ips, unresolved
+}
+
+/*
+CheckHosts resolves each host and compares the answers with the expected IPs.
+
+A host matches when it resolves to at least one expected IP and doesn't resolve
+to any unexpected IP. Addresses of an IP version which isn't present in the
+expected IPs (eg: AAAA records when only IPv4 addresses are expected) are ignored.
+*/
+func CheckHosts(ctx context.Context, resolver *net.Resolver, hosts []string, expected []string) []HostCheck {
+	checks := make([]HostCheck, len(hosts))
+
+	expectV4 := slices.ContainsFunc(expected, isIPv4)
+	expectV6 := slices.ContainsFunc(expected, func(ip string) bool { return !isIPv4(ip) })
+
+	for i, host := range hosts {
+		check := HostCheck{Host: host, Addresses: []string{}}
+
+		resolved, err := resolver.LookupIP(ctx, "ip", host)
+		if err != nil || len(resolved) == 0 {
+			check.Status = CheckUnresolved
+			check.Err = err
+			checks[i] = check
+			continue
+		}
+
+		matched := false
+		unexpected := false
+
+		for _, ip := range resolved {
+			check.Addresses = appendIP(check.Addresses, ip)
+
+			if (ip.To4() != nil && !expectV4) || (ip.To4() == nil && !expectV6) {
+				continue
+			}
+
+			if containsIP(expected, ip) {
+				matched = true
+			} else {
+				unexpected = true
+			}
+		}
+
+		if matched && !unexpected {
+			check.Status = CheckOk
+		} else {
+			check.Status = CheckMismatch
+		}
+
+		checks[i] = check
+	}
+
+	return checks
+}
+
+func appendIP(ips []string, ip net.IP) []string {
+	if containsIP(ips, ip) {
+		return ips
+	}
+
+	return append(ips, ip.String())
+}
+
+func containsIP(ips []string, ip net.IP) bool {
+	return slices.ContainsFunc(ips, func(s string) bool {
+		return ip.Equal(net.ParseIP(s))
+	})
+}
+
+func isIPv4(ip string) bool {
+	parsed := net.ParseIP(ip)
+	return parsed != nil && parsed.To4() != nil
+}
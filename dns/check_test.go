@@ -0,0 +1,59 @@
+package dns
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestCheckHosts(t *testing.T) {
+	address := StartTestServer(t, map[string][]string{
+		"example.test":      {"1.2.3.4", "2001:db8::1"},
+		"www.example.test":  {"1.2.3.4"},
+		"old.example.test":  {"5.6.7.8"},
+		"both.example.test": {"1.2.3.4", "5.6.7.8"},
+	})
+
+	resolver := NewResolver(address)
+	hosts := []string{"example.test", "www.example.test", "old.example.test", "both.example.test", "missing.example.test"}
+
+	checks := CheckHosts(context.Background(), resolver, hosts, []string{"1.2.3.4"})
+
+	expected := map[string]CheckStatus{
+		"example.test":         CheckOk,
+		"www.example.test":     CheckOk,
+		"old.example.test":     CheckMismatch,
+		"both.example.test":    CheckMismatch,
+		"missing.example.test": CheckUnresolved,
+	}
+
+	for _, check := range checks {
+		if check.Status != expected[check.Host] {
+			t.Errorf("expected %s to be %s, got %s (%v)", check.Host, expected[check.Host], check.Status, check.Addresses)
+		}
+	}
+
+	checks = CheckHosts(context.Background(), resolver, []string{"example.test"}, []string{"1.2.3.4", "2001:db8::2"})
+
+	if checks[0].Status != CheckMismatch {
+		t.Errorf("expected unexpected IPv6 address to be a mismatch, got %s", checks[0].Status)
+	}
+}
+
+func TestResolveServers(t *testing.T) {
+	address := StartTestServer(t, map[string][]string{
+		"server.example.test": {"5.6.7.8"},
+	})
+
+	ips, unresolved := ResolveServers(context.Background(), NewResolver(address), []string{"1.2.3.4", "server.example.test", "your_server_hostname", "1.2.3.4"})
+
+	expected := []string{"1.2.3.4", "5.6.7.8"}
+
+	if !reflect.DeepEqual(ips, expected) {
+		t.Errorf("expected %v, got %v", expected, ips)
+	}
+
+	if !reflect.DeepEqual(unresolved, []string{"your_server_hostname"}) {
+		t.Errorf("expected your_server_hostname to be unresolved, got %v", unresolved)
+	}
+}
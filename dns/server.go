@@ -0,0 +1,111 @@
+package dns
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serverTTL is short so hosts follow VMs which are restarted with a new IP.
+const serverTTL = 5
+
+/*
+Server is a minimal UDP DNS server for development hosts. Records returns
+hosts and the IPv4 address they resolve to; it's called for every query so
+changes (eg: from another project's VM starting) apply immediately.
+Subdomains of a host resolve to the host's address, and names which don't
+match any host get NXDOMAIN.
+*/
+type Server struct {
+	Records func() (map[string]string, error)
+}
+
+// Serve answers queries received on conn until it's closed.
+func (s *Server) Serve(conn net.PacketConn) error {
+	buf := make([]byte, 512)
+
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		response, err := s.answer(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		_, _ = conn.WriteTo(response, addr)
+	}
+}
+
+func (s *Server) answer(query []byte) ([]byte, error) {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil {
+		return nil, err
+	}
+
+	resp := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:            msg.Header.ID,
+			Response:      true,
+			Authoritative: true,
+		},
+		Questions: msg.Questions,
+	}
+
+	if len(msg.Questions) != 1 {
+		resp.Header.RCode = dnsmessage.RCodeFormatError
+		return resp.Pack()
+	}
+
+	records, err := s.Records()
+	if err != nil {
+		resp.Header.RCode = dnsmessage.RCodeServerFailure
+		return resp.Pack()
+	}
+
+	question := msg.Questions[0]
+	ip, found := lookupHost(records, question.Name.String())
+
+	if !found {
+		resp.Header.RCode = dnsmessage.RCodeNameError
+		return resp.Pack()
+	}
+
+	// Other types (eg: AAAA) get an empty answer so clients fall back to the A record.
+	if ip4 := net.ParseIP(ip).To4(); ip4 != nil && question.Type == dnsmessage.TypeA {
+		resource := &dnsmessage.AResource{}
+		copy(resource.A[:], ip4)
+
+		resp.Answers = append(resp.Answers, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: serverTTL},
+			Body:   resource,
+		})
+	}
+
+	return resp.Pack()
+}
+
+// lookupHost returns the address of name, or of the longest host name is a subdomain of.
+func lookupHost(records map[string]string, name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	matched := ""
+
+	for host := range records {
+		if (name == host || strings.HasSuffix(name, "."+host)) && len(host) > len(matched) {
+			matched = host
+		}
+	}
+
+	if matched == "" {
+		return "", false
+	}
+
+	return records[matched], true
+}
@@ -0,0 +1,66 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	records := map[string]string{
+		"example.test":      "192.168.56.5",
+		"blog.example.test": "192.168.56.6",
+	}
+
+	server := &Server{Records: func() (map[string]string, error) { return records, nil }}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() { _ = server.Serve(conn) }()
+
+	resolver := NewResolver(conn.LocalAddr().String())
+
+	cases := map[string]string{
+		"example.test":          "192.168.56.5",
+		"EXAMPLE.test":          "192.168.56.5",
+		"site1.example.test":    "192.168.56.5",
+		"blog.example.test":     "192.168.56.6",
+		"www.blog.example.test": "192.168.56.6",
+	}
+
+	for host, expected := range cases {
+		ips, err := resolver.LookupIP(context.Background(), "ip", host)
+		if err != nil {
+			t.Errorf("%s: %v", host, err)
+			continue
+		}
+
+		if len(ips) != 1 || ips[0].String() != expected {
+			t.Errorf("expected %s to resolve to %s, got %v", host, expected, ips)
+		}
+	}
+
+	_, err = resolver.LookupIP(context.Background(), "ip", "other.test")
+
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+		t.Errorf("expected other.test to not be found, got %v", err)
+	}
+}
+
+func TestLookupHost(t *testing.T) {
+	records := map[string]string{"example.test": "192.168.56.5"}
+
+	if _, found := lookupHost(records, "notexample.test."); found {
+		t.Error("expected notexample.test not to match example.test")
+	}
+
+	if ip, found := lookupHost(records, "a.b.example.test."); !found || ip != "192.168.56.5" {
+		t.Errorf("expected subdomain to match, got %q %v", ip, found)
+	}
+}
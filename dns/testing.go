@@ -0,0 +1,83 @@
+package dns
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+/*
+StartTestServer starts a local UDP DNS server for tests which answers A and
+AAAA queries from records (host => IPs). Unknown hosts return NXDOMAIN.
+It returns the server's address to use with NewResolver.
+*/
+func StartTestServer(t *testing.T, records map[string][]string) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			var msg dnsmessage.Message
+			if err := msg.Unpack(buf[:n]); err != nil || len(msg.Questions) != 1 {
+				continue
+			}
+
+			question := msg.Questions[0]
+			name := strings.TrimSuffix(question.Name.String(), ".")
+			ips, found := records[name]
+
+			resp := dnsmessage.Message{
+				Header: dnsmessage.Header{
+					ID:            msg.Header.ID,
+					Response:      true,
+					Authoritative: true,
+				},
+				Questions: msg.Questions,
+			}
+
+			if !found {
+				resp.Header.RCode = dnsmessage.RCodeNameError
+			}
+
+			for _, s := range ips {
+				ip := net.ParseIP(s)
+				header := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 60}
+
+				if ip4 := ip.To4(); ip4 != nil && question.Type == dnsmessage.TypeA {
+					header.Type = dnsmessage.TypeA
+					resource := &dnsmessage.AResource{}
+					copy(resource.A[:], ip4)
+					resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: resource})
+				} else if ip.To4() == nil && question.Type == dnsmessage.TypeAAAA {
+					header.Type = dnsmessage.TypeAAAA
+					resource := &dnsmessage.AAAAResource{}
+					copy(resource.AAAA[:], ip.To16())
+					resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: resource})
+				}
+			}
+
+			packed, err := resp.Pack()
+			if err != nil {
+				continue
+			}
+
+			_, _ = conn.WriteTo(packed, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
@@ -77,6 +77,15 @@ func main() {
 		"deploy": func() (cli.Command, error) {
 			return cmd.NewDeployCommand(ui, trellis), nil
 		},
+		"dns": func() (cli.Command, error) {
+			return &cmd.NamespaceCommand{
+				HelpText:     "Usage: trellis dns <subcommand> [<args>]",
+				SynopsisText: "Commands for DNS",
+			}, nil
+		},
+		"dns check": func() (cli.Command, error) {
+			return cmd.NewDnsCheckCommand(ui, trellis), nil
+		},
 		"dotenv": func() (cli.Command, error) {
 			return cmd.NewDotEnvCommand(ui, trellis), nil
 		},
@@ -104,6 +113,30 @@ func main() {
 		"server dns": func() (cli.Command, error) {
 			return cmd.NewServerDnsCommand(ui, trellis), nil
 		},
+		"server images": func() (cli.Command, error) {
+			return cmd.NewServerImagesCommand(ui, trellis), nil
+		},
+		"server known-hosts": func() (cli.Command, error) {
+			return cmd.NewServerKnownHostsCommand(ui, trellis), nil
+		},
+		"server login": func() (cli.Command, error) {
+			return cmd.NewServerLoginCommand(ui, trellis), nil
+		},
+		"server logout": func() (cli.Command, error) {
+			return cmd.NewServerLogoutCommand(ui, trellis), nil
+		},
+		"server profiles": func() (cli.Command, error) {
+			return cmd.NewServerProfilesCommand(ui, trellis), nil
+		},
+		"server regions": func() (cli.Command, error) {
+			return cmd.NewServerRegionsCommand(ui, trellis), nil
+		},
+		"server register": func() (cli.Command, error) {
+			return cmd.NewServerRegisterCommand(ui, trellis), nil
+		},
+		"server sizes": func() (cli.Command, error) {
+			return cmd.NewServerSizesCommand(ui, trellis), nil
+		},
 		"exec": func() (cli.Command, error) {
 			return &cmd.ExecCommand{UI: ui, Trellis: trellis}, nil
 		},
@@ -122,6 +155,9 @@ func main() {
 		"init": func() (cli.Command, error) {
 			return cmd.NewInitCommand(ui, trellis), nil
 		},
+		"inventory": func() (cli.Command, error) {
+			return cmd.NewInventoryCommand(ui, trellis), nil
+		},
 		"key": func() (cli.Command, error) {
 			return &cmd.NamespaceCommand{
 				HelpText:     "Usage: trellis key <subcommand> [<args>]",
@@ -152,6 +188,9 @@ func main() {
 		"ssh": func() (cli.Command, error) {
 			return cmd.NewSshCommand(ui, trellis), nil
 		},
+		"up": func() (cli.Command, error) {
+			return cmd.NewUpCommand(ui, trellis), nil
+		},
 		"vault": func() (cli.Command, error) {
 			return &cmd.NamespaceCommand{
 				HelpText:     "Usage: trellis vault <subcommand> [<args>]",
@@ -188,21 +227,87 @@ func main() {
 				SynopsisText: "Commands for managing development virtual machines",
 			}, nil
 		},
+		"vm config": func() (cli.Command, error) {
+			return cmd.NewVmConfigCommand(ui, trellis), nil
+		},
 		"vm delete": func() (cli.Command, error) {
 			return cmd.NewVmDeleteCommand(ui, trellis), nil
 		},
+		"vm dns-server": func() (cli.Command, error) {
+			return &cmd.VmDnsServerCommand{UI: ui, Trellis: trellis}, nil
+		},
+		"vm doctor": func() (cli.Command, error) {
+			return cmd.NewVmDoctorCommand(ui, trellis), nil
+		},
+		"vm hosts": func() (cli.Command, error) {
+			return &cmd.NamespaceCommand{
+				HelpText:     "Usage: trellis vm hosts <subcommand> [<args>]",
+				SynopsisText: "Commands for the development VM's /etc/hosts entries",
+			}, nil
+		},
+		"vm hosts prune": func() (cli.Command, error) {
+			return cmd.NewVmHostsPruneCommand(ui, trellis), nil
+		},
+		"vm hosts remove": func() (cli.Command, error) {
+			return cmd.NewVmHostsRemoveCommand(ui, trellis), nil
+		},
+		"vm hosts show": func() (cli.Command, error) {
+			return cmd.NewVmHostsShowCommand(ui, trellis), nil
+		},
+		"vm hosts sync": func() (cli.Command, error) {
+			return cmd.NewVmHostsSyncCommand(ui, trellis), nil
+		},
+		"vm list": func() (cli.Command, error) {
+			return cmd.NewVmListCommand(ui, trellis), nil
+		},
 		"vm shell": func() (cli.Command, error) {
 			return cmd.NewVmShellCommand(ui, trellis), nil
 		},
+		"vm snapshot": func() (cli.Command, error) {
+			return &cmd.NamespaceCommand{
+				HelpText:     "Usage: trellis vm snapshot <subcommand> [<args>]",
+				SynopsisText: "Commands for development VM snapshots",
+			}, nil
+		},
+		"vm snapshot create": func() (cli.Command, error) {
+			return cmd.NewVmSnapshotCreateCommand(ui, trellis), nil
+		},
+		"vm snapshot delete": func() (cli.Command, error) {
+			return cmd.NewVmSnapshotDeleteCommand(ui, trellis), nil
+		},
+		"vm snapshot list": func() (cli.Command, error) {
+			return cmd.NewVmSnapshotListCommand(ui, trellis), nil
+		},
+		"vm snapshot restore": func() (cli.Command, error) {
+			return cmd.NewVmSnapshotRestoreCommand(ui, trellis), nil
+		},
 		"vm start": func() (cli.Command, error) {
 			return cmd.NewVmStartCommand(ui, trellis), nil
 		},
+		"vm status": func() (cli.Command, error) {
+			return cmd.NewVmStatusCommand(ui, trellis), nil
+		},
 		"vm stop": func() (cli.Command, error) {
 			return cmd.NewVmStopCommand(ui, trellis), nil
 		},
 		"vm sudoers": func() (cli.Command, error) {
 			return &cmd.VmSudoersCommand{UI: ui, Trellis: trellis}, nil
 		},
+		"vm sync": func() (cli.Command, error) {
+			return &cmd.NamespaceCommand{
+				HelpText:     "Usage: trellis vm sync <subcommand> [<args>]",
+				SynopsisText: "Commands for syncing site files with the development VM",
+			}, nil
+		},
+		"vm sync daemon": func() (cli.Command, error) {
+			return &cmd.VmSyncDaemonCommand{UI: ui, Trellis: trellis}, nil
+		},
+		"vm sync flush": func() (cli.Command, error) {
+			return cmd.NewVmSyncFlushCommand(ui, trellis), nil
+		},
+		"vm sync status": func() (cli.Command, error) {
+			return cmd.NewVmSyncStatusCommand(ui, trellis), nil
+		},
 		"vm trust": func() (cli.Command, error) {
 			return cmd.NewVmTrustCommand(ui, trellis), nil
 		},
@@ -226,7 +331,7 @@ func main() {
 		},
 	}
 
-	c.HiddenCommands = []string{"venv", "venv hook"}
+	c.HiddenCommands = []string{"venv", "venv hook", "vm dns-server", "vm sync daemon"}
 	c.HelpFunc = deprecatedCommandHelpFunc(deprecatedCommands, cli.BasicHelpFunc("trellis"))
 
 	if trellis.CliConfig.LoadPlugins {
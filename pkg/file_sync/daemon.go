@@ -0,0 +1,159 @@
+package file_sync
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultInterval is how often the daemon syncs when it isn't flushed.
+const DefaultInterval = 2 * time.Second
+
+// watchDelay is how long the daemon waits after a local change so a burst of
+// changes (eg: a git checkout) is synced at once.
+const watchDelay = 200 * time.Millisecond
+
+var ErrDaemonNotRunning = errors.New("the sync daemon is not running")
+
+/*
+Daemon runs its Syncers in a loop (every Interval, immediately when flushed, or
+shortly after a local change) and records the result in StatusPath after each sync.
+
+Local changes are detected with OS file events, so the local directories are
+only rescanned when something changed. The VM's side is scanned on every sync
+since its file events aren't available over SSH; excluded directories (eg:
+`vendor/`) aren't scanned so a scan is cheap even for large sites.
+*/
+type Daemon struct {
+	Syncers    []*Syncer
+	StatusPath string
+	Interval   time.Duration
+	// Running reports whether the VM is still running. It's checked after a
+	// failed sync and the daemon exits once the VM is stopped.
+	Running func() bool
+}
+
+// Run syncs until stop receives a value or the VM stops. Values received on flush trigger an immediate sync.
+func (d *Daemon) Run(flush <-chan os.Signal, stop <-chan os.Signal) error {
+	interval := d.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+
+	status := &Status{Pid: os.Getpid(), StartedAt: time.Now()}
+
+	changed := make(chan struct{}, 1)
+	for _, syncer := range d.Syncers {
+		// without a watcher the local directory is scanned on every sync instead
+		if err := syncer.Watch(changed); err != nil {
+			log.Printf("%s: could not watch for changes: %v\n", syncer.LocalDir, err)
+		}
+		defer func() { _ = syncer.Close() }()
+	}
+
+	for {
+		d.sync(status)
+
+		if err := WriteStatus(d.StatusPath, status); err != nil {
+			return err
+		}
+
+		if status.LastError != "" && d.Running != nil && !d.Running() {
+			log.Println("VM is not running. Exiting.")
+			return d.exit(status)
+		}
+
+		select {
+		case <-stop:
+			return d.exit(status)
+		case <-flush:
+		case <-changed:
+			time.Sleep(watchDelay)
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (d *Daemon) sync(status *Status) {
+	started := time.Now()
+	errs := []string{}
+
+	for _, syncer := range d.Syncers {
+		changes, err := syncer.Sync()
+
+		status.Pushed += len(changes.Pushed)
+		status.Pulled += len(changes.Pulled)
+		status.Deleted += len(changes.Deleted)
+
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", syncer.LocalDir, err))
+			continue
+		}
+
+		if !changes.Empty() {
+			log.Printf("%s: pushed %d, pulled %d, deleted %d\n", syncer.LocalDir, len(changes.Pushed), len(changes.Pulled), len(changes.Deleted))
+		}
+	}
+
+	status.LastSync = started
+	status.LastError = strings.Join(errs, "\n")
+
+	if status.LastError != "" {
+		log.Println(status.LastError)
+	}
+}
+
+func (d *Daemon) exit(status *Status) error {
+	status.Pid = 0
+	return WriteStatus(d.StatusPath, status)
+}
+
+// Running reports whether the daemon which wrote the status is still running.
+func (s *Status) Running() bool {
+	if s.Pid <= 0 {
+		return false
+	}
+
+	process, err := os.FindProcess(s.Pid)
+	if err != nil {
+		return false
+	}
+
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// Flush makes the running daemon sync immediately and waits until it's done.
+func Flush(statusPath string, timeout time.Duration) (*Status, error) {
+	status, err := ReadStatus(statusPath)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && !status.Running()) {
+		return nil, ErrDaemonNotRunning
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	requested := time.Now()
+
+	process, err := os.FindProcess(status.Pid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := process.Signal(syscall.SIGUSR1); err != nil {
+		return nil, fmt.Errorf("Could not signal the sync daemon: %v", err)
+	}
+
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); {
+		time.Sleep(100 * time.Millisecond)
+
+		if status, err = ReadStatus(statusPath); err == nil && status.LastSync.After(requested) {
+			return status, nil
+		}
+	}
+
+	return nil, fmt.Errorf("timed out waiting for the sync daemon to sync")
+}
@@ -0,0 +1,555 @@
+package file_sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roots/trellis-cli/command"
+)
+
+// DefaultExcludes are kept separately on each side: they're either large and
+// built by tools inside the VM (eg: `composer install`) or user content.
+var DefaultExcludes = []string{"vendor/", "node_modules/", "web/app/uploads/"}
+
+// massDeletionMinimum is the number of paths which must disappear from the VM
+// at once (along with most of its files) before a sync refuses to delete them on the host.
+const massDeletionMinimum = 50
+
+var ErrMassDeletion = errors.New("refusing to delete files on the host")
+
+// Entry is the state of a file or directory used to detect changes.
+type Entry struct {
+	ModTime string
+	Size    int64
+	Dir     bool
+}
+
+// Snapshot maps slash-separated paths relative to the synced directory to their state.
+type Snapshot map[string]Entry
+
+// Changes are the paths a sync transferred or deleted.
+type Changes struct {
+	Pushed  []string
+	Pulled  []string
+	Deleted []string
+}
+
+func (c Changes) Empty() bool {
+	return len(c.Pushed) == 0 && len(c.Pulled) == 0 && len(c.Deleted) == 0
+}
+
+/*
+Syncer keeps a local directory and a directory inside a VM in sync in both
+directions using rsync over SSH.
+
+Each Sync compares both sides with their state at the previous sync: changed
+paths are copied to the other side (if both sides changed, the newest file
+wins) and deleted paths are deleted from the other side. Paths matching
+Excludes are never synced.
+
+If the VM's directory is suddenly empty or most of its files disappeared (eg:
+the VM's disk was reset), nothing is deleted on the host; the host's files are
+copied back to the VM instead.
+
+The local side is only rescanned when the watcher (see Watch) reported a
+change, or on every sync if it isn't watched.
+*/
+type Syncer struct {
+	LocalDir  string
+	RemoteDir string
+	// SshArgs are the arguments of the ssh command connecting to Host (eg: ["-F", "ssh.config"]).
+	SshArgs  []string
+	Host     string
+	Excludes []string
+	local    Snapshot
+	remote   Snapshot
+	watcher  *watcher
+}
+
+// Initial merges both sides (copying newer files in each direction) and records their state.
+func (s *Syncer) Initial() error {
+	if err := s.remoteCommand(fmt.Sprintf(`sudo mkdir -p %s && sudo chown "$(id -u):$(id -g)" %s`, command.ShellQuote(s.RemoteDir), command.ShellQuote(s.RemoteDir))); err != nil {
+		return fmt.Errorf("Could not create %s in the VM: %v", s.RemoteDir, err)
+	}
+
+	if err := s.rsync(s.localPath(), s.remotePath(), nil); err != nil {
+		return err
+	}
+
+	if err := s.rsync(s.remotePath(), s.localPath(), nil); err != nil {
+		return err
+	}
+
+	return s.scan()
+}
+
+// Sync applies the changes made on each side since the previous sync to the other side.
+func (s *Syncer) Sync() (Changes, error) {
+	changes := Changes{}
+
+	if s.local == nil || s.remote == nil {
+		return changes, s.Initial()
+	}
+
+	local := s.local
+	if s.watcher == nil || s.watcher.changed() {
+		var err error
+		if local, err = ScanLocal(s.LocalDir, s.Excludes); err != nil {
+			return changes, err
+		}
+	}
+
+	remote, err := s.scanRemote()
+	if err != nil {
+		return changes, err
+	}
+
+	if missing := missingPaths(s.remote, remote); len(missing) > 0 && massDeletion(s.remote, remote, missing) {
+		if err := s.Initial(); err != nil {
+			return changes, err
+		}
+
+		return changes, fmt.Errorf("%w: %d of %d paths disappeared from %s in the VM at once, so they were copied back from the host instead. Delete them on the host if that was intended", ErrMassDeletion, len(missing), len(s.remote), s.RemoteDir)
+	}
+
+	localChanged, localDeleted := Diff(s.local, local)
+	remoteChanged, remoteDeleted := Diff(s.remote, remote)
+
+	// Deletions run first so the transfers below set the times of their parent directories
+
+	// A path deleted on one side but changed on the other is kept
+	remoteDeletions := withoutPaths(localDeleted, remoteChanged)
+	if len(remoteDeletions) > 0 {
+		quoted := []string{}
+		for _, path := range remoteDeletions {
+			quoted = append(quoted, command.ShellQuote(s.RemoteDir+"/"+path))
+		}
+
+		if err := s.remoteCommand("rm -rf -- " + strings.Join(quoted, " ")); err != nil {
+			return changes, fmt.Errorf("Could not delete files in the VM: %v", err)
+		}
+	}
+
+	localDeletions := withoutPaths(remoteDeleted, localChanged)
+	for _, path := range localDeletions {
+		if err := os.RemoveAll(filepath.Join(s.LocalDir, filepath.FromSlash(path))); err != nil {
+			return changes, err
+		}
+	}
+
+	changes.Deleted = append(remoteDeletions, localDeletions...)
+
+	if len(localChanged) > 0 {
+		if err := s.rsync(s.localPath(), s.remotePath(), localChanged); err != nil {
+			return changes, err
+		}
+		changes.Pushed = localChanged
+	}
+
+	if len(remoteChanged) > 0 {
+		if err := s.rsync(s.remotePath(), s.localPath(), remoteChanged); err != nil {
+			return changes, err
+		}
+		changes.Pulled = remoteChanged
+	}
+
+	s.local, s.remote = nextSnapshots(local, remote, changes, remoteDeletions, localDeletions)
+
+	return changes, nil
+}
+
+/*
+nextSnapshots returns the state of both sides after a sync from the snapshots
+taken before it. Instead of rescanning, transferred paths take the state they
+were copied with (rsync keeps modification times) so any edit made while the
+sync was running (eg: an editor saving a file being pushed) is still a change
+next time.
+
+If a path was changed on both sides, rsync --update keeps the newest copy on
+both, or the host's if they have the same modification time.
+*/
+func nextSnapshots(local Snapshot, remote Snapshot, changes Changes, remoteDeletions []string, localDeletions []string) (Snapshot, Snapshot) {
+	nextLocal := maps.Clone(local)
+	nextRemote := maps.Clone(remote)
+
+	deletePaths(nextRemote, remoteDeletions)
+	deletePaths(nextLocal, localDeletions)
+
+	for _, path := range changes.Pushed {
+		nextRemote[path] = local[path]
+	}
+
+	for _, path := range changes.Pulled {
+		entry := remote[path]
+
+		if slices.Contains(changes.Pushed, path) && !newer(remote[path], local[path]) {
+			entry = local[path]
+		}
+
+		nextLocal[path] = entry
+		nextRemote[path] = entry
+	}
+
+	return nextLocal, nextRemote
+}
+
+// newer reports whether a has a later modification time than b.
+func newer(a Entry, b Entry) bool {
+	aTime, _ := strconv.ParseInt(a.ModTime, 10, 64)
+	bTime, _ := strconv.ParseInt(b.ModTime, 10, 64)
+
+	return aTime > bTime
+}
+
+// deletePaths removes paths and the contents of deleted directories from snapshot.
+func deletePaths(snapshot Snapshot, paths []string) {
+	for _, path := range paths {
+		for existing := range snapshot {
+			if existing == path || strings.HasPrefix(existing, path+"/") {
+				delete(snapshot, existing)
+			}
+		}
+	}
+}
+
+func (s *Syncer) scan() (err error) {
+	if s.local, err = ScanLocal(s.LocalDir, s.Excludes); err != nil {
+		return err
+	}
+
+	s.remote, err = s.scanRemote()
+	return err
+}
+
+// ScanLocal returns the state of every path in dir which isn't excluded.
+func ScanLocal(dir string, excludes []string) (Snapshot, error) {
+	snapshot := Snapshot{}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if path == dir {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if Excluded(rel, d.IsDir(), excludes) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		entry := Entry{ModTime: strconv.FormatInt(info.ModTime().Unix(), 10), Dir: d.IsDir()}
+		if !d.IsDir() {
+			entry.Size = info.Size()
+		}
+		snapshot[rel] = entry
+
+		return nil
+	})
+
+	return snapshot, err
+}
+
+func (s *Syncer) scanRemote() (Snapshot, error) {
+	output, err := command.Cmd("ssh", s.sshCommandArgs(findScript(s.RemoteDir, s.Excludes))).Output()
+	if err != nil {
+		return nil, fmt.Errorf("Could not list files in the VM: %v", err)
+	}
+
+	return ParseRemoteSnapshot(output, s.Excludes), nil
+}
+
+// findScript lists the tab separated path, mtime, size and type of each path in
+// dir. Excluded directories are pruned so their contents aren't listed.
+func findScript(dir string, excludes []string) string {
+	prune := []string{}
+
+	for _, pattern := range excludes {
+		dirOnly := strings.HasSuffix(pattern, "/")
+		pattern = strings.Trim(pattern, "/")
+		if pattern == "" {
+			continue
+		}
+
+		test := "-name " + command.ShellQuote(pattern)
+		if strings.Contains(pattern, "/") {
+			test = "-path " + command.ShellQuote("./"+pattern)
+		}
+		if dirOnly {
+			test += " -type d"
+		}
+
+		prune = append(prune, `\( `+test+` \)`)
+	}
+
+	find := "find . -mindepth 1"
+	if len(prune) > 0 {
+		find += ` \( ` + strings.Join(prune, " -o ") + ` \) -prune -o`
+	}
+
+	return fmt.Sprintf(`cd %s && %s -printf '%%P\t%%T@\t%%s\t%%y\n'`, command.ShellQuote(dir), find)
+}
+
+// ParseRemoteSnapshot parses the output of `find -printf '%P\t%T@\t%s\t%y\n'`.
+func ParseRemoteSnapshot(output []byte, excludes []string) Snapshot {
+	snapshot := Snapshot{}
+
+	for line := range bytes.SplitSeq(output, []byte("\n")) {
+		fields := strings.Split(string(line), "\t")
+		if len(fields) != 4 || fields[0] == "" {
+			continue
+		}
+
+		path, dir := fields[0], fields[3] == "d"
+		if excludedPath(path, dir, excludes) {
+			continue
+		}
+
+		modTime, _, _ := strings.Cut(fields[1], ".")
+		entry := Entry{ModTime: modTime, Dir: dir}
+		if !dir {
+			entry.Size, _ = strconv.ParseInt(fields[2], 10, 64)
+		}
+		snapshot[path] = entry
+	}
+
+	return snapshot
+}
+
+// Diff returns the paths which are new or changed and the paths which were deleted since previous.
+func Diff(previous Snapshot, current Snapshot) (changed []string, deleted []string) {
+	changed = []string{}
+	deleted = []string{}
+
+	for path, entry := range current {
+		if old, ok := previous[path]; !ok || old != entry {
+			changed = append(changed, path)
+		}
+	}
+
+	for path := range previous {
+		if _, ok := current[path]; !ok && !parentDeleted(path, current, previous) {
+			deleted = append(deleted, path)
+		}
+	}
+
+	sort.Strings(changed)
+	sort.Strings(deleted)
+
+	return changed, deleted
+}
+
+/*
+Excluded reports whether a path matches an exclude pattern. Like rsync, a
+pattern ending with `/` only matches directories, and a pattern without any
+other `/` matches the name of a path at any depth. Otherwise it matches the
+path from the synced directory (eg: `web/app/uploads/`).
+*/
+func Excluded(path string, dir bool, excludes []string) bool {
+	for _, pattern := range excludes {
+		dirOnly := strings.HasSuffix(pattern, "/")
+		pattern = strings.Trim(pattern, "/")
+
+		if pattern == "" || (dirOnly && !dir) {
+			continue
+		}
+
+		target := path
+		if !strings.Contains(pattern, "/") {
+			target = filepath.Base(path)
+		}
+
+		if matched, _ := filepath.Match(pattern, target); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// excludedPath reports whether a path or any of its parent directories is excluded.
+func excludedPath(path string, dir bool, excludes []string) bool {
+	if Excluded(path, dir, excludes) {
+		return true
+	}
+
+	for parent := filepath.Dir(path); parent != "." && parent != "/"; parent = filepath.Dir(parent) {
+		if Excluded(parent, true, excludes) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parentDeleted reports whether one of path's parent directories was deleted
+// too, in which case deleting the parent is enough.
+func parentDeleted(path string, current Snapshot, previous Snapshot) bool {
+	for parent := filepath.Dir(path); parent != "." && parent != "/"; parent = filepath.Dir(parent) {
+		if _, existed := previous[parent]; existed {
+			if _, exists := current[parent]; !exists {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// rsync copies src to dst, skipping files which are newer in dst. Only paths
+// are copied if given; otherwise the whole directory is.
+func (s *Syncer) rsync(src string, dst string, paths []string) error {
+	sshCommand := []string{"ssh"}
+	for _, arg := range s.SshArgs {
+		if strings.ContainsAny(arg, " '\"") {
+			arg = command.ShellQuote(arg)
+		}
+		sshCommand = append(sshCommand, arg)
+	}
+
+	args := []string{"-a", "--update", "-e", strings.Join(sshCommand, " ")}
+
+	for _, exclude := range s.Excludes {
+		args = append(args, "--exclude", exclude)
+	}
+
+	if paths != nil {
+		args = append(args, "--files-from=-")
+	}
+
+	args = append(args, src, dst)
+
+	cmd := command.Cmd("rsync", args)
+	if paths != nil {
+		cmd.Stdin = strings.NewReader(strings.Join(paths, "\n") + "\n")
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("rsync failed: %v\n%s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+func (s *Syncer) remoteCommand(script string) error {
+	output, err := command.Cmd("ssh", s.sshCommandArgs(script)).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+func (s *Syncer) sshCommandArgs(script string) []string {
+	return append(slices.Clone(s.SshArgs), s.Host, script)
+}
+
+func (s *Syncer) localPath() string {
+	return strings.TrimSuffix(s.LocalDir, "/") + "/"
+}
+
+func (s *Syncer) remotePath() string {
+	return s.Host + ":" + strings.TrimSuffix(s.RemoteDir, "/") + "/"
+}
+
+// Status is written by the sync daemon after each sync. LastSync is when the
+// last sync started, so it includes every change made before then. The counts
+// are totals since the daemon started.
+type Status struct {
+	Pid       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+	LastSync  time.Time `json:"last_sync"`
+	LastError string    `json:"last_error,omitempty"`
+	Pushed    int       `json:"pushed"`
+	Pulled    int       `json:"pulled"`
+	Deleted   int       `json:"deleted"`
+}
+
+func ReadStatus(path string) (*Status, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{}
+	if err := json.Unmarshal(data, status); err != nil {
+		return nil, fmt.Errorf("Could not parse %s: %v", path, err)
+	}
+
+	return status, nil
+}
+
+func WriteStatus(path string, status *Status) error {
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// missingPaths returns every path (including the contents of deleted directories) in previous which isn't in current.
+func missingPaths(previous Snapshot, current Snapshot) []string {
+	missing := []string{}
+	for path := range previous {
+		if _, ok := current[path]; !ok {
+			missing = append(missing, path)
+		}
+	}
+
+	return missing
+}
+
+// massDeletion reports whether so many paths disappeared that it's more likely
+// the side was reset or unavailable than that they were deleted on purpose.
+func massDeletion(previous Snapshot, current Snapshot, missing []string) bool {
+	if len(current) == 0 {
+		return true
+	}
+
+	return len(missing) >= massDeletionMinimum && len(missing)*2 > len(previous)
+}
+
+func withoutPaths(paths []string, exclude []string) []string {
+	result := []string{}
+	for _, path := range paths {
+		if !slices.Contains(exclude, path) {
+			result = append(result, path)
+		}
+	}
+
+	return result
+}
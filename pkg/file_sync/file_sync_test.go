@@ -0,0 +1,401 @@
+package file_sync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/roots/trellis-cli/command"
+)
+
+func TestExcluded(t *testing.T) {
+	excludes := []string{"vendor/", "node_modules/", "web/app/uploads/", "*.log"}
+
+	cases := []struct {
+		path     string
+		dir      bool
+		expected bool
+	}{
+		{"vendor", true, true},
+		{"web/app/plugins/foo/vendor", true, true},
+		{"vendor", false, false},
+		{"node_modules", true, true},
+		{"web/app/uploads", true, true},
+		{"uploads", true, false},
+		{"debug.log", false, true},
+		{"web/app/debug.log", false, true},
+		{"web/app/themes/sage/index.php", false, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			if excluded := Excluded(tc.path, tc.dir, excludes); excluded != tc.expected {
+				t.Errorf("expected Excluded(%q, %v) to be %v", tc.path, tc.dir, tc.expected)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	previous := Snapshot{
+		"composer.json":       {ModTime: "1", Size: 10},
+		"web":                 {ModTime: "1", Dir: true},
+		"web/index.php":       {ModTime: "1", Size: 10},
+		"web/old":             {ModTime: "1", Dir: true},
+		"web/old/file.php":    {ModTime: "1", Size: 10},
+		"web/old/sub":         {ModTime: "1", Dir: true},
+		"web/old/sub/foo.php": {ModTime: "1", Size: 10},
+		"README.md":           {ModTime: "1", Size: 10},
+	}
+
+	current := Snapshot{
+		"composer.json": {ModTime: "2", Size: 10},
+		"web":           {ModTime: "2", Dir: true},
+		"web/index.php": {ModTime: "1", Size: 10},
+		"web/new.php":   {ModTime: "2", Size: 5},
+		"README.md":     {ModTime: "1", Size: 10},
+	}
+
+	changed, deleted := Diff(previous, current)
+
+	expectedChanged := []string{"composer.json", "web", "web/new.php"}
+	if !reflect.DeepEqual(changed, expectedChanged) {
+		t.Errorf("expected changed %v got %v", expectedChanged, changed)
+	}
+
+	// Only the deleted directory since deleting it deletes its contents
+	expectedDeleted := []string{"web/old"}
+	if !reflect.DeepEqual(deleted, expectedDeleted) {
+		t.Errorf("expected deleted %v got %v", expectedDeleted, deleted)
+	}
+}
+
+func TestParseRemoteSnapshot(t *testing.T) {
+	output := "composer.json\t1700000000.1234567890\t120\tf\n" +
+		"web\t1700000001.0000000000\t4096\td\n" +
+		"web/app/uploads/image.jpg\t1700000002.0000000000\t300\tf\n" +
+		"malformed line\n"
+
+	snapshot := ParseRemoteSnapshot([]byte(output), DefaultExcludes)
+
+	expected := Snapshot{
+		"composer.json": {ModTime: "1700000000", Size: 120},
+		"web":           {ModTime: "1700000001", Dir: true},
+	}
+
+	if !reflect.DeepEqual(snapshot, expected) {
+		t.Errorf("expected %v got %v", expected, snapshot)
+	}
+}
+
+func TestScanLocal(t *testing.T) {
+	dir := t.TempDir()
+
+	files := []string{"composer.json", "web/index.php", "vendor/autoload.php", "web/app/uploads/image.jpg"}
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshot, err := ScanLocal(dir, DefaultExcludes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths, _ := Diff(Snapshot{}, snapshot)
+	expected := []string{"composer.json", "web", "web/app", "web/index.php"}
+
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected %v got %v", expected, paths)
+	}
+
+	if entry := snapshot["composer.json"]; entry.Size != 7 || entry.Dir {
+		t.Errorf("unexpected entry for composer.json: %v", entry)
+	}
+}
+
+func TestFindScript(t *testing.T) {
+	script := findScript("/srv/www/example.com/current", []string{"vendor/", "web/app/uploads/", "*.log"})
+
+	expected := `cd '/srv/www/example.com/current' && find . -mindepth 1 \( \( -name 'vendor' -type d \) -o \( -path './web/app/uploads' -type d \) -o \( -name '*.log' \) \) -prune -o -printf '%P\t%T@\t%s\t%y\n'`
+
+	if script != expected {
+		t.Errorf("expected %s\ngot %s", expected, script)
+	}
+}
+
+func TestSync(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, file := range []string{"changed.php", "deleted-in-vm.php"} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	local, err := ScanLocal(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	syncer := &Syncer{
+		LocalDir:  dir,
+		RemoteDir: "/srv/www/example.com/current",
+		SshArgs:   []string{"-F", "/lima/ssh.config"},
+		Host:      "lima-example",
+		Excludes:  []string{"vendor/"},
+		local: Snapshot{
+			"changed.php":       {ModTime: "1", Size: 7},
+			"deleted-in-vm.php": local["deleted-in-vm.php"],
+		},
+		remote: Snapshot{
+			"changed.php":       {ModTime: "1", Size: 7},
+			"deleted-in-vm.php": {ModTime: "1", Size: 7},
+		},
+	}
+
+	defer command.MockExecCommands(t, []command.MockCommand{
+		{
+			Command: "ssh",
+			Args:    []string{"-F", "/lima/ssh.config", "lima-example", findScript(syncer.RemoteDir, syncer.Excludes)},
+			Output:  "changed.php\t1.0\t7\tf\n",
+		},
+		{
+			Command: "rsync",
+			Args:    []string{"-a", "--update", "-e", "ssh -F /lima/ssh.config", "--exclude", "vendor/", "--files-from=-", dir + "/", "lima-example:/srv/www/example.com/current/"},
+		},
+	})()
+
+	changes, err := syncer.Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Changes{Pushed: []string{"changed.php"}, Deleted: []string{"deleted-in-vm.php"}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %v got %v", expected, changes)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "deleted-in-vm.php")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected deleted-in-vm.php to be deleted locally")
+	}
+}
+
+func TestSyncKeepsEditsDuringTransfer(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.php")
+
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, time.Unix(100, 0), time.Unix(100, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	syncer := &Syncer{
+		LocalDir:  dir,
+		RemoteDir: "/srv/www/example.com/current",
+		SshArgs:   []string{"-F", "/lima/ssh.config"},
+		Host:      "lima-example",
+		local:     Snapshot{"index.php": {ModTime: "1", Size: 7}},
+		remote:    Snapshot{"index.php": {ModTime: "1", Size: 7}},
+	}
+
+	mockSync := func(remoteOutput string) func() {
+		return command.MockExecCommands(t, []command.MockCommand{
+			{
+				Command: "ssh",
+				Args:    []string{"-F", "/lima/ssh.config", "lima-example", findScript(syncer.RemoteDir, nil)},
+				Output:  remoteOutput,
+			},
+			{
+				Command: "rsync",
+				Args:    []string{"-a", "--update", "-e", "ssh -F /lima/ssh.config", "--files-from=-", dir + "/", "lima-example:/srv/www/example.com/current/"},
+			},
+		})
+	}
+
+	restore := mockSync("index.php\t1.0\t7\tf\n")
+	rsync := command.ExecCommand
+	command.ExecCommand = func(name string, args []string) *exec.Cmd {
+		// an editor saves the file while it's being pushed
+		if name == "rsync" {
+			if err := os.WriteFile(path, []byte("edited during the sync"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, time.Unix(200, 0), time.Unix(200, 0)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return rsync(name, args)
+	}
+
+	changes, err := syncer.Sync()
+	restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(changes.Pushed, []string{"index.php"}) {
+		t.Fatalf("expected index.php to be pushed, got %v", changes)
+	}
+
+	// rsync kept the pushed file's modification time in the VM
+	defer mockSync("index.php\t100.0\t7\tf\n")()
+
+	changes, err = syncer.Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(changes.Pushed, []string{"index.php"}) || len(changes.Pulled) > 0 {
+		t.Errorf("expected the edit made during the previous sync to be pushed, got %v", changes)
+	}
+}
+
+func TestSyncRefusesMassDeletion(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "index.php"), []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	local, err := ScanLocal(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	syncer := &Syncer{
+		LocalDir:  dir,
+		RemoteDir: "/srv/www/example.com/current",
+		SshArgs:   []string{"-F", "/lima/ssh.config"},
+		Host:      "lima-example",
+		local:     local,
+		remote:    Snapshot{"index.php": {ModTime: "1", Size: 7}},
+	}
+
+	defer command.MockExecCommands(t, []command.MockCommand{
+		{
+			Command: "ssh",
+			Args:    []string{"-F", "/lima/ssh.config", "lima-example", findScript(syncer.RemoteDir, nil)},
+		},
+		{
+			Command: "ssh",
+			Args:    []string{"-F", "/lima/ssh.config", "lima-example", `sudo mkdir -p '/srv/www/example.com/current' && sudo chown "$(id -u):$(id -g)" '/srv/www/example.com/current'`},
+		},
+		{
+			Command: "rsync",
+			Args:    []string{"-a", "--update", "-e", "ssh -F /lima/ssh.config", dir + "/", "lima-example:/srv/www/example.com/current/"},
+		},
+		{
+			Command: "rsync",
+			Args:    []string{"-a", "--update", "-e", "ssh -F /lima/ssh.config", "lima-example:/srv/www/example.com/current/", dir + "/"},
+		},
+	})()
+
+	if _, err := syncer.Sync(); !errors.Is(err, ErrMassDeletion) {
+		t.Errorf("expected ErrMassDeletion got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "index.php")); err != nil {
+		t.Errorf("expected index.php to be kept locally: %v", err)
+	}
+}
+
+func TestMassDeletion(t *testing.T) {
+	previous := Snapshot{}
+	for i := range 100 {
+		previous[fmt.Sprintf("file-%d.php", i)] = Entry{ModTime: "1", Size: 1}
+	}
+
+	cases := []struct {
+		name      string
+		remaining int
+		expected  bool
+	}{
+		{"empty", 0, true},
+		{"few_deleted", 90, false},
+		{"most_deleted", 40, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			current := Snapshot{}
+			for i := range tc.remaining {
+				current[fmt.Sprintf("file-%d.php", i)] = Entry{ModTime: "1", Size: 1}
+			}
+
+			if got := massDeletion(previous, current, missingPaths(previous, current)); got != tc.expected {
+				t.Errorf("expected %v got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "vendor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	notify := make(chan struct{}, 1)
+	syncer := &Syncer{LocalDir: dir, Excludes: []string{"vendor/"}}
+
+	if err := syncer.Watch(notify); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = syncer.Close() }()
+
+	if !syncer.watcher.changed() {
+		t.Error("expected the first sync to scan")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "vendor", "autoload.php"), []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "index.php"), []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-notify:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a change to be reported")
+	}
+
+	if !syncer.watcher.changed() {
+		t.Error("expected the watcher to record the change")
+	}
+}
+
+func TestFlushDaemonNotRunning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.json")
+
+	if _, err := Flush(path, 0); !errors.Is(err, ErrDaemonNotRunning) {
+		t.Errorf("expected ErrDaemonNotRunning got %v", err)
+	}
+
+	if err := WriteStatus(path, &Status{Pid: 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Flush(path, 0); !errors.Is(err, ErrDaemonNotRunning) {
+		t.Errorf("expected ErrDaemonNotRunning got %v", err)
+	}
+}
+
+func TestCommandHelperProcess(t *testing.T) {
+	command.CommandHelperProcess(t)
+}
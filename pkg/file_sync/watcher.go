@@ -0,0 +1,132 @@
+package file_sync
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watcher records whether anything changed in a local directory (and its
+// subdirectories which aren't excluded) since it was last checked.
+type watcher struct {
+	fsWatcher *fsnotify.Watcher
+	dir       string
+	excludes  []string
+	dirty     atomic.Bool
+	notify    chan<- struct{}
+}
+
+/*
+Watch watches the local directory with OS file events so a sync only rescans it
+when something changed. notify receives a value (without blocking) on each
+change so the caller can sync right away. The VM's side is still scanned on
+every sync since its file events aren't available over SSH.
+*/
+func (s *Syncer) Watch(notify chan<- struct{}) error {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	w := &watcher{fsWatcher: fsWatcher, dir: s.LocalDir, excludes: s.Excludes, notify: notify}
+	// the first sync always scans
+	w.dirty.Store(true)
+
+	if err := w.addTree(s.LocalDir); err != nil {
+		_ = fsWatcher.Close()
+		return err
+	}
+
+	go w.run()
+	s.watcher = w
+
+	return nil
+}
+
+// Close stops watching the local directory.
+func (s *Syncer) Close() error {
+	if s.watcher == nil {
+		return nil
+	}
+
+	err := s.watcher.fsWatcher.Close()
+	s.watcher = nil
+
+	return err
+}
+
+// changed reports whether anything changed since the previous call.
+func (w *watcher) changed() bool {
+	return w.dirty.Swap(false)
+}
+
+func (w *watcher) run() {
+	for {
+		select {
+		case event, ok := <-w.fsWatcher.Events:
+			if !ok {
+				return
+			}
+
+			rel, err := filepath.Rel(w.dir, event.Name)
+			if err != nil {
+				continue
+			}
+			rel = filepath.ToSlash(rel)
+
+			info, statErr := os.Lstat(event.Name)
+			isDir := statErr == nil && info.IsDir()
+
+			if excludedPath(rel, isDir, w.excludes) {
+				continue
+			}
+
+			// new directories (eg: from `mkdir -p` or a git checkout) need their own watches
+			if event.Has(fsnotify.Create) && isDir {
+				_ = w.addTree(event.Name)
+			}
+
+			w.markChanged()
+		case _, ok := <-w.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+
+			// events may have been dropped (eg: a queue overflow) so the next sync rescans
+			w.markChanged()
+		}
+	}
+}
+
+func (w *watcher) markChanged() {
+	w.dirty.Store(true)
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (w *watcher) addTree(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+
+		if path != w.dir {
+			rel, err := filepath.Rel(w.dir, path)
+			if err != nil {
+				return nil
+			}
+
+			if excludedPath(filepath.ToSlash(rel), true, w.excludes) {
+				return filepath.SkipDir
+			}
+		}
+
+		return w.fsWatcher.Add(path)
+	})
+}
@@ -0,0 +1,195 @@
+package lima
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/roots/trellis-cli/cli_config"
+)
+
+// portForwards returns the port forwards from the CLI config. The HTTP port
+// forward (`vm.forward_http_port`) keeps its existing host port if there is one.
+func (m *Manager) portForwards(existing []PortForward) ([]PortForward, error) {
+	forwards := []PortForward{}
+	configured := m.trellis.CliConfig.Vm.PortForwards
+
+	forwardsHttp := slices.ContainsFunc(configured, func(forward cli_config.VmPortForward) bool {
+		return forward.Guest == 80
+	})
+
+	if m.trellis.CliConfig.Vm.ForwardHttpPort && !forwardsHttp {
+		index := slices.IndexFunc(existing, func(forward PortForward) bool {
+			return forward.GuestPort == 80
+		})
+
+		if index >= 0 {
+			forwards = append(forwards, existing[index])
+		} else {
+			httpForwardPort, err := m.PortFinder.Resolve()
+			if err != nil {
+				return nil, fmt.Errorf("Could not find a local free port for HTTP forwarding: %v", err)
+			}
+
+			forwards = append(forwards, PortForward{GuestPort: 80, HostPort: httpForwardPort})
+		}
+	}
+
+	for _, forward := range configured {
+		forwards = append(forwards, PortForward{GuestPort: forward.Guest, HostPort: forward.Host})
+	}
+
+	return forwards, nil
+}
+
+/*
+ConfigChanges compares the VM settings in the CLI config (`vm.cpus`, `vm.memory`,
+`vm.disk` and `vm.port_forwards`) with an existing instance and describes the
+differences. Unset settings are ignored, and so are settings the override file
+sets since it's applied last. Disks can only grow, so a smaller `vm.disk`
+results in a warning instead of a change.
+*/
+func (m *Manager) ConfigChanges(name string) ([]string, error) {
+	instance, ok := m.GetInstance(name)
+	if !ok {
+		return nil, nil
+	}
+
+	// port forwards added by the override file aren't part of the CLI config
+	config, err := withoutOverride(instance.Config, instance.OverrideFile)
+	if err != nil {
+		return nil, err
+	}
+	instance.Config = config
+
+	override, err := readOverride(instance.OverrideFile)
+	if err != nil {
+		return nil, err
+	}
+
+	desired, err := m.desiredConfig(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []string{}
+
+	if override.Cpus == 0 && desired.Cpus != instance.Config.Cpus && desired.Cpus != instance.Cpus {
+		changes = append(changes, fmt.Sprintf("cpus: %d → %d", instance.Cpus, desired.Cpus))
+	}
+
+	if override.Memory == "" && desired.Memory != instance.Config.Memory {
+		memory, _ := cli_config.ParseByteSize(desired.Memory)
+		if memory != int64(instance.Memory) {
+			changes = append(changes, fmt.Sprintf("memory: %s → %s", formatByteSize(int64(instance.Memory)), desired.Memory))
+		}
+	}
+
+	if override.Disk == "" {
+		if desired.Disk != instance.Config.Disk {
+			changes = append(changes, fmt.Sprintf("disk: %s → %s", formatByteSize(int64(instance.Disk)), desired.Disk))
+		} else if disk, _ := cli_config.ParseByteSize(m.trellis.CliConfig.Vm.Disk); disk > 0 && disk < int64(instance.Disk) {
+			m.ui.Warn(fmt.Sprintf("Warning: vm.disk (%s) is smaller than the VM's disk (%s). Disks can't be shrunk; delete and recreate the VM to use a smaller disk.", m.trellis.CliConfig.Vm.Disk, formatByteSize(int64(instance.Disk))))
+		}
+	}
+
+	if !samePortForwards(desired.PortForwards, instance.Config.PortForwards) {
+		changes = append(changes, fmt.Sprintf("port forwards: %s → %s", formatPortForwards(instance.Config.PortForwards), formatPortForwards(desired.PortForwards)))
+	}
+
+	return changes, nil
+}
+
+// ApplyConfigChanges writes the CLI config's VM settings to the instance's
+// Lima config. A running instance is stopped first so the next start applies them.
+func (m *Manager) ApplyConfigChanges(name string) error {
+	instance, ok := m.GetInstance(name)
+	if !ok {
+		return nil
+	}
+
+	desired, err := m.desiredConfig(instance)
+	if err != nil {
+		return err
+	}
+
+	if instance.Running() {
+		if err := m.StopInstance(name); err != nil {
+			return err
+		}
+	}
+
+	instance.Config = desired
+	return instance.UpdateConfig()
+}
+
+// desiredConfig is the instance's config with the CLI config's VM settings applied.
+func (m *Manager) desiredConfig(instance Instance) (Config, error) {
+	vmConfig := m.trellis.CliConfig.Vm
+	config := instance.Config
+
+	if vmConfig.Cpus > 0 {
+		config.Cpus = vmConfig.Cpus
+	}
+
+	if vmConfig.Memory != "" {
+		config.Memory = vmConfig.Memory
+	}
+
+	if vmConfig.Disk != "" {
+		disk, err := cli_config.ParseByteSize(vmConfig.Disk)
+		if err != nil {
+			return Config{}, err
+		}
+
+		if disk > int64(instance.Disk) {
+			config.Disk = vmConfig.Disk
+		}
+	}
+
+	portForwards, err := m.portForwards(instance.Config.PortForwards)
+	if err != nil {
+		return Config{}, err
+	}
+	config.PortForwards = portForwards
+
+	return config, nil
+}
+
+func samePortForwards(a []PortForward, b []PortForward) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for _, forward := range a {
+		if !slices.Contains(b, forward) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func formatPortForwards(forwards []PortForward) string {
+	if len(forwards) == 0 {
+		return "none"
+	}
+
+	formatted := []string{}
+	for _, forward := range forwards {
+		formatted = append(formatted, fmt.Sprintf("%d→%d", forward.GuestPort, forward.HostPort))
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+func formatByteSize(bytes int64) string {
+	switch {
+	case bytes >= 1<<30 && bytes%(1<<30) == 0:
+		return fmt.Sprintf("%dGiB", bytes>>30)
+	case bytes >= 1<<20 && bytes%(1<<20) == 0:
+		return fmt.Sprintf("%dMiB", bytes>>20)
+	default:
+		return fmt.Sprintf("%dB", bytes)
+	}
+}
@@ -0,0 +1,261 @@
+package lima
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/cli_config"
+	"github.com/roots/trellis-cli/command"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestNewInstanceVmResources(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	trellis.CliConfig.Vm.Cpus = 6
+	trellis.CliConfig.Vm.Memory = "8GiB"
+	trellis.CliConfig.Vm.Disk = "150GiB"
+	trellis.CliConfig.Vm.PortForwards = []cli_config.VmPortForward{{Guest: 8025, Host: 8025}, {Guest: 443, Host: 8443}}
+
+	t.Setenv("TRELLIS_BYPASS_LIMA_REQUIREMENTS", "1")
+
+	manager, err := NewManager(trellis, cli.NewMockUi())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager.PortFinder = &MockPortFinder{}
+
+	instance, err := manager.newInstance("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if instance.Config.Cpus != 6 || instance.Config.Memory != "8GiB" || instance.Config.Disk != "150GiB" {
+		t.Errorf("expected resources to be set from the CLI config, got %+v", instance.Config)
+	}
+
+	expectedForwards := []PortForward{
+		{GuestPort: 80, HostPort: 60720},
+		{GuestPort: 8025, HostPort: 8025},
+		{GuestPort: 443, HostPort: 8443},
+	}
+
+	if !reflect.DeepEqual(instance.Config.PortForwards, expectedForwards) {
+		t.Errorf("expected port forwards %v, got %v", expectedForwards, instance.Config.PortForwards)
+	}
+
+	content, err := instance.GenerateConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"cpus: 6\nmemory: \"8GiB\"\ndisk: \"150GiB\"\nimages:",
+		"- guestPort: 8025\n  hostPort: 8025\n",
+		"- guestPort: 443\n  hostPort: 8443\n",
+	} {
+		if !strings.Contains(content.String(), expected) {
+			t.Errorf("expected config to contain %q\ngot %s", expected, content.String())
+		}
+	}
+}
+
+func TestNewInstanceConfiguredHttpPortForward(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	trellis.CliConfig.Vm.PortForwards = []cli_config.VmPortForward{{Guest: 80, Host: 8080}}
+
+	t.Setenv("TRELLIS_BYPASS_LIMA_REQUIREMENTS", "1")
+
+	manager, err := NewManager(trellis, cli.NewMockUi())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager.PortFinder = &MockPortFinder{}
+
+	instance, err := manager.newInstance("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedForwards := []PortForward{{GuestPort: 80, HostPort: 8080}}
+
+	if !reflect.DeepEqual(instance.Config.PortForwards, expectedForwards) {
+		t.Errorf("expected port forwards %v, got %v", expectedForwards, instance.Config.PortForwards)
+	}
+}
+
+func TestConfigChanges(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+
+	t.Setenv("TRELLIS_BYPASS_LIMA_REQUIREMENTS", "1")
+
+	existing := `{"name":"test","status":"%s","dir":"%s","vmType":"vz","cpus":4,"memory":4294967296,"disk":107374182400,"config":{"cpus":4,"memory":"4GiB","disk":"100GiB","images":[{"location":"http://ubuntu.com/focal","arch":"aarch64"}],"portForwards":[{"guestPort":80,"hostPort":60720}]}}`
+
+	cases := []struct {
+		name     string
+		vm       cli_config.VmConfig
+		override string
+		changes  []string
+		warnings string
+	}{
+		{
+			"unset",
+			cli_config.VmConfig{ForwardHttpPort: true},
+			"",
+			[]string{},
+			"",
+		},
+		{
+			"same_values",
+			cli_config.VmConfig{ForwardHttpPort: true, Cpus: 4, Memory: "4G", Disk: "100GiB"},
+			"",
+			[]string{},
+			"",
+		},
+		{
+			"changed",
+			cli_config.VmConfig{
+				ForwardHttpPort: true,
+				Cpus:            6,
+				Memory:          "8GiB",
+				Disk:            "150GiB",
+				PortForwards:    []cli_config.VmPortForward{{Guest: 8025, Host: 8025}},
+			},
+			"",
+			[]string{
+				"cpus: 4 → 6",
+				"memory: 4GiB → 8GiB",
+				"disk: 100GiB → 150GiB",
+				"port forwards: 80→60720 → 80→60720, 8025→8025",
+			},
+			"",
+		},
+		{
+			"smaller_disk",
+			cli_config.VmConfig{ForwardHttpPort: true, Disk: "50GiB"},
+			"",
+			[]string{},
+			"Warning: vm.disk (50GiB) is smaller than the VM's disk (100GiB).",
+		},
+		{
+			"pinned_by_override",
+			cli_config.VmConfig{ForwardHttpPort: true, Cpus: 6, Memory: "8GiB", Disk: "150GiB"},
+			"cpus: 4\nmemory: 4GiB\n",
+			[]string{"disk: 100GiB → 150GiB"},
+			"",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			trellis := trellis.NewTrellis()
+			if err := trellis.LoadProject(); err != nil {
+				t.Fatal(err)
+			}
+			tc.vm.HostsResolver = trellis.CliConfig.Vm.HostsResolver
+			trellis.CliConfig.Vm = tc.vm
+
+			ui := cli.NewMockUi()
+			manager, err := NewManager(trellis, ui)
+			if err != nil {
+				t.Fatal(err)
+			}
+			manager.PortFinder = &MockPortFinder{}
+
+			if tc.override != "" {
+				if err := os.MkdirAll(manager.ConfigPath, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(manager.OverridePath(), []byte(tc.override), 0644); err != nil {
+					t.Fatal(err)
+				}
+				defer os.Remove(manager.OverridePath())
+			}
+
+			defer command.MockExecCommands(t, []command.MockCommand{
+				{
+					Command: "limactl",
+					Args:    []string{"ls", "--format=json"},
+					Output:  fmt.Sprintf(existing, "Stopped", t.TempDir()),
+				},
+			})()
+
+			changes, err := manager.ConfigChanges("test")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(changes, tc.changes) {
+				t.Errorf("expected changes %q, got %q", tc.changes, changes)
+			}
+
+			if !strings.Contains(ui.ErrorWriter.String(), tc.warnings) {
+				t.Errorf("expected warnings to contain %q, got %q", tc.warnings, ui.ErrorWriter.String())
+			}
+		})
+	}
+}
+
+func TestApplyConfigChanges(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	trellis.CliConfig.Vm.Cpus = 6
+	trellis.CliConfig.Vm.Memory = "8GiB"
+	trellis.CliConfig.Vm.PortForwards = []cli_config.VmPortForward{{Guest: 8025, Host: 8025}}
+
+	t.Setenv("TRELLIS_BYPASS_LIMA_REQUIREMENTS", "1")
+
+	manager, err := NewManager(trellis, cli.NewMockUi())
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager.PortFinder = &MockPortFinder{}
+
+	dir := t.TempDir()
+	defer command.MockExecCommands(t, []command.MockCommand{
+		{
+			Command: "limactl",
+			Args:    []string{"ls", "--format=json"},
+			Output:  fmt.Sprintf(`{"name":"test","status":"Stopped","dir":"%s","vmType":"vz","cpus":4,"memory":4294967296,"disk":107374182400,"config":{"images":[{"location":"http://ubuntu.com/focal","arch":"aarch64"}],"portForwards":[{"guestPort":80,"hostPort":60720}]}}`, dir),
+		},
+	})()
+
+	if err := manager.ApplyConfigChanges("test"); err != nil {
+		t.Fatal(err)
+	}
+
+	instance := Instance{Dir: dir}
+	content, err := os.ReadFile(instance.ConfigFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"cpus: 6\nmemory: \"8GiB\"\nimages:",
+		"- guestPort: 80\n  hostPort: 60720\n",
+		"- guestPort: 8025\n  hostPort: 8025\n",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("expected config to contain %q\ngot %s", expected, content)
+		}
+	}
+}
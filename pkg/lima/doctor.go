@@ -0,0 +1,313 @@
+package lima
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/mcuadros/go-version"
+	"github.com/roots/trellis-cli/command"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+const kvmDevice = "/dev/kvm"
+
+// tapDeviceState is the state of a TAP network's device on the host.
+type tapDeviceState struct {
+	Exists bool
+	// Owner is the uid of the user allowed to attach to the device.
+	Owner     string
+	Addressed bool
+}
+
+/*
+Doctor diagnoses everything the Lima manager depends on for the instance. Unlike
+NewManager it doesn't stop at the first missing requirement, so it also works
+when Lima or KVM aren't usable.
+*/
+func Doctor(trellis *trellis.Trellis, ui cli.Ui, name string) []vm.Check {
+	checks := []vm.Check{}
+
+	switch runtime.GOOS {
+	case "darwin":
+		checks = append(checks, macOSCheck())
+	case "linux":
+		checks = append(checks, kvmCheck())
+	}
+
+	checks = append(checks, limaCheck())
+
+	if runtime.GOOS == "linux" {
+		checks = append(checks, qemuCheck())
+	}
+
+	manager, err := newManager(trellis, ui)
+	if err != nil {
+		return append(checks, vm.FailCheck("Lima manager", err.Error(), "Check the vm settings in trellis.cli.yml."))
+	}
+
+	return append(checks, manager.instanceChecks(name)...)
+}
+
+func (m *Manager) instanceChecks(name string) []vm.Check {
+	instance, exists := m.GetInstance(name)
+	running := exists && instance.Running()
+
+	checks := []vm.Check{}
+
+	if runtime.GOOS == "linux" {
+		checks = append(checks, m.tapDeviceCheck(instance, exists), m.qemuWrapperCheck(instance, exists))
+	}
+
+	return append(checks,
+		m.portForwardsCheck(instance, exists),
+		vm.HostsCheck(m.HostsResolver, name, running),
+		m.inventoryCheck(exists, running),
+	)
+}
+
+func macOSCheck() vm.Check {
+	macOSVersion, err := getMacOSVersion()
+	if err != nil {
+		return vm.FailCheck("macOS", "could not determine the macOS version", "Lima VMs require macOS 13.0+.")
+	}
+
+	if version.Compare(macOSVersion, RequiredMacOSVersion, "<") {
+		return vm.FailCheck("macOS", fmt.Sprintf("macOS %s is not supported", macOSVersion), "Upgrade to macOS 13.0+ to use Lima VMs.")
+	}
+
+	return vm.PassCheck("macOS", macOSVersion)
+}
+
+func kvmCheck() vm.Check {
+	f, err := os.OpenFile(kvmDevice, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return vm.WarnCheck("KVM", kvmDevice+" not found. QEMU will run without hardware acceleration and will be very slow.", "Ensure your CPU supports virtualization and it is enabled in BIOS.")
+	}
+	if err != nil {
+		return vm.WarnCheck("KVM", fmt.Sprintf("cannot access %s: %v. QEMU will run without hardware acceleration and will be very slow.", kvmDevice, err), "Add your user to the 'kvm' group with `sudo usermod -aG kvm $USER`, then log out and back in.")
+	}
+
+	_ = f.Close()
+	return vm.PassCheck("KVM", kvmDevice+" is accessible")
+}
+
+func limaCheck() vm.Check {
+	err := Installed()
+
+	switch {
+	case err == nil:
+		output, _ := command.Cmd("limactl", []string{"-v"}).Output()
+		return vm.PassCheck("Lima", strings.TrimSpace(string(output)))
+	case runtime.GOOS == "linux" && errors.Is(err, ErrUnparseableVersion) && HasHashVersionOutput(err.Error()):
+		return vm.WarnCheck("Lima", firstLine(err.Error()), fmt.Sprintf("This Linux Lima package reports a git-hash version string. Make sure it's Lima %s.", VersionRequired))
+	default:
+		return vm.FailCheck("Lima", firstLine(err.Error()), "Install or upgrade Lima: https://lima-vm.io/docs/installation/")
+	}
+}
+
+func qemuCheck() vm.Check {
+	binary := qemuBinary()
+
+	if _, err := exec.LookPath(binary); err != nil {
+		return vm.FailCheck("QEMU", binary+" not found in PATH", "Install QEMU so Lima can launch Linux VMs (eg: `sudo apt install qemu-system`).")
+	}
+
+	output, err := command.Cmd(binary, []string{"--version"}).Output()
+	if err != nil {
+		return vm.WarnCheck("QEMU", fmt.Sprintf("could not determine the version of %s: %v", binary, err), "Reinstall QEMU.")
+	}
+
+	return vm.PassCheck("QEMU", firstLine(string(output)))
+}
+
+func (m *Manager) tapDeviceCheck(instance Instance, exists bool) vm.Check {
+	const checkName = "TAP device"
+
+	if !exists || instance.Network == nil {
+		return vm.PassCheck(checkName, "not allocated yet (`trellis vm start` sets it up)")
+	}
+
+	device := instance.Network.Device
+	restart := "Run `trellis vm start` to recreate it (requires sudo)."
+	if instance.Running() {
+		restart = "Run `trellis vm stop && trellis vm start` to recreate it (requires sudo)."
+	}
+
+	currentUser, err := user.Current()
+	if err != nil {
+		return vm.FailCheck(checkName, fmt.Sprintf("could not determine the current user: %v", err), "")
+	}
+
+	state := readTapDeviceState(instance.Network)
+
+	switch {
+	case !state.Exists && instance.Running():
+		return vm.FailCheck(checkName, fmt.Sprintf("%s doesn't exist but the VM is running", device), restart)
+	case !state.Exists:
+		return vm.PassCheck(checkName, fmt.Sprintf("%s doesn't exist yet (`trellis vm start` creates it)", device))
+	case state.Owner != currentUser.Uid:
+		owner := "no user"
+		if state.Owner != "" {
+			owner = "uid " + state.Owner
+		}
+		return vm.FailCheck(checkName, fmt.Sprintf("%s is owned by %s instead of the current user (uid %s)", device, owner, currentUser.Uid), restart)
+	case !state.Addressed:
+		return vm.WarnCheck(checkName, fmt.Sprintf("%s doesn't have the host address %s", device, instance.Network.HostCIDR()), restart)
+	}
+
+	return vm.PassCheck(checkName, fmt.Sprintf("%s is owned by uid %s with address %s", device, state.Owner, instance.Network.HostCIDR()))
+}
+
+func (m *Manager) qemuWrapperCheck(instance Instance, exists bool) vm.Check {
+	const checkName = "QEMU wrapper"
+	const fix = "Run `trellis vm start` to write it."
+
+	if !exists || instance.Network == nil {
+		return vm.PassCheck(checkName, "not written yet (`trellis vm start` writes it)")
+	}
+
+	binary := qemuBinary()
+	wrapperPath := filepath.Join(m.ConfigPath, "bin", instance.Name, binary)
+
+	contents, err := os.ReadFile(wrapperPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return vm.WarnCheck(checkName, wrapperPath+" not found", fix)
+	}
+	if err != nil {
+		return vm.FailCheck(checkName, err.Error(), fix)
+	}
+
+	if !strings.Contains(string(contents), "ifname="+instance.Network.Device+",") {
+		return vm.WarnCheck(checkName, fmt.Sprintf("%s doesn't attach %s", wrapperPath, instance.Network.Device), fix)
+	}
+
+	if realPath, err := exec.LookPath(binary); err == nil && !strings.Contains(string(contents), realPath) {
+		return vm.WarnCheck(checkName, fmt.Sprintf("%s doesn't run %s", wrapperPath, realPath), fix)
+	}
+
+	return vm.PassCheck(checkName, fmt.Sprintf("%s attaches %s", wrapperPath, instance.Network.Device))
+}
+
+/*
+portForwardsCheck checks that the host ports of the port forwards are free. A
+running VM holds its own ports so they're only checked while it's stopped.
+*/
+func (m *Manager) portForwardsCheck(instance Instance, exists bool) vm.Check {
+	const checkName = "Port forwards"
+
+	if exists && instance.Running() {
+		return vm.PassCheck(checkName, "forwarded by the running VM")
+	}
+
+	hostPorts := []int{}
+	if exists {
+		for _, forward := range instance.Config.PortForwards {
+			hostPorts = append(hostPorts, forward.HostPort)
+		}
+	} else {
+		for _, forward := range m.trellis.CliConfig.Vm.PortForwards {
+			hostPorts = append(hostPorts, forward.Host)
+		}
+	}
+
+	if len(hostPorts) == 0 {
+		return vm.PassCheck(checkName, "none configured")
+	}
+
+	busy := []string{}
+	for _, port := range hostPorts {
+		if !portFree(port) {
+			busy = append(busy, fmt.Sprint(port))
+		}
+	}
+
+	if len(busy) > 0 {
+		return vm.FailCheck(checkName, fmt.Sprintf("host ports already in use: %s", strings.Join(busy, ", ")), "Stop the process using them or change vm.port_forwards in trellis.cli.yml (see `lsof -i :PORT`).")
+	}
+
+	return vm.PassCheck(checkName, fmt.Sprintf("%d host ports are free", len(hostPorts)))
+}
+
+func (m *Manager) inventoryCheck(exists bool, running bool) vm.Check {
+	const checkName = "Inventory"
+	path := m.InventoryPath()
+
+	if _, err := os.Stat(path); err == nil {
+		return vm.PassCheck(checkName, path)
+	}
+
+	switch {
+	case running:
+		return vm.FailCheck(checkName, path+" not found", "Restart the VM with `trellis vm stop && trellis vm start`.")
+	case exists:
+		return vm.WarnCheck(checkName, path+" not found", "Run `trellis vm start` to write it.")
+	default:
+		return vm.WarnCheck(checkName, path+" not found (the VM hasn't been created)", "Run `trellis vm start` to create the VM.")
+	}
+}
+
+// readTapDeviceState reads the network's TAP device owner from `ip tuntap` and its addresses from `ip addr`.
+func readTapDeviceState(network *TapNetwork) tapDeviceState {
+	tuntapOutput, _ := command.Cmd("ip", []string{"tuntap", "show"}).CombinedOutput()
+	linkOutput, linkErr := command.Cmd("ip", []string{"-4", "addr", "show", "dev", network.Device}).CombinedOutput()
+
+	exists, owner := parseTapDevice(string(tuntapOutput), network.Device)
+
+	return tapDeviceState{
+		Exists:    exists,
+		Owner:     owner,
+		Addressed: linkErr == nil && strings.Contains(string(linkOutput), network.HostCIDR()),
+	}
+}
+
+// parseTapDevice finds the device in `ip tuntap show` output (eg: "tap0: tap persist user 1000").
+func parseTapDevice(output string, device string) (exists bool, owner string) {
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, device+":") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for i, field := range fields {
+			if field == "user" && i+1 < len(fields) {
+				return true, fields[i+1]
+			}
+		}
+
+		return true, ""
+	}
+
+	return false, ""
+}
+
+func portFree(port int) bool {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return false
+	}
+
+	_ = listener.Close()
+	return true
+}
+
+func qemuBinary() string {
+	if runtime.GOARCH == "arm64" {
+		return "qemu-system-aarch64"
+	}
+
+	return "qemu-system-x86_64"
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -0,0 +1,163 @@
+package lima
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/command"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestParseTapDevice(t *testing.T) {
+	output := "tap0: tap persist user 1000\ntap1: tap persist\ntap10: tap persist user 0\n"
+
+	cases := []struct {
+		device string
+		exists bool
+		owner  string
+	}{
+		{"tap0", true, "1000"},
+		{"tap1", true, ""},
+		{"tap10", true, "0"},
+		{"tap2", false, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.device, func(t *testing.T) {
+			exists, owner := parseTapDevice(output, tc.device)
+
+			if exists != tc.exists || owner != tc.owner {
+				t.Errorf("expected (%v, %q) got (%v, %q)", tc.exists, tc.owner, exists, owner)
+			}
+		})
+	}
+}
+
+func TestTapDeviceCheck(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := newManager(trellis, cli.NewMockUi())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	currentUser, err := user.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	network := NewTapNetwork(1)
+	addrOutput := fmt.Sprintf("5: tap1: <BROADCAST,MULTICAST,UP> mtu 1500\n    inet %s scope global tap1\n", network.HostCIDR())
+
+	cases := []struct {
+		name    string
+		status  string
+		tuntap  string
+		addr    string
+		addrErr int
+		result  vm.CheckStatus
+		message string
+	}{
+		{"missing_stopped", "Stopped", "", "", 1, vm.CheckPass, "tap1 doesn't exist yet"},
+		{"missing_running", "Running", "", "", 1, vm.CheckFail, "tap1 doesn't exist but the VM is running"},
+		{"wrong_owner", "Stopped", "tap1: tap persist user 12345678\n", addrOutput, 0, vm.CheckFail, "tap1 is owned by uid 12345678"},
+		{"no_address", "Stopped", fmt.Sprintf("tap1: tap persist user %s\n", currentUser.Uid), "", 0, vm.CheckWarn, "doesn't have the host address"},
+		{"ok", "Running", fmt.Sprintf("tap1: tap persist user %s\n", currentUser.Uid), addrOutput, 0, vm.CheckPass, "tap1 is owned by uid " + currentUser.Uid},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer command.MockExecCommands(t, []command.MockCommand{
+				{Command: "ip", Args: []string{"tuntap", "show"}, Output: tc.tuntap},
+				{Command: "ip", Args: []string{"-4", "addr", "show", "dev", "tap1"}, Output: tc.addr, ExitCode: tc.addrErr},
+			})()
+
+			instance := Instance{Name: "test", Status: tc.status, Network: &network}
+			check := manager.tapDeviceCheck(instance, true)
+
+			if check.Status != tc.result {
+				t.Errorf("expected status %s got %s (%s)", tc.result, check.Status, check.Message)
+			}
+
+			if !strings.Contains(check.Message, tc.message) {
+				t.Errorf("expected message %q to contain %q", check.Message, tc.message)
+			}
+		})
+	}
+}
+
+func TestPortForwardsCheck(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := newManager(trellis, cli.NewMockUi())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	busyPort := listener.Addr().(*net.TCPAddr).Port
+
+	instance := Instance{
+		Name:   "test",
+		Status: "Stopped",
+		Config: Config{PortForwards: []PortForward{{GuestPort: 80, HostPort: busyPort}}},
+	}
+
+	check := manager.portForwardsCheck(instance, true)
+	if check.Status != vm.CheckFail || !strings.Contains(check.Message, fmt.Sprint(busyPort)) {
+		t.Errorf("expected busy port %d to fail, got %s: %s", busyPort, check.Status, check.Message)
+	}
+
+	instance.Status = "Running"
+	if check := manager.portForwardsCheck(instance, true); check.Status != vm.CheckPass {
+		t.Errorf("expected running VM to pass, got %s: %s", check.Status, check.Message)
+	}
+}
+
+func TestInventoryCheck(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := newManager(trellis, cli.NewMockUi())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if check := manager.inventoryCheck(true, true); check.Status != vm.CheckFail {
+		t.Errorf("expected missing inventory of a running VM to fail, got %s", check.Status)
+	}
+
+	if check := manager.inventoryCheck(false, false); check.Status != vm.CheckWarn {
+		t.Errorf("expected missing inventory without a VM to warn, got %s", check.Status)
+	}
+
+	if err := os.WriteFile(manager.InventoryPath(), []byte("default"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if check := manager.inventoryCheck(true, true); check.Status != vm.CheckPass {
+		t.Errorf("expected existing inventory to pass, got %s", check.Status)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"text/template"
 
 	"github.com/roots/trellis-cli/command"
@@ -36,25 +37,38 @@ type Image struct {
 	Arch     string `yaml:"arch"`
 }
 
+type Mount struct {
+	Location   string `yaml:"location"`
+	MountPoint string `yaml:"mountPoint"`
+}
+
 type Config struct {
+	Cpus         int           `yaml:"cpus"`
+	Memory       string        `yaml:"memory"`
+	Disk         string        `yaml:"disk"`
 	Images       []Image       `yaml:"images"`
 	PortForwards []PortForward `yaml:"portForwards"`
+	Mounts       []Mount       `yaml:"mounts"`
 }
 
 type Instance struct {
 	InventoryFile string
 	Sites         map[string]*trellis.Site
-	Name          string `json:"name"`
-	Status        string `json:"status"`
-	Dir           string `json:"dir"`
-	Arch          string `json:"arch"`
-	Cpus          int    `json:"cpus"`
-	Memory        int    `json:"memory"`
-	Disk          int    `json:"disk"`
-	SshLocalPort  int    `json:"sshLocalPort,omitempty"`
-	VMType        string `json:"vmType,omitempty"`
-	Config        Config `json:"config"`
-	Username      string `json:"username,omitempty"`
+	Name          string      `json:"name"`
+	Status        string      `json:"status"`
+	Dir           string      `json:"dir"`
+	Arch          string      `json:"arch"`
+	Cpus          int         `json:"cpus"`
+	Memory        int         `json:"memory"`
+	Disk          int         `json:"disk"`
+	SshLocalPort  int         `json:"sshLocalPort,omitempty"`
+	VMType        string      `json:"vmType,omitempty"`
+	Config        Config      `json:"config"`
+	Username      string      `json:"username,omitempty"`
+	Network       *TapNetwork `json:"-"`
+	ProjectPath   string      `json:"-"`
+	SyncMode      string      `json:"-"`
+	OverrideFile  string      `json:"-"`
 }
 
 func (i *Instance) ConfigFile() string {
@@ -64,13 +78,27 @@ func (i *Instance) ConfigFile() string {
 func (i *Instance) GenerateConfig() (*bytes.Buffer, error) {
 	var contents bytes.Buffer
 
+	config, err := withoutOverride(i.Config, i.OverrideFile)
+	if err != nil {
+		return &contents, fmt.Errorf("%v: %w", ConfigErr, err)
+	}
+
+	// the override is applied to the result so it must only be templated from trellis-cli's own settings
+	instance := *i
+	instance.Config = config
+
 	tpl := template.Must(template.New("lima").Parse(ConfigTemplate))
 
-	if err := tpl.Execute(&contents, i); err != nil {
+	if err := tpl.Execute(&contents, instance); err != nil {
+		return &contents, fmt.Errorf("%v: %w", ConfigErr, err)
+	}
+
+	merged, err := applyOverride(contents.Bytes(), i.OverrideFile)
+	if err != nil {
 		return &contents, fmt.Errorf("%v: %w", ConfigErr, err)
 	}
 
-	return &contents, nil
+	return bytes.NewBuffer(merged), nil
 }
 
 func (i *Instance) UpdateConfig() error {
@@ -125,11 +153,11 @@ func (i *Instance) IP() (ip string, err error) {
 		return "", fmt.Errorf("%w: %v\n%s", IpErr, err, string(output))
 	}
 
-	if i.VMType == "qemu" {
-		reCustom := regexp.MustCompile(`src (192\.168\.56\.[0-9]+)`)
-		customMatches := reCustom.FindStringSubmatch(string(output))
-		if len(customMatches) >= 2 {
-			return customMatches[1], nil
+	if i.VMType == "qemu" && i.Network != nil {
+		reTap := regexp.MustCompile(`src (` + regexp.QuoteMeta(i.Network.GuestIP) + `)(\s|$)`)
+		tapMatches := reTap.FindStringSubmatch(string(output))
+		if len(tapMatches) >= 2 {
+			return tapMatches[1], nil
 		}
 	}
 
@@ -142,6 +170,32 @@ func (i *Instance) IP() (ip string, err error) {
 	return matches[1], nil
 }
 
+// TrellisManaged reports whether the instance was created by trellis-cli. VMs
+// created before project paths were recorded are detected by their site mounts.
+func (i *Instance) TrellisManaged() bool {
+	if i.ProjectPath != "" {
+		return true
+	}
+
+	for _, mount := range i.Config.Mounts {
+		if strings.HasPrefix(mount.MountPoint, "/srv/www/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadState reads the trellis-cli state stored in the Lima instance directory.
+func (i *Instance) loadState() {
+	if i.Dir == "" {
+		return
+	}
+
+	i.Network, _ = readTapNetwork(i.Dir)
+	i.ProjectPath, _ = readProjectPath(i.Dir)
+}
+
 func (i *Instance) Running() bool {
 	return i.Status == "Running"
 }
@@ -18,11 +18,14 @@ func TestGenerateConfig(t *testing.T) {
 	}
 
 	dir := t.TempDir()
+	network := NewTapNetwork(1)
 
 	absSitePath := filepath.Join(trellis.Path, "../site")
 	testCases := []struct {
 		name     string
 		vmType   string
+		syncMode string
+		network  *TapNetwork
 		expected string
 	}{
 		{
@@ -61,8 +64,41 @@ provision:
 `, absSitePath),
 		},
 		{
-			name:   "qemu",
-			vmType: "qemu",
+			name:     "rsync",
+			vmType:   "vz",
+			syncMode: "rsync",
+			expected: `vmType: "vz"
+rosetta:
+  enabled: false
+images:
+- location: http://ubuntu.com/focal
+  arch: aarch64
+
+mounts: []
+mountType: "virtiofs"
+ssh:
+  forwardAgent: true
+  loadDotSSHPubKeys: true
+networks:
+- vzNAT: true
+
+portForwards:
+- guestPort: 80
+  hostPort: 1234
+
+containerd:
+  user: false
+provision:
+- mode: system
+  script: |
+    #!/bin/bash
+    echo "127.0.0.1 $(hostname)" >> /etc/hosts
+`,
+		},
+		{
+			name:    "qemu",
+			vmType:  "qemu",
+			network: &network,
 			expected: fmt.Sprintf(`vmType: "qemu"
 images:
 - location: http://ubuntu.com/focal
@@ -94,10 +130,10 @@ provision:
     #!/bin/bash
     echo "127.0.0.1 $(hostname)" >> /etc/hosts
 
-    TAP_IFACE="$(ip -o link show | awk '/52:54:00:12:34:56/ {print $2}' | tr -d ':' | head -n1)"
+    TAP_IFACE="$(ip -o link show | awk '/52:54:00:12:35:56/ {print $2}' | tr -d ':' | head -n1)"
     if [ -n "$TAP_IFACE" ]; then
       ip link set "$TAP_IFACE" up
-      ip addr add 192.168.56.5/24 dev "$TAP_IFACE" 2>/dev/null || true
+      ip addr add 192.168.57.5/24 dev "$TAP_IFACE" 2>/dev/null || true
     fi
 `, absSitePath),
 		},
@@ -106,8 +142,10 @@ provision:
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			instance := &Instance{
-				Dir:    dir,
-				VMType: tc.vmType,
+				Dir:      dir,
+				VMType:   tc.vmType,
+				SyncMode: tc.syncMode,
+				Network:  tc.network,
 				Config: Config{
 					Images: []Image{
 						{
@@ -145,11 +183,13 @@ func TestUpdateConfig(t *testing.T) {
 	}
 
 	dir := t.TempDir()
+	network := NewTapNetwork(1)
 
 	absSitePath := filepath.Join(trellis.Path, "../site")
 	testCases := []struct {
 		name     string
 		vmType   string
+		network  *TapNetwork
 		expected string
 	}{
 		{
@@ -188,8 +228,9 @@ provision:
 `, absSitePath),
 		},
 		{
-			name:   "qemu",
-			vmType: "qemu",
+			name:    "qemu",
+			vmType:  "qemu",
+			network: &network,
 			expected: fmt.Sprintf(`vmType: "qemu"
 images:
 - location: http://ubuntu.com/focal
@@ -221,10 +262,10 @@ provision:
     #!/bin/bash
     echo "127.0.0.1 $(hostname)" >> /etc/hosts
 
-    TAP_IFACE="$(ip -o link show | awk '/52:54:00:12:34:56/ {print $2}' | tr -d ':' | head -n1)"
+    TAP_IFACE="$(ip -o link show | awk '/52:54:00:12:35:56/ {print $2}' | tr -d ':' | head -n1)"
     if [ -n "$TAP_IFACE" ]; then
       ip link set "$TAP_IFACE" up
-      ip addr add 192.168.56.5/24 dev "$TAP_IFACE" 2>/dev/null || true
+      ip addr add 192.168.57.5/24 dev "$TAP_IFACE" 2>/dev/null || true
     fi
 `, absSitePath),
 		},
@@ -233,8 +274,9 @@ provision:
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			instance := &Instance{
-				Dir:    dir,
-				VMType: tc.vmType,
+				Dir:     dir,
+				VMType:  tc.vmType,
+				Network: tc.network,
 				Config: Config{
 					Images: []Image{
 						{
@@ -337,13 +379,15 @@ func TestIP(t *testing.T) {
 }
 
 func TestIPQemu(t *testing.T) {
+	network := NewTapNetwork(1)
 	instance := &Instance{
-		Name:   "test",
-		VMType: "qemu",
+		Name:    "test",
+		VMType:  "qemu",
+		Network: &network,
 	}
 
 	mockOutput := `default via 192.168.5.1 dev lima0 proto dhcp src 192.168.5.15 metric 100
-192.168.56.0/24 dev enp0s8 proto kernel scope link src 192.168.56.5
+192.168.57.0/24 dev enp0s8 proto kernel scope link src 192.168.57.5
 `
 	commands := []command.MockCommand{
 		{
@@ -361,8 +405,8 @@ func TestIPQemu(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if ip != "192.168.56.5" {
-		t.Errorf("expected 192.168.56.5\ngot %s", ip)
+	if ip != "192.168.57.5" {
+		t.Errorf("expected 192.168.57.5\ngot %s", ip)
 	}
 }
 
@@ -11,6 +11,7 @@ import (
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/fatih/color"
@@ -24,11 +25,6 @@ import (
 const (
 	configDir            = "lima"
 	RequiredMacOSVersion = "13.0.0"
-	// Linux TAP networking constants. These are hardcoded to a single set of
-	// values, which means only one Lima VM can run at a time on Linux.
-	linuxTapDevice     = "tap0"
-	linuxTapHostCIDR   = "192.168.56.1/24"
-	linuxTapMACAddress = "52:54:00:12:34:56"
 )
 
 var (
@@ -58,6 +54,11 @@ func NewManager(trellis *trellis.Trellis, ui cli.Ui) (manager *Manager, err erro
 		}
 	}
 
+	return newManager(trellis, ui)
+}
+
+// newManager returns a manager without checking the requirements (eg: for diagnostics).
+func newManager(trellis *trellis.Trellis, ui cli.Ui) (manager *Manager, err error) {
 	limaConfigPath := filepath.Join(trellis.ConfigPath(), configDir)
 
 	hostNames := trellis.Environments["development"].AllHosts()
@@ -67,6 +68,10 @@ func NewManager(trellis *trellis.Trellis, ui cli.Ui) (manager *Manager, err erro
 		return nil, err
 	}
 
+	if hostsFile, ok := hostsResolver.(*vm.HostsFileResolver); ok {
+		hostsFile.Manager = "lima"
+	}
+
 	manager = &Manager{
 		ConfigPath:    limaConfigPath,
 		Sites:         trellis.Environments["development"].WordPressSites,
@@ -94,6 +99,28 @@ func (m *Manager) GetInstance(name string) (Instance, bool) {
 	return instance, ok
 }
 
+/*
+GenerateConfig returns the Lima config written for the instance on its next
+start (or used to create it if it doesn't exist yet), including the project's
+override file.
+*/
+func (m *Manager) GenerateConfig(name string) ([]byte, error) {
+	instance, ok := m.GetInstance(name)
+	if !ok {
+		var err error
+		if instance, err = m.newInstance(name); err != nil {
+			return nil, err
+		}
+	}
+
+	contents, err := instance.GenerateConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return contents.Bytes(), nil
+}
+
 func (m *Manager) CreateInstance(name string) error {
 	instance, err := m.newInstance(name)
 	if err != nil {
@@ -132,7 +159,7 @@ func (m *Manager) DeleteInstance(name string) error {
 			return err
 		}
 
-		return nil
+		return m.releaseTapNetwork(instance)
 	} else {
 		return fmt.Errorf("Error: VM is running. Run `trellis vm stop` to stop it.")
 	}
@@ -244,6 +271,18 @@ func (m *Manager) ReadRootFile(remotePath string) ([]byte, error) {
 	return cmd.Output()
 }
 
+func (m *Manager) InstanceIP(name string) (string, error) {
+	instance, ok := m.GetInstance(name)
+	if !ok {
+		return "", vm.ErrVmNotFound
+	}
+	if instance.Stopped() {
+		return "", fmt.Errorf("VM is not running. Run `trellis vm start` to start it.")
+	}
+
+	return instance.IP()
+}
+
 func (m *Manager) StartInstance(name string) error {
 	instance, ok := m.GetInstance(name)
 
@@ -253,15 +292,27 @@ func (m *Manager) StartInstance(name string) error {
 
 	if instance.Running() {
 		m.ui.Info(fmt.Sprintf("%s VM already running", color.GreenString("[✓]")))
-		return nil
+		return m.startSync(instance)
+	}
+
+	if runtime.GOOS == "linux" {
+		if err := m.allocateTapNetwork(&instance); err != nil {
+			return err
+		}
 	}
 
 	if err := instance.UpdateConfig(); err != nil {
 		return err
 	}
 
+	if instance.ProjectPath != m.trellis.Path {
+		if err := writeProjectPath(instance.Dir, m.trellis.Path); err != nil {
+			return fmt.Errorf("Could not save the VM's project path: %v", err)
+		}
+	}
+
 	if runtime.GOOS == "linux" {
-		if err := m.ensureLinuxTapDevice(); err != nil {
+		if err := m.ensureLinuxTapDevice(instance.Network); err != nil {
 			return err
 		}
 	}
@@ -272,7 +323,7 @@ func (m *Manager) StartInstance(name string) error {
 	).Cmd("limactl", []string{"start", instance.Name})
 
 	if runtime.GOOS == "linux" {
-		wrapperDir, err := m.ensureQEMUWrapper()
+		wrapperDir, err := m.ensureQEMUWrapper(instance)
 		if err != nil {
 			return err
 		}
@@ -300,6 +351,10 @@ func (m *Manager) StartInstance(name string) error {
 		return err
 	}
 
+	if err = m.startSync(instance); err != nil {
+		return err
+	}
+
 	if err = m.addHosts(instance); err != nil {
 		return err
 	}
@@ -320,6 +375,10 @@ func (m *Manager) StopInstance(name string) error {
 		return nil
 	}
 
+	if err := m.stopSync(instance); err != nil {
+		return fmt.Errorf("Error stopping the sync daemon\n%v", err)
+	}
+
 	err := command.WithOptions(
 		command.WithTermOutput(),
 		command.WithLogging(m.ui),
@@ -352,6 +411,8 @@ func (m *Manager) hydrateInstance(instance *Instance) error {
 func (m *Manager) initInstance(instance *Instance) {
 	instance.InventoryFile = m.InventoryPath()
 	instance.Sites = m.Sites
+	instance.SyncMode = m.SyncMode()
+	instance.OverrideFile = m.OverridePath()
 }
 
 func (m *Manager) newInstance(name string) (Instance, error) {
@@ -376,22 +437,19 @@ func (m *Manager) newInstance(name string) (Instance, error) {
 		images = imagesFromVersion(m.trellis.CliConfig.Vm.Ubuntu)
 	}
 
-	portForwards := []PortForward{}
-
-	if m.trellis.CliConfig.Vm.ForwardHttpPort {
-		httpForwardPort, err := m.PortFinder.Resolve()
-		if err != nil {
-			return Instance{}, fmt.Errorf("Could not find a local free port for HTTP forwarding: %v", err)
-		}
-
-		portForwards = append(portForwards, PortForward{
-			GuestPort: 80,
-			HostPort:  httpForwardPort,
-		},
-		)
+	portForwards, err := m.portForwards(nil)
+	if err != nil {
+		return Instance{}, err
 	}
 
-	config := Config{Images: images, PortForwards: portForwards}
+	vmConfig := m.trellis.CliConfig.Vm
+	config := Config{
+		Cpus:         vmConfig.Cpus,
+		Memory:       vmConfig.Memory,
+		Disk:         vmConfig.Disk,
+		Images:       images,
+		PortForwards: portForwards,
+	}
 	instance.Config = config
 	return instance, nil
 }
@@ -420,16 +478,65 @@ func (m *Manager) addHosts(instance Instance) error {
 func (m *Manager) instances() (instances map[string]Instance) {
 	instances = make(map[string]Instance)
 
+	for _, instance := range listInstances() {
+		m.initInstance(&instance)
+		instances[instance.Name] = instance
+	}
+
+	return instances
+}
+
+/*
+TrellisInstances returns every Lima instance on the machine created by
+trellis-cli (from any project), sorted by name. It doesn't require a project.
+*/
+func TrellisInstances() []Instance {
+	instances := []Instance{}
+
+	for _, instance := range listInstances() {
+		if instance.TrellisManaged() {
+			instances = append(instances, instance)
+		}
+	}
+
+	sort.Slice(instances, func(i, j int) bool { return instances[i].Name < instances[j].Name })
+
+	return instances
+}
+
+// InstanceNames returns the names of every Lima instance on the machine (from any project).
+func InstanceNames() ([]string, error) {
+	output, err := command.Cmd("limactl", []string{"ls", "--format=json"}).Output()
+	if err != nil {
+		return nil, fmt.Errorf("Could not list Lima instances: %v", err)
+	}
+
+	names := []string{}
+	for line := range bytes.SplitSeq(output, []byte("\n")) {
+		instance := Instance{}
+		if err := json.Unmarshal(line, &instance); err != nil {
+			continue
+		}
+		names = append(names, instance.Name)
+	}
+
+	return names, nil
+}
+
+// listInstances returns all Lima instances with their trellis-cli state loaded.
+func listInstances() []Instance {
+	instances := []Instance{}
+
 	// Returns line delimited JSON
 	output, _ := command.Cmd("limactl", []string{"ls", "--format=json"}).Output()
 
 	for line := range bytes.SplitSeq(output, []byte("\n")) {
-		instance := &Instance{}
-		if err := json.Unmarshal([]byte(line), instance); err != nil {
+		instance := Instance{}
+		if err := json.Unmarshal([]byte(line), &instance); err != nil {
 			continue
 		}
-		m.initInstance(instance)
-		instances[instance.Name] = *instance
+		instance.loadState()
+		instances = append(instances, instance)
 	}
 
 	return instances
@@ -485,33 +592,72 @@ func imagesFromVersion(version string) []Image {
 }
 
 func checkKVM(ui cli.Ui) {
-	f, err := os.OpenFile("/dev/kvm", os.O_RDWR, 0)
-	if err != nil {
-		if os.IsNotExist(err) {
-			ui.Warn("Warning: KVM is not available (/dev/kvm not found). QEMU will run without hardware acceleration and will be very slow.\nEnsure your CPU supports virtualization and it is enabled in BIOS.")
-		} else {
-			ui.Warn(fmt.Sprintf("Warning: Cannot access /dev/kvm: %v\nQEMU will run without hardware acceleration and will be very slow.\nYou may need to add your user to the 'kvm' group:\n\n  sudo usermod -aG kvm $USER\n\nThen log out and back in.", err))
+	if check := kvmCheck(); check.Status != vm.CheckPass {
+		ui.Warn(fmt.Sprintf("Warning: KVM is not available: %s\n%s", check.Message, check.Fix))
+	}
+}
+
+// allocateTapNetwork assigns the instance a TAP network which isn't used by any
+// other Lima instance (from any project) and persists it in the instance directory.
+func (m *Manager) allocateTapNetwork(instance *Instance) error {
+	if instance.Network != nil {
+		return nil
+	}
+
+	used := []int{}
+	for name, other := range m.instances() {
+		if name != instance.Name && other.Network != nil {
+			used = append(used, other.Network.Index)
 		}
-		return
 	}
 
-	_ = f.Close()
+	network, err := allocateTapNetwork(used)
+	if err != nil {
+		return err
+	}
+
+	if err := writeTapNetwork(instance.Dir, network); err != nil {
+		return fmt.Errorf("Could not save Linux TAP network allocation: %v", err)
+	}
+
+	instance.Network = &network
+	return nil
+}
+
+// releaseTapNetwork removes the instance's TAP network allocation and its TAP device.
+func (m *Manager) releaseTapNetwork(instance Instance) error {
+	if instance.Network == nil {
+		return nil
+	}
+
+	if err := removeTapNetwork(instance.Dir); err != nil {
+		return fmt.Errorf("Could not remove Linux TAP network allocation: %v", err)
+	}
+
+	tuntapOutput, _ := command.Cmd("ip", []string{"tuntap", "show"}).CombinedOutput()
+	if exists, _ := parseTapDevice(string(tuntapOutput), instance.Network.Device); !exists {
+		return nil
+	}
+
+	err := command.WithOptions(
+		command.WithTermOutput(),
+		command.WithLogging(m.ui),
+	).Cmd("sudo", []string{"ip", "tuntap", "del", "dev", instance.Network.Device, "mode", "tap"}).Run()
+
+	if err != nil {
+		return fmt.Errorf("Could not remove Linux TAP interface %q: %v", instance.Network.Device, err)
+	}
+
+	return nil
 }
 
-func (m *Manager) ensureLinuxTapDevice() error {
+func (m *Manager) ensureLinuxTapDevice(network *TapNetwork) error {
 	currentUser, err := user.Current()
 	if err != nil {
 		return fmt.Errorf("Could not determine current user for Linux TAP setup: %v", err)
 	}
 
-	tuntapOutput, _ := command.Cmd("ip", []string{"tuntap", "show"}).CombinedOutput()
-	linkOutput, linkErr := command.Cmd("ip", []string{"-4", "addr", "show", "dev", linuxTapDevice}).CombinedOutput()
-	expectedOwner := fmt.Sprintf("user %s", currentUser.Uid)
-
-	if strings.Contains(string(tuntapOutput), linuxTapDevice+":") &&
-		strings.Contains(string(tuntapOutput), expectedOwner) &&
-		linkErr == nil &&
-		strings.Contains(string(linkOutput), "192.168.56.1/24") {
+	if state := readTapDeviceState(network); state.Exists && state.Owner == currentUser.Uid && state.Addressed {
 		return nil
 	}
 
@@ -530,25 +676,27 @@ fi
 ip tuntap add dev %s mode tap user %s
 ip addr replace %s dev %s
 ip link set %s up`,
-			linuxTapDevice,
-			linuxTapDevice,
-			linuxTapDevice,
+			network.Device,
+			network.Device,
+			network.Device,
 			currentUser.Uid,
-			linuxTapHostCIDR,
-			linuxTapDevice,
-			linuxTapDevice,
+			network.HostCIDR(),
+			network.Device,
+			network.Device,
 		),
 	})
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("Could not configure Linux TAP interface %q: %v", linuxTapDevice, err)
+		return fmt.Errorf("Could not configure Linux TAP interface %q: %v", network.Device, err)
 	}
 
 	return nil
 }
 
-func (m *Manager) ensureQEMUWrapper() (string, error) {
-	wrapperDir := filepath.Join(m.ConfigPath, "bin")
+// ensureQEMUWrapper writes QEMU wrappers which attach the instance's TAP device.
+// Wrappers are per instance since each one has its own TAP network.
+func (m *Manager) ensureQEMUWrapper(instance Instance) (string, error) {
+	wrapperDir := filepath.Join(m.ConfigPath, "bin", instance.Name)
 	if err := os.MkdirAll(wrapperDir, 0755); err != nil {
 		return "", fmt.Errorf("Could not create QEMU wrapper directory: %v", err)
 	}
@@ -582,7 +730,7 @@ exec %q \
   -netdev tap,id=trellis-tap0,ifname=%s,script=no,downscript=no \
   -device virtio-net-pci,netdev=trellis-tap0,mac=%s \
   "$@"
-`, realPath, realPath, linuxTapDevice, linuxTapMACAddress)
+`, realPath, realPath, instance.Network.Device, instance.Network.MAC)
 
 		if err := os.WriteFile(wrapperPath, []byte(wrapperScript), 0755); err != nil {
 			return "", fmt.Errorf("Could not write QEMU wrapper %q: %v", wrapperPath, err)
@@ -6,6 +6,7 @@ import (
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/cli"
@@ -302,6 +303,7 @@ func TestStartInstance(t *testing.T) {
 	sshPort := 60720
 	username := "user1"
 	ip := "192.168.64.2"
+	network := NewTapNetwork(0)
 	vmType := "vz"
 	expectedHostIP := ip
 	if runtime.GOOS == "linux" {
@@ -346,7 +348,7 @@ func TestStartInstance(t *testing.T) {
 			},
 			command.MockCommand{
 				Command:  "ip",
-				Args:     []string{"-4", "addr", "show", "dev", linuxTapDevice},
+				Args:     []string{"-4", "addr", "show", "dev", network.Device},
 				Output:   "",
 				ExitCode: 1,
 			},
@@ -358,7 +360,7 @@ fi
 ip tuntap add dev %s mode tap user %s
 ip addr replace %s dev %s
 ip link set %s up`,
-					linuxTapDevice, linuxTapDevice, linuxTapDevice, currentUser.Uid, linuxTapHostCIDR, linuxTapDevice, linuxTapDevice)},
+					network.Device, network.Device, network.Device, currentUser.Uid, network.HostCIDR(), network.Device, network.Device)},
 				Output: ``,
 			},
 		)
@@ -410,6 +412,128 @@ default
 	if hostsStorage[instanceName] != expectedHostIP {
 		t.Errorf("expected hosts entry to be %s, got %s", expectedHostIP, hostsStorage[instanceName])
 	}
+
+	if runtime.GOOS == "linux" {
+		allocated, err := readTapNetwork(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if allocated == nil || *allocated != network {
+			t.Errorf("expected TAP network %v to be allocated, got %v", network, allocated)
+		}
+
+		wrapper, err := os.ReadFile(filepath.Join(manager.ConfigPath, "bin", instanceName, "qemu-system-aarch64"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(string(wrapper), "ifname=tap0") || !strings.Contains(string(wrapper), "mac=52:54:00:12:34:56") {
+			t.Errorf("expected QEMU wrapper to use the allocated TAP network, got %s", wrapper)
+		}
+	}
+}
+
+func TestAllocateTapNetworkSkipsOtherInstances(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TRELLIS_BYPASS_LIMA_REQUIREMENTS", "1")
+
+	manager, err := NewManager(trellis, cli.NewMockUi())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherDir := t.TempDir()
+	dir := t.TempDir()
+
+	if err := writeTapNetwork(otherDir, NewTapNetwork(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	commands := []command.MockCommand{
+		{
+			Command: "limactl",
+			Args:    []string{"ls", "--format=json"},
+			Output: fmt.Sprintf(`{"name":"other","status":"Running","dir":"%s","vmType":"qemu"}
+{"name":"test","status":"Stopped","dir":"%s","vmType":"qemu"}`, otherDir, dir),
+		},
+	}
+	defer command.MockExecCommands(t, commands)()
+
+	instance, _ := manager.GetInstance("test")
+	if err := manager.allocateTapNetwork(&instance); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := NewTapNetwork(1)
+	if instance.Network == nil || *instance.Network != expected {
+		t.Fatalf("expected TAP network %v, got %v", expected, instance.Network)
+	}
+
+	instance, _ = manager.GetInstance("test")
+	if instance.Network == nil || *instance.Network != expected {
+		t.Errorf("expected TAP network %v to be persisted, got %v", expected, instance.Network)
+	}
+}
+
+func TestDeleteInstanceReleasesTapNetwork(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TRELLIS_BYPASS_LIMA_REQUIREMENTS", "1")
+
+	manager, err := NewManager(trellis, cli.NewMockUi())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := writeTapNetwork(dir, NewTapNetwork(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	commands := []command.MockCommand{
+		{
+			Command: "limactl",
+			Args:    []string{"ls", "--format=json"},
+			Output:  fmt.Sprintf(`{"name":"test","status":"Stopped","dir":"%s","vmType":"qemu"}`, dir),
+		},
+		{
+			Command: "limactl",
+			Args:    []string{"delete", "test"},
+		},
+		{
+			Command: "ip",
+			Args:    []string{"tuntap", "show"},
+			Output:  "tap2: tap persist user 1000\n",
+		},
+		{
+			Command: "sudo",
+			Args:    []string{"ip", "tuntap", "del", "dev", "tap2", "mode", "tap"},
+		},
+	}
+	defer command.MockExecCommands(t, commands)()
+
+	if err := manager.DeleteInstance("test"); err != nil {
+		t.Fatal(err)
+	}
+
+	network, err := readTapNetwork(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if network != nil {
+		t.Errorf("expected TAP network allocation to be removed, got %v", network)
+	}
 }
 
 func (h *MockHostsResolver) AddHosts(name string, ip string) error {
@@ -421,3 +545,8 @@ func (h *MockHostsResolver) RemoveHosts(name string) error {
 	delete(h.Hosts, name)
 	return nil
 }
+
+func (h *MockHostsResolver) HasHosts(name string) (bool, error) {
+	_, ok := h.Hosts[name]
+	return ok, nil
+}
@@ -0,0 +1,99 @@
+package lima
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+const (
+	// networkFile stores an instance's TAP network allocation in its Lima instance directory.
+	networkFile = "trellis-network.json"
+	// maxTapNetworks limits allocations to the 192.168.56.0/24 - 192.168.119.0/24 subnets.
+	maxTapNetworks = 64
+)
+
+var ErrNoFreeTapNetwork = errors.New("no free TAP network available for Linux VM networking")
+
+/*
+TapNetwork is the host-reachable network of a Linux (QEMU) instance.
+Each instance is allocated its own TAP device, /24 subnet, guest IP and MAC
+address so multiple VMs can run at the same time. Index 0 matches the values
+used before allocations existed (tap0, 192.168.56.0/24).
+*/
+type TapNetwork struct {
+	Index   int    `json:"index"`
+	Device  string `json:"device"`
+	HostIP  string `json:"host_ip"`
+	GuestIP string `json:"guest_ip"`
+	MAC     string `json:"mac"`
+}
+
+func NewTapNetwork(index int) TapNetwork {
+	subnet := 56 + index
+
+	return TapNetwork{
+		Index:   index,
+		Device:  fmt.Sprintf("tap%d", index),
+		HostIP:  fmt.Sprintf("192.168.%d.1", subnet),
+		GuestIP: fmt.Sprintf("192.168.%d.5", subnet),
+		MAC:     fmt.Sprintf("52:54:00:12:%02x:56", 0x34+index),
+	}
+}
+
+func (n *TapNetwork) HostCIDR() string {
+	return n.HostIP + "/24"
+}
+
+func (n *TapNetwork) GuestCIDR() string {
+	return n.GuestIP + "/24"
+}
+
+// allocateTapNetwork returns the lowest TAP network not in use by another instance.
+func allocateTapNetwork(used []int) (TapNetwork, error) {
+	for index := range maxTapNetworks {
+		if !slices.Contains(used, index) {
+			return NewTapNetwork(index), nil
+		}
+	}
+
+	return TapNetwork{}, ErrNoFreeTapNetwork
+}
+
+// readTapNetwork returns the allocation stored in dir, or nil if there is none.
+func readTapNetwork(dir string) (*TapNetwork, error) {
+	data, err := os.ReadFile(filepath.Join(dir, networkFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	network := &TapNetwork{}
+	if err := json.Unmarshal(data, network); err != nil {
+		return nil, fmt.Errorf("Could not parse %s: %v", filepath.Join(dir, networkFile), err)
+	}
+
+	return network, nil
+}
+
+func writeTapNetwork(dir string, network TapNetwork) error {
+	data, err := json.MarshalIndent(network, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, networkFile), data, 0644)
+}
+
+func removeTapNetwork(dir string) error {
+	if err := os.Remove(filepath.Join(dir, networkFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
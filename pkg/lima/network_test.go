@@ -0,0 +1,73 @@
+package lima
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewTapNetwork(t *testing.T) {
+	cases := []struct {
+		index    int
+		expected TapNetwork
+	}{
+		{0, TapNetwork{Index: 0, Device: "tap0", HostIP: "192.168.56.1", GuestIP: "192.168.56.5", MAC: "52:54:00:12:34:56"}},
+		{1, TapNetwork{Index: 1, Device: "tap1", HostIP: "192.168.57.1", GuestIP: "192.168.57.5", MAC: "52:54:00:12:35:56"}},
+		{63, TapNetwork{Index: 63, Device: "tap63", HostIP: "192.168.119.1", GuestIP: "192.168.119.5", MAC: "52:54:00:12:73:56"}},
+	}
+
+	for _, tc := range cases {
+		if network := NewTapNetwork(tc.index); network != tc.expected {
+			t.Errorf("expected %v, got %v", tc.expected, network)
+		}
+	}
+}
+
+func TestAllocateTapNetwork(t *testing.T) {
+	network, err := allocateTapNetwork([]int{0, 1, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if network.Index != 2 {
+		t.Errorf("expected index 2, got %d", network.Index)
+	}
+
+	used := []int{}
+	for index := range maxTapNetworks {
+		used = append(used, index)
+	}
+
+	if _, err := allocateTapNetwork(used); !errors.Is(err, ErrNoFreeTapNetwork) {
+		t.Errorf("expected ErrNoFreeTapNetwork, got %v", err)
+	}
+}
+
+func TestReadWriteTapNetwork(t *testing.T) {
+	dir := t.TempDir()
+
+	network, err := readTapNetwork(dir)
+	if err != nil || network != nil {
+		t.Fatalf("expected no allocation, got %v (%v)", network, err)
+	}
+
+	if err := writeTapNetwork(dir, NewTapNetwork(4)); err != nil {
+		t.Fatal(err)
+	}
+
+	network, err = readTapNetwork(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if network == nil || *network != NewTapNetwork(4) {
+		t.Errorf("expected %v, got %v", NewTapNetwork(4), network)
+	}
+
+	if err := removeTapNetwork(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := removeTapNetwork(dir); err != nil {
+		t.Errorf("expected removing a missing allocation to succeed, got %v", err)
+	}
+}
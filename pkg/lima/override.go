@@ -0,0 +1,150 @@
+package lima
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v2"
+)
+
+// overrideFile is the project's Lima config override (in the project's Lima config directory).
+const overrideFile = "override.yml"
+
+// OverridePath is the project's Lima config override file (.trellis/lima/override.yml).
+func (m *Manager) OverridePath() string {
+	return filepath.Join(m.ConfigPath, overrideFile)
+}
+
+// applyOverride deep-merges the override file (if it exists) over the generated config.
+func applyOverride(config []byte, overridePath string) ([]byte, error) {
+	if overridePath == "" {
+		return config, nil
+	}
+
+	overrideContents, err := os.ReadFile(overridePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	override := yaml.MapSlice{}
+	if err := yaml.Unmarshal(overrideContents, &override); err != nil {
+		return nil, fmt.Errorf("Could not parse %s: %v", overridePath, err)
+	}
+
+	if len(override) == 0 {
+		return config, nil
+	}
+
+	generated := yaml.MapSlice{}
+	if err := yaml.Unmarshal(config, &generated); err != nil {
+		return nil, fmt.Errorf("Could not parse the generated config: %v", err)
+	}
+
+	return yaml.Marshal(mergeYaml(generated, override))
+}
+
+// readOverride returns the settings in the override file (if it exists).
+func readOverride(overridePath string) (Config, error) {
+	override := Config{}
+
+	if overridePath == "" {
+		return override, nil
+	}
+
+	overrideContents, err := os.ReadFile(overridePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return override, nil
+	}
+	if err != nil {
+		return override, err
+	}
+
+	if err := yaml.Unmarshal(overrideContents, &override); err != nil {
+		return override, fmt.Errorf("Could not parse %s: %v", overridePath, err)
+	}
+
+	return override, nil
+}
+
+/*
+withoutOverride removes the images and port forwards which the override file
+adds from an existing instance's config. The config Lima reports for an
+instance already has the override merged in, so they'd otherwise be appended
+again each time the config is regenerated.
+*/
+func withoutOverride(config Config, overridePath string) (Config, error) {
+	override, err := readOverride(overridePath)
+	if err != nil {
+		return config, err
+	}
+
+	images := []Image{}
+	for _, image := range config.Images {
+		if !slices.ContainsFunc(override.Images, func(o Image) bool { return o.Location == image.Location && o.Arch == image.Arch }) {
+			images = append(images, image)
+		}
+	}
+
+	portForwards := []PortForward{}
+	for _, forward := range config.PortForwards {
+		if !slices.Contains(override.PortForwards, forward) {
+			portForwards = append(portForwards, forward)
+		}
+	}
+
+	config.Images = images
+	config.PortForwards = portForwards
+
+	return config, nil
+}
+
+/*
+mergeYaml merges override into base. Mappings are merged key by key, sequences
+are appended to (eg: extra mounts or provision scripts) and other values are
+replaced. A null value removes the key.
+*/
+func mergeYaml(base interface{}, override interface{}) interface{} {
+	switch override := override.(type) {
+	case yaml.MapSlice:
+		baseMap, _ := base.(yaml.MapSlice)
+
+		merged := yaml.MapSlice{}
+		merged = append(merged, baseMap...)
+
+		for _, item := range override {
+			index := -1
+			for i, existing := range merged {
+				if existing.Key == item.Key {
+					index = i
+					break
+				}
+			}
+
+			switch {
+			case item.Value == nil && index >= 0:
+				merged = append(merged[:index], merged[index+1:]...)
+			case item.Value == nil:
+			case index >= 0:
+				merged[index].Value = mergeYaml(merged[index].Value, item.Value)
+			default:
+				merged = append(merged, yaml.MapItem{Key: item.Key, Value: mergeYaml(nil, item.Value)})
+			}
+		}
+
+		return merged
+	case []interface{}:
+		if baseSlice, ok := base.([]interface{}); ok {
+			return append(append([]interface{}{}, baseSlice...), override...)
+		}
+
+		return override
+	default:
+		return override
+	}
+}
@@ -0,0 +1,131 @@
+package lima
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestApplyOverride(t *testing.T) {
+	generated := `vmType: "vz"
+mounts:
+- location: /site
+  mountPoint: /srv/www/example.com/current
+  writable: true
+mountType: "virtiofs"
+ssh:
+  forwardAgent: true
+  loadDotSSHPubKeys: true
+containerd:
+  user: false
+`
+
+	override := `mountType: 9p
+nestedVirtualization: true
+ssh:
+  loadDotSSHPubKeys: false
+containerd: null
+env:
+  FOO: bar
+mounts:
+- location: ~/shared
+`
+
+	expected := `vmType: vz
+mounts:
+- location: /site
+  mountPoint: /srv/www/example.com/current
+  writable: true
+- location: ~/shared
+mountType: 9p
+ssh:
+  forwardAgent: true
+  loadDotSSHPubKeys: false
+nestedVirtualization: true
+env:
+  FOO: bar
+`
+
+	path := filepath.Join(t.TempDir(), "override.yml")
+
+	merged, err := applyOverride([]byte(generated), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(merged) != generated {
+		t.Errorf("expected the generated config to be unchanged without an override file, got\n%s", merged)
+	}
+
+	if err := os.WriteFile(path, []byte(override), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err = applyOverride([]byte(generated), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(merged) != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, merged)
+	}
+
+	if err := os.WriteFile(path, []byte("mounts: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = applyOverride([]byte(generated), path); err == nil {
+		t.Error("expected an error for an invalid override file")
+	}
+}
+
+func TestGenerateConfigWithOverrideIsStable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "override.yml")
+	override := `images:
+- location: https://example.com/custom.img
+  arch: x86_64
+portForwards:
+- guestPort: 3000
+  hostPort: 3000
+`
+
+	if err := os.WriteFile(path, []byte(override), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	instance := &Instance{
+		Name:         "test",
+		VMType:       "vz",
+		OverrideFile: path,
+		Config: Config{
+			Images:       []Image{{Location: "http://ubuntu.com/noble", Arch: "aarch64"}},
+			PortForwards: []PortForward{{GuestPort: 80, HostPort: 60720}},
+		},
+	}
+
+	first, err := instance.GenerateConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Lima reports the config with the override already merged in
+	if err := yaml.Unmarshal(first.Bytes(), &instance.Config); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := instance.GenerateConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.String() != second.String() {
+		t.Errorf("expected regenerating the config to be stable\nfirst:\n%s\nsecond:\n%s", first, second)
+	}
+
+	if count := strings.Count(second.String(), "guestPort: 3000"); count != 1 {
+		t.Errorf("expected the override's port forward once, got %d times:\n%s", count, second)
+	}
+}
@@ -0,0 +1,24 @@
+package lima
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// projectFile records which Trellis project an instance belongs to (in its Lima instance directory).
+const projectFile = "trellis-project"
+
+func readProjectPath(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, projectFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+
+	return strings.TrimSpace(string(data)), err
+}
+
+func writeProjectPath(dir string, projectPath string) error {
+	return os.WriteFile(filepath.Join(dir, projectFile), []byte(projectPath+"\n"), 0644)
+}
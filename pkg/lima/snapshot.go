@@ -0,0 +1,70 @@
+package lima
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/roots/trellis-cli/command"
+	"github.com/roots/trellis-cli/pkg/vm"
+)
+
+/*
+SnapshotsSupported reports whether Lima can snapshot the instance. Lima only
+supports snapshots of QEMU VMs, and QEMU can't save a running VM with 9p mounts.
+Mounted sites also live on the host so their uploads wouldn't be part of the
+snapshot; those instances fall back to database and uploads snapshots.
+*/
+func (m *Manager) SnapshotsSupported(name string) bool {
+	instance, ok := m.GetInstance(name)
+	return ok && instance.VMType == "qemu" && len(instance.Config.Mounts) == 0
+}
+
+func (m *Manager) SnapshotKind() string {
+	return "full VM"
+}
+
+func (m *Manager) CreateSnapshot(name string, tag string) error {
+	return m.snapshot("create", name, tag)
+}
+
+func (m *Manager) RestoreSnapshot(name string, tag string) error {
+	return m.snapshot("apply", name, tag)
+}
+
+func (m *Manager) DeleteSnapshot(name string, tag string) error {
+	return m.snapshot("delete", name, tag)
+}
+
+func (m *Manager) ListSnapshots(name string) ([]vm.Snapshot, error) {
+	if _, ok := m.GetInstance(name); !ok {
+		return nil, vm.ErrVmNotFound
+	}
+
+	output, err := command.WithOptions(
+		command.WithLogging(m.ui),
+	).Cmd("limactl", []string{"snapshot", "list", "--quiet", name}).Output()
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not list snapshots: %v", err)
+	}
+
+	snapshots := []vm.Snapshot{}
+	for tag := range strings.SplitSeq(string(output), "\n") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			snapshots = append(snapshots, vm.Snapshot{Name: tag})
+		}
+	}
+
+	return snapshots, nil
+}
+
+func (m *Manager) snapshot(action string, name string, tag string) error {
+	if _, ok := m.GetInstance(name); !ok {
+		return vm.ErrVmNotFound
+	}
+
+	return command.WithOptions(
+		command.WithTermOutput(),
+		command.WithLogging(m.ui),
+	).Cmd("limactl", []string{"snapshot", action, name, "--tag", tag}).Run()
+}
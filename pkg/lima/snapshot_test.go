@@ -0,0 +1,76 @@
+package lima
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/command"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestSnapshots(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TRELLIS_BYPASS_LIMA_REQUIREMENTS", "1")
+
+	manager, err := NewManager(trellis, cli.NewMockUi())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer command.MockExecCommands(t, []command.MockCommand{
+		{
+			Command: "limactl",
+			Args:    []string{"ls", "--format=json"},
+			Output: fmt.Sprintf(`{"name":"qemu","status":"Running","dir":"%s","vmType":"qemu"}
+{"name":"vz","status":"Running","dir":"%s","vmType":"vz"}
+{"name":"mounted","status":"Running","dir":"%s","vmType":"qemu","config":{"mounts":[{"location":"/tmp/site","mountPoint":"/srv/www/example.com/current"}]}}`, t.TempDir(), t.TempDir(), t.TempDir()),
+		},
+		{
+			Command: "limactl",
+			Args:    []string{"snapshot", "create", "qemu", "--tag", "before-upgrade"},
+		},
+		{
+			Command: "limactl",
+			Args:    []string{"snapshot", "list", "--quiet", "qemu"},
+			Output:  "before-upgrade\nafter-migration\n",
+		},
+	})()
+
+	if !manager.SnapshotsSupported("qemu") {
+		t.Error("expected snapshots to be supported for QEMU VMs")
+	}
+
+	if manager.SnapshotsSupported("vz") || manager.SnapshotsSupported("missing") {
+		t.Error("expected snapshots to be unsupported for VZ and missing VMs")
+	}
+
+	if manager.SnapshotsSupported("mounted") {
+		t.Error("expected snapshots to be unsupported for QEMU VMs with mounts")
+	}
+
+	if err := manager.CreateSnapshot("qemu", "before-upgrade"); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshots, err := manager.ListSnapshots("qemu")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []vm.Snapshot{{Name: "before-upgrade"}, {Name: "after-migration"}}
+	if !reflect.DeepEqual(snapshots, expected) {
+		t.Errorf("expected %v, got %v", expected, snapshots)
+	}
+
+	if err := manager.RestoreSnapshot("missing", "before-upgrade"); err != vm.ErrVmNotFound {
+		t.Errorf("expected ErrVmNotFound, got %v", err)
+	}
+}
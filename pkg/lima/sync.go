@@ -0,0 +1,194 @@
+package lima
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/roots/trellis-cli/pkg/file_sync"
+	"github.com/roots/trellis-cli/pkg/vm"
+)
+
+// The sync daemon's status and log are kept in the Lima instance directory.
+const (
+	syncStatusFile = "trellis-sync.json"
+	syncLogFile    = "trellis-sync.log"
+	flushTimeout   = 2 * time.Minute
+)
+
+// syncDaemonCommand runs `trellis vm sync daemon` for the project at projectPath.
+var syncDaemonCommand = func(projectPath string) *exec.Cmd {
+	executable, _ := os.Executable()
+	cmd := exec.Command(executable, "vm", "sync", "daemon")
+	cmd.Dir = projectPath
+	return cmd
+}
+
+// SyncMode is `vm.sync_mode`: mount (the default) or rsync.
+func (m *Manager) SyncMode() string {
+	if m.trellis.CliConfig.Vm.SyncMode == "" {
+		return "mount"
+	}
+
+	return m.trellis.CliConfig.Vm.SyncMode
+}
+
+// SyncExcludes are the `vm.sync_excludes` patterns, or the defaults if they aren't set.
+func (m *Manager) SyncExcludes() []string {
+	if m.trellis.CliConfig.Vm.SyncExcludes == nil {
+		return file_sync.DefaultExcludes
+	}
+
+	return m.trellis.CliConfig.Vm.SyncExcludes
+}
+
+// SyncStatus returns the sync daemon's last status, or nil if it has never run.
+func (m *Manager) SyncStatus(name string) (*file_sync.Status, error) {
+	instance, ok := m.GetInstance(name)
+	if !ok {
+		return nil, vm.ErrVmNotFound
+	}
+
+	status, err := file_sync.ReadStatus(instance.syncStatusPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	return status, err
+}
+
+// FlushSync syncs immediately through the sync daemon, restarting it if it isn't running.
+func (m *Manager) FlushSync(name string) (*file_sync.Status, error) {
+	instance, ok := m.GetInstance(name)
+	if !ok {
+		return nil, vm.ErrVmNotFound
+	}
+
+	if instance.Stopped() {
+		return nil, fmt.Errorf("VM is not running. Run `trellis vm start` to start it.")
+	}
+
+	status, err := file_sync.Flush(instance.syncStatusPath(), flushTimeout)
+	if !errors.Is(err, file_sync.ErrDaemonNotRunning) {
+		return status, err
+	}
+
+	// The initial sync of a (re)started daemon is the flush
+	status = &file_sync.Status{LastSync: time.Now()}
+	if err := m.startSync(instance); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// SyncDaemon returns the daemon which keeps the instance's sites in sync until it's stopped.
+func (m *Manager) SyncDaemon(name string) (*file_sync.Daemon, error) {
+	instance, ok := m.GetInstance(name)
+	if !ok {
+		return nil, vm.ErrVmNotFound
+	}
+
+	if instance.Stopped() {
+		return nil, fmt.Errorf("VM is not running. Run `trellis vm start` to start it.")
+	}
+
+	return &file_sync.Daemon{
+		Syncers:    m.syncers(instance),
+		StatusPath: instance.syncStatusPath(),
+		Interval:   file_sync.DefaultInterval,
+		Running: func() bool {
+			instance, ok := m.GetInstance(name)
+			return ok && instance.Running()
+		},
+	}, nil
+}
+
+func (m *Manager) syncers(instance Instance) []*file_sync.Syncer {
+	names := make([]string, 0, len(instance.Sites))
+	for name := range instance.Sites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	syncers := []*file_sync.Syncer{}
+	for _, name := range names {
+		syncers = append(syncers, &file_sync.Syncer{
+			LocalDir:  instance.Sites[name].AbsLocalPath,
+			RemoteDir: "/srv/www/" + name + "/current",
+			SshArgs:   []string{"-F", filepath.Join(instance.Dir, "ssh.config")},
+			Host:      "lima-" + instance.Name,
+			Excludes:  m.SyncExcludes(),
+		})
+	}
+
+	return syncers
+}
+
+/*
+startSync copies the sites into the VM (so they're available for provisioning)
+and starts the sync daemon in the background unless it's already running. It
+does nothing unless `vm.sync_mode` is rsync.
+*/
+func (m *Manager) startSync(instance Instance) error {
+	if m.SyncMode() != "rsync" {
+		return nil
+	}
+
+	if status, err := file_sync.ReadStatus(instance.syncStatusPath()); err == nil && status.Running() {
+		return nil
+	}
+
+	m.ui.Info("Syncing site files to the VM...")
+
+	for _, syncer := range m.syncers(instance) {
+		if err := syncer.Initial(); err != nil {
+			return fmt.Errorf("Could not sync %s to the VM: %v", syncer.LocalDir, err)
+		}
+	}
+
+	logFile, err := os.OpenFile(filepath.Join(instance.Dir, syncLogFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = logFile.Close() }()
+
+	cmd := syncDaemonCommand(m.trellis.Path)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("Could not start the sync daemon: %v", err)
+	}
+
+	_ = cmd.Process.Release()
+
+	m.ui.Info(fmt.Sprintf("%s Started syncing site files. Run `trellis vm sync status` for details.", color.GreenString("[✓]")))
+	return nil
+}
+
+// stopSync stops the sync daemon if it's running.
+func (m *Manager) stopSync(instance Instance) error {
+	status, err := file_sync.ReadStatus(instance.syncStatusPath())
+	if err != nil || !status.Running() {
+		return nil
+	}
+
+	process, err := os.FindProcess(status.Pid)
+	if err != nil {
+		return err
+	}
+
+	return process.Signal(syscall.SIGTERM)
+}
+
+func (i *Instance) syncStatusPath() string {
+	return filepath.Join(i.Dir, syncStatusFile)
+}
@@ -0,0 +1,81 @@
+package lima
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/command"
+	"github.com/roots/trellis-cli/pkg/file_sync"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+func TestSyncDaemon(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	trellis := trellis.NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TRELLIS_BYPASS_LIMA_REQUIREMENTS", "1")
+
+	manager, err := NewManager(trellis, cli.NewMockUi())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if manager.SyncMode() != "mount" {
+		t.Errorf("expected the default sync mode to be mount, got %s", manager.SyncMode())
+	}
+
+	if !reflect.DeepEqual(manager.SyncExcludes(), file_sync.DefaultExcludes) {
+		t.Errorf("expected the default excludes, got %v", manager.SyncExcludes())
+	}
+
+	trellis.CliConfig.Vm.SyncMode = "rsync"
+	trellis.CliConfig.Vm.SyncExcludes = []string{"vendor/"}
+
+	dir := t.TempDir()
+
+	defer command.MockExecCommands(t, []command.MockCommand{
+		{
+			Command: "limactl",
+			Args:    []string{"ls", "--format=json"},
+			Output:  fmt.Sprintf(`{"name":"example.com","status":"Running","dir":"%s","vmType":"vz"}`, dir),
+		},
+	})()
+
+	status, err := manager.SyncStatus("example.com")
+	if err != nil || status != nil {
+		t.Errorf("expected no status before the daemon runs, got %v (%v)", status, err)
+	}
+
+	daemon, err := manager.SyncDaemon("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if daemon.StatusPath != filepath.Join(dir, "trellis-sync.json") {
+		t.Errorf("unexpected status path %s", daemon.StatusPath)
+	}
+
+	expected := []*file_sync.Syncer{
+		{
+			LocalDir:  filepath.Join(trellis.Path, "../site"),
+			RemoteDir: "/srv/www/example.com/current",
+			SshArgs:   []string{"-F", filepath.Join(dir, "ssh.config")},
+			Host:      "lima-example.com",
+			Excludes:  []string{"vendor/"},
+		},
+	}
+
+	if !reflect.DeepEqual(daemon.Syncers, expected) {
+		t.Errorf("expected syncers %v got %v", expected[0], daemon.Syncers[0])
+	}
+
+	if !daemon.Running() {
+		t.Error("expected the daemon to report the VM as running")
+	}
+}
@@ -0,0 +1,308 @@
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/roots/trellis-cli/pkg/server/types"
+)
+
+const (
+	defaultBaseURL = "https://api.cloudflare.com/client/v4"
+	defaultTTL     = 300
+)
+
+// Provider implements the types.DNSProvider interface for Cloudflare.
+// Cloudflare only manages DNS so it's used alongside a separate compute provider.
+type Provider struct {
+	// AccountID is required to create zones when the API token has access to more than one account.
+	AccountID string
+	baseURL   string
+	client    *http.Client
+	token     string
+}
+
+// New creates a new Cloudflare DNS provider with the given API token.
+func New(token string) *Provider {
+	return &Provider{
+		baseURL: defaultBaseURL,
+		client:  http.DefaultClient,
+		token:   token,
+	}
+}
+
+func (p *Provider) Name() string        { return "cloudflare" }
+func (p *Provider) DisplayName() string { return "Cloudflare" }
+
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type apiResponse struct {
+	Success    bool            `json:"success"`
+	Errors     []apiError      `json:"errors"`
+	Result     json.RawMessage `json:"result"`
+	ResultInfo *struct {
+		Page       int `json:"page"`
+		TotalPages int `json:"total_pages"`
+	} `json:"result_info"`
+}
+
+type zone struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type account struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type dnsRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+	Proxied bool   `json:"proxied"`
+}
+
+func (p *Provider) CreateZone(ctx context.Context, domain string) error {
+	accountID := p.AccountID
+
+	if accountID == "" {
+		var accounts []account
+		if _, err := p.request(ctx, http.MethodGet, "/accounts", nil, &accounts); err != nil {
+			return err
+		}
+
+		if len(accounts) != 1 {
+			return fmt.Errorf("could not determine Cloudflare account for new zone %s (found %d accounts). Set the CLOUDFLARE_ACCOUNT_ID environment variable.", domain, len(accounts))
+		}
+
+		accountID = accounts[0].ID
+	}
+
+	body := map[string]any{
+		"name":    domain,
+		"type":    "full",
+		"account": map[string]string{"id": accountID},
+	}
+
+	_, err := p.request(ctx, http.MethodPost, "/zones", body, nil)
+	return err
+}
+
+func (p *Provider) GetZone(ctx context.Context, domain string) (*types.Zone, bool, error) {
+	z, err := p.getZone(ctx, domain)
+	if err != nil {
+		return nil, false, err
+	}
+	if z == nil {
+		return nil, false, nil
+	}
+
+	return &types.Zone{
+		ID:   z.ID,
+		Name: z.Name,
+	}, true, nil
+}
+
+func (p *Provider) CreateRecord(ctx context.Context, domain string, record types.DNSRecord) (*types.DNSRecord, error) {
+	z, err := p.requireZone(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	var result dnsRecord
+	path := fmt.Sprintf("/zones/%s/dns_records", z.ID)
+	if _, err := p.request(ctx, http.MethodPost, path, toAPIRecord(domain, record), &result); err != nil {
+		return nil, err
+	}
+
+	converted := fromAPIRecord(domain, result)
+	return &converted, nil
+}
+
+/*
+UpdateRecord only changes the record's value (and TTL if it's set) so settings
+managed in Cloudflare, like proxying, are kept.
+*/
+func (p *Provider) UpdateRecord(ctx context.Context, domain string, recordID string, record types.DNSRecord) (*types.DNSRecord, error) {
+	z, err := p.requireZone(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]any{"content": record.Value}
+	if record.TTL != 0 {
+		body["ttl"] = record.TTL
+	}
+
+	var result dnsRecord
+	path := fmt.Sprintf("/zones/%s/dns_records/%s", z.ID, recordID)
+	if _, err := p.request(ctx, http.MethodPatch, path, body, &result); err != nil {
+		return nil, err
+	}
+
+	converted := fromAPIRecord(domain, result)
+	return &converted, nil
+}
+
+func (p *Provider) DeleteRecord(ctx context.Context, domain string, recordID string) error {
+	z, err := p.requireZone(ctx, domain)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.request(ctx, http.MethodDelete, fmt.Sprintf("/zones/%s/dns_records/%s", z.ID, recordID), nil, nil)
+	return err
+}
+
+func (p *Provider) ListRecords(ctx context.Context, domain string) ([]types.DNSRecord, error) {
+	z, err := p.getZone(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	if z == nil {
+		return []types.DNSRecord{}, nil
+	}
+
+	result := []types.DNSRecord{}
+
+	for page := 1; ; page++ {
+		var records []dnsRecord
+		path := fmt.Sprintf("/zones/%s/dns_records?per_page=100&page=%d", z.ID, page)
+
+		resp, err := p.request(ctx, http.MethodGet, path, nil, &records)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range records {
+			result = append(result, fromAPIRecord(domain, r))
+		}
+
+		if resp.ResultInfo == nil || page >= resp.ResultInfo.TotalPages {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+func (p *Provider) getZone(ctx context.Context, domain string) (*zone, error) {
+	var zones []zone
+	if _, err := p.request(ctx, http.MethodGet, "/zones?name="+url.QueryEscape(domain), nil, &zones); err != nil {
+		return nil, err
+	}
+
+	if len(zones) == 0 {
+		return nil, nil
+	}
+
+	return &zones[0], nil
+}
+
+func (p *Provider) requireZone(ctx context.Context, domain string) (*zone, error) {
+	z, err := p.getZone(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	if z == nil {
+		return nil, fmt.Errorf("zone %s not found", domain)
+	}
+
+	return z, nil
+}
+
+func (p *Provider) request(ctx context.Context, method string, path string, body any, result any) (*apiResponse, error) {
+	var reqBody io.Reader
+
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	apiResp := &apiResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(apiResp); err != nil {
+		return nil, fmt.Errorf("could not parse Cloudflare API response (HTTP %d): %w", resp.StatusCode, err)
+	}
+
+	if !apiResp.Success {
+		messages := make([]string, len(apiResp.Errors))
+		for i, e := range apiResp.Errors {
+			messages[i] = fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+		}
+
+		return nil, fmt.Errorf("Cloudflare API error (HTTP %d): %s", resp.StatusCode, strings.Join(messages, ", "))
+	}
+
+	if result != nil && len(apiResp.Result) > 0 {
+		if err := json.Unmarshal(apiResp.Result, result); err != nil {
+			return nil, fmt.Errorf("could not parse Cloudflare API result: %w", err)
+		}
+	}
+
+	return apiResp, nil
+}
+
+// Cloudflare uses fully qualified record names while trellis-cli uses names
+// relative to the zone ("@" for the apex).
+func toAPIRecord(domain string, record types.DNSRecord) dnsRecord {
+	ttl := record.TTL
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+
+	name := domain
+	if record.Name != "@" && record.Name != "" {
+		name = record.Name + "." + domain
+	}
+
+	return dnsRecord{
+		Type:    record.Type,
+		Name:    name,
+		Content: record.Value,
+		TTL:     ttl,
+	}
+}
+
+func fromAPIRecord(domain string, r dnsRecord) types.DNSRecord {
+	name := strings.TrimSuffix(r.Name, "."+domain)
+	if r.Name == domain {
+		name = "@"
+	}
+
+	return types.DNSRecord{
+		ID:    r.ID,
+		Type:  r.Type,
+		Name:  name,
+		Value: r.Content,
+		TTL:   r.TTL,
+	}
+}
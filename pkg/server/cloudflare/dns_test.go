@@ -0,0 +1,284 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/roots/trellis-cli/pkg/server/types"
+)
+
+// stubAPI is a minimal in-memory implementation of the Cloudflare API endpoints used by the provider.
+type stubAPI struct {
+	mu       sync.Mutex
+	accounts []account
+	zones    []zone
+	records  map[string][]dnsRecord
+	nextID   int
+}
+
+func (s *stubAPI) respond(w http.ResponseWriter, status int, result any) {
+	data, _ := json.Marshal(result)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success": status < 400,
+		"errors":  []apiError{},
+		"result":  json.RawMessage(data),
+		"result_info": map[string]int{
+			"page":        1,
+			"total_pages": 1,
+		},
+	})
+}
+
+func (s *stubAPI) fail(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success": false,
+		"errors":  []apiError{{Code: 1000, Message: message}},
+	})
+}
+
+func (s *stubAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		s.fail(w, http.StatusForbidden, "Invalid API Token")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/accounts":
+		s.respond(w, http.StatusOK, s.accounts)
+	case r.Method == http.MethodGet && r.URL.Path == "/zones":
+		result := []zone{}
+		for _, z := range s.zones {
+			if z.Name == r.URL.Query().Get("name") {
+				result = append(result, z)
+			}
+		}
+		s.respond(w, http.StatusOK, result)
+	case r.Method == http.MethodPost && r.URL.Path == "/zones":
+		var body struct {
+			Name    string `json:"name"`
+			Account struct {
+				ID string `json:"id"`
+			} `json:"account"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.Account.ID == "" {
+			s.fail(w, http.StatusBadRequest, "account is required")
+			return
+		}
+		s.nextID++
+		z := zone{ID: fmt.Sprintf("zone-%d", s.nextID), Name: body.Name}
+		s.zones = append(s.zones, z)
+		s.respond(w, http.StatusOK, z)
+	case len(parts) >= 3 && parts[0] == "zones" && parts[2] == "dns_records":
+		zoneID := parts[1]
+
+		switch r.Method {
+		case http.MethodGet:
+			s.respond(w, http.StatusOK, s.records[zoneID])
+		case http.MethodPost:
+			var record dnsRecord
+			_ = json.NewDecoder(r.Body).Decode(&record)
+			s.nextID++
+			record.ID = fmt.Sprintf("record-%d", s.nextID)
+			s.records[zoneID] = append(s.records[zoneID], record)
+			s.respond(w, http.StatusOK, record)
+		case http.MethodPatch:
+			for i, existing := range s.records[zoneID] {
+				if existing.ID == parts[3] {
+					// Only the fields in the body are changed
+					_ = json.NewDecoder(r.Body).Decode(&existing)
+					s.records[zoneID][i] = existing
+					s.respond(w, http.StatusOK, existing)
+					return
+				}
+			}
+			s.fail(w, http.StatusNotFound, "Record not found")
+		case http.MethodDelete:
+			for i, existing := range s.records[zoneID] {
+				if existing.ID == parts[3] {
+					s.records[zoneID] = append(s.records[zoneID][:i], s.records[zoneID][i+1:]...)
+					s.respond(w, http.StatusOK, map[string]string{"id": existing.ID})
+					return
+				}
+			}
+			s.fail(w, http.StatusNotFound, "Record not found")
+		}
+	default:
+		s.fail(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func newTestProvider(t *testing.T, api *stubAPI) *Provider {
+	t.Helper()
+
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	p := New("test-token")
+	p.baseURL = srv.URL
+	p.client = srv.Client()
+
+	return p
+}
+
+func TestZones(t *testing.T) {
+	api := &stubAPI{
+		accounts: []account{{ID: "account-1", Name: "Roots"}},
+		records:  map[string][]dnsRecord{},
+	}
+	p := newTestProvider(t, api)
+	ctx := context.Background()
+
+	_, exists, err := p.GetZone(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Fatal("expected zone to not exist")
+	}
+
+	if err := p.CreateZone(ctx, "example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	z, exists, err := p.GetZone(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists || z.Name != "example.com" {
+		t.Errorf("expected example.com zone to exist, got %v", z)
+	}
+}
+
+func TestCreateZoneMultipleAccounts(t *testing.T) {
+	api := &stubAPI{
+		accounts: []account{{ID: "account-1"}, {ID: "account-2"}},
+		records:  map[string][]dnsRecord{},
+	}
+	p := newTestProvider(t, api)
+
+	err := p.CreateZone(context.Background(), "example.com")
+	if err == nil || !strings.Contains(err.Error(), "CLOUDFLARE_ACCOUNT_ID") {
+		t.Fatalf("expected account error, got %v", err)
+	}
+
+	p.AccountID = "account-2"
+
+	if err := p.CreateZone(context.Background(), "example.com"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecords(t *testing.T) {
+	api := &stubAPI{
+		zones: []zone{{ID: "zone-1", Name: "example.com"}},
+		records: map[string][]dnsRecord{
+			"zone-1": {
+				{ID: "existing", Type: "MX", Name: "example.com", Content: "mail.example.com", TTL: 1},
+			},
+		},
+	}
+	p := newTestProvider(t, api)
+	ctx := context.Background()
+
+	created, err := p.CreateRecord(ctx, "example.com", types.DNSRecord{Type: "A", Name: "www", Value: "1.2.3.4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if created.Name != "www" || created.TTL != defaultTTL {
+		t.Errorf("expected created record name www with default TTL, got %+v", created)
+	}
+
+	if api.records["zone-1"][1].Name != "www.example.com" {
+		t.Errorf("expected record to be created with FQDN, got %q", api.records["zone-1"][1].Name)
+	}
+
+	_, err = p.CreateRecord(ctx, "example.com", types.DNSRecord{Type: "A", Name: "@", Value: "1.2.3.4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := p.UpdateRecord(ctx, "example.com", created.ID, types.DNSRecord{Type: "A", Name: "www", Value: "5.6.7.8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Value != "5.6.7.8" {
+		t.Errorf("expected updated record value to be 5.6.7.8, got %q", updated.Value)
+	}
+
+	records, err := p.ListRecords(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []types.DNSRecord{
+		{ID: "existing", Type: "MX", Name: "@", Value: "mail.example.com", TTL: 1},
+		{ID: created.ID, Type: "A", Name: "www", Value: "5.6.7.8", TTL: defaultTTL},
+		{ID: "record-2", Type: "A", Name: "@", Value: "1.2.3.4", TTL: defaultTTL},
+	}
+
+	if fmt.Sprint(records) != fmt.Sprint(expected) {
+		t.Errorf("expected records %v, got %v", expected, records)
+	}
+
+	if err := p.DeleteRecord(ctx, "example.com", created.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(api.records["zone-1"]) != 2 {
+		t.Errorf("expected record to be deleted, got %v", api.records["zone-1"])
+	}
+
+	err = p.DeleteRecord(ctx, "example.com", "missing")
+	if err == nil || !strings.Contains(err.Error(), "Record not found") {
+		t.Errorf("expected API error, got %v", err)
+	}
+}
+
+func TestUpdateRecordKeepsProxied(t *testing.T) {
+	api := &stubAPI{
+		zones: []zone{{ID: "zone-1", Name: "example.com"}},
+		records: map[string][]dnsRecord{
+			"zone-1": {
+				{ID: "proxied", Type: "A", Name: "example.com", Content: "1.2.3.4", TTL: 1, Proxied: true},
+			},
+		},
+	}
+	p := newTestProvider(t, api)
+
+	_, err := p.UpdateRecord(context.Background(), "example.com", "proxied", types.DNSRecord{Type: "A", Name: "@", Value: "5.6.7.8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := dnsRecord{ID: "proxied", Type: "A", Name: "example.com", Content: "5.6.7.8", TTL: 1, Proxied: true}
+	if api.records["zone-1"][0] != expected {
+		t.Errorf("expected record %+v, got %+v", expected, api.records["zone-1"][0])
+	}
+}
+
+func TestInvalidToken(t *testing.T) {
+	p := newTestProvider(t, &stubAPI{})
+	p.token = "wrong"
+
+	_, _, err := p.GetZone(context.Background(), "example.com")
+	expected := "Cloudflare API error (HTTP 403): Invalid API Token (code 1000)"
+
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
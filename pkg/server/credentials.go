@@ -0,0 +1,215 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/roots/trellis-cli/app_paths"
+	"github.com/roots/trellis-cli/command"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultProfile is the credentials profile used when none is configured.
+const DefaultProfile = "default"
+
+/*
+Credentials are provider API tokens grouped into named profiles (eg: an agency
+account and a client account). They're stored globally in a YAML file which
+must only be readable by the current user:
+
+	profiles:
+	  agency:
+	    digitalocean: dop_v1_xxx
+	    cloudflare: xxx
+	  client:
+	    hetzner: xxx
+*/
+type Credentials struct {
+	Profiles map[string]map[ProviderName]string `yaml:"profiles"`
+}
+
+// TokenSource configures where GetProviderToken looks for tokens besides the environment.
+type TokenSource struct {
+	// Profile is the credentials profile to use (defaults to DefaultProfile).
+	Profile string
+	// Helper is a shell command which prints a token. TRELLIS_PROVIDER and
+	// TRELLIS_PROFILE are set in its environment.
+	Helper string
+	// CredentialsPath defaults to CredentialsPath().
+	CredentialsPath string
+}
+
+func CredentialsPath() string {
+	return app_paths.ConfigPath("credentials.yml")
+}
+
+// LoadCredentials reads a credentials file. A missing file results in empty credentials.
+func LoadCredentials(path string) (*Credentials, error) {
+	credentials := &Credentials{Profiles: map[string]map[ProviderName]string{}}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return credentials, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("credentials file %s is accessible by other users (mode %04o). Restrict it with: chmod 600 %s", path, info.Mode().Perm(), path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.UnmarshalStrict(content, credentials); err != nil {
+		return nil, fmt.Errorf("could not parse credentials file %s: %w", path, err)
+	}
+
+	if credentials.Profiles == nil {
+		credentials.Profiles = map[string]map[ProviderName]string{}
+	}
+
+	return credentials, nil
+}
+
+// Save writes the credentials file so it's only readable by the current user.
+func (c *Credentials) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	content, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return err
+	}
+
+	// WriteFile doesn't change the mode of existing files
+	return os.Chmod(path, 0600)
+}
+
+func (c *Credentials) Token(profile string, provider ProviderName) string {
+	return c.Profiles[profile][provider]
+}
+
+func (c *Credentials) SetToken(profile string, provider ProviderName, token string) {
+	if c.Profiles[profile] == nil {
+		c.Profiles[profile] = map[ProviderName]string{}
+	}
+
+	c.Profiles[profile][provider] = token
+}
+
+// Remove deletes a provider's token from a profile, or the whole profile if provider is empty.
+// It returns false if there was nothing to remove.
+func (c *Credentials) Remove(profile string, provider ProviderName) bool {
+	tokens, ok := c.Profiles[profile]
+	if !ok {
+		return false
+	}
+
+	if provider == "" {
+		delete(c.Profiles, profile)
+		return true
+	}
+
+	if _, ok := tokens[provider]; !ok {
+		return false
+	}
+
+	delete(tokens, provider)
+
+	if len(tokens) == 0 {
+		delete(c.Profiles, profile)
+	}
+
+	return true
+}
+
+func (c *Credentials) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ProfileProviders returns the names of the providers with a token in a profile.
+func (c *Credentials) ProfileProviders(profile string) []string {
+	providers := []string{}
+	for provider := range c.Profiles[profile] {
+		providers = append(providers, string(provider))
+	}
+	sort.Strings(providers)
+
+	return providers
+}
+
+// TokenEnvVar returns the environment variable a provider's token is read from.
+func TokenEnvVar(provider ProviderName) string {
+	return tokenEnvVars[provider]
+}
+
+/*
+LookupProviderToken finds a provider's API token without prompting. Sources are
+checked in order:
+
+ 1. the provider's environment variable (eg: HCLOUD_TOKEN)
+ 2. the credential helper command
+ 3. the profile in the credentials file
+
+An empty token is returned if none of them have one.
+*/
+func LookupProviderToken(provider ProviderName, source TokenSource) (string, error) {
+	if token := os.Getenv(tokenEnvVars[provider]); token != "" {
+		return token, nil
+	}
+
+	profile := source.Profile
+	if profile == "" {
+		profile = DefaultProfile
+	}
+
+	if source.Helper != "" {
+		return runCredentialHelper(source.Helper, provider, profile)
+	}
+
+	path := source.CredentialsPath
+	if path == "" {
+		path = CredentialsPath()
+	}
+
+	credentials, err := LoadCredentials(path)
+	if err != nil {
+		return "", err
+	}
+
+	return credentials.Token(profile, provider), nil
+}
+
+func runCredentialHelper(helper string, provider ProviderName, profile string) (string, error) {
+	cmd := command.Cmd("sh", []string{"-c", helper})
+	cmd.Env = append(os.Environ(), "TRELLIS_PROVIDER="+string(provider), "TRELLIS_PROFILE="+profile)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("credential helper failed: %w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
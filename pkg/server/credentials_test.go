@@ -0,0 +1,127 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCredentialsSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trellis", "credentials.yml")
+
+	credentials, err := LoadCredentials(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credentials.SetToken("agency", ProviderDigitalOcean, "do-token")
+	credentials.SetToken("agency", ProviderCloudflare, "cf-token")
+	credentials.SetToken("client", ProviderHetzner, "hetzner-token")
+
+	if err := credentials.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected credentials file mode 0600, got %04o", info.Mode().Perm())
+	}
+
+	loaded, err := LoadCredentials(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(loaded.ProfileNames(), []string{"agency", "client"}) {
+		t.Errorf("expected agency and client profiles, got %v", loaded.ProfileNames())
+	}
+
+	if !reflect.DeepEqual(loaded.ProfileProviders("agency"), []string{"cloudflare", "digitalocean"}) {
+		t.Errorf("expected agency providers, got %v", loaded.ProfileProviders("agency"))
+	}
+
+	if token := loaded.Token("client", ProviderHetzner); token != "hetzner-token" {
+		t.Errorf("expected hetzner-token, got %q", token)
+	}
+
+	if !loaded.Remove("agency", ProviderCloudflare) || loaded.Token("agency", ProviderCloudflare) != "" {
+		t.Error("expected cloudflare token to be removed")
+	}
+
+	if loaded.Remove("agency", ProviderHetzner) {
+		t.Error("expected removing a missing token to return false")
+	}
+
+	if !loaded.Remove("client", "") || loaded.Remove("client", "") {
+		t.Error("expected client profile to be removed once")
+	}
+}
+
+func TestLoadCredentialsInsecurePermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.yml")
+
+	if err := os.WriteFile(path, []byte("profiles: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadCredentials(path)
+	if err == nil || !strings.Contains(err.Error(), "chmod 600") {
+		t.Errorf("expected permissions error, got %v", err)
+	}
+}
+
+func TestLookupProviderToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.yml")
+
+	credentials, _ := LoadCredentials(path)
+	credentials.SetToken(DefaultProfile, ProviderHetzner, "default-token")
+	credentials.SetToken("client", ProviderHetzner, "client-token")
+	if err := credentials.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("HCLOUD_TOKEN", "")
+
+	cases := []struct {
+		name   string
+		env    string
+		source TokenSource
+		want   string
+	}{
+		{"default_profile", "", TokenSource{CredentialsPath: path}, "default-token"},
+		{"named_profile", "", TokenSource{Profile: "client", CredentialsPath: path}, "client-token"},
+		{"missing_profile", "", TokenSource{Profile: "other", CredentialsPath: path}, ""},
+		{"env_takes_precedence", "env-token", TokenSource{Profile: "client", CredentialsPath: path}, "env-token"},
+		{"helper", "", TokenSource{Profile: "client", Helper: "echo $TRELLIS_PROVIDER-$TRELLIS_PROFILE", CredentialsPath: path}, "hetzner-client"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("HCLOUD_TOKEN", tc.env)
+
+			token, err := LookupProviderToken(ProviderHetzner, tc.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if token != tc.want {
+				t.Errorf("expected token %q, got %q", tc.want, token)
+			}
+		})
+	}
+}
+
+func TestLookupProviderTokenHelperFailure(t *testing.T) {
+	t.Setenv("HCLOUD_TOKEN", "")
+
+	_, err := LookupProviderToken(ProviderHetzner, TokenSource{Helper: "echo nope >&2; exit 1"})
+	if err == nil || !strings.Contains(err.Error(), "credential helper failed") || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("expected helper error, got %v", err)
+	}
+}
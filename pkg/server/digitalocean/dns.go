@@ -59,6 +59,38 @@ func (p *Provider) CreateRecord(ctx context.Context, domain string, record types
 	}, nil
 }
 
+func (p *Provider) UpdateRecord(ctx context.Context, domain string, recordID string, record types.DNSRecord) (*types.DNSRecord, error) {
+	id, err := strconv.Atoi(recordID)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := record.TTL
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+
+	req := &godo.DomainRecordEditRequest{
+		Type: record.Type,
+		Name: record.Name,
+		Data: record.Value,
+		TTL:  ttl,
+	}
+
+	r, _, err := p.client.Domains.EditRecord(ctx, domain, id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.DNSRecord{
+		ID:    strconv.Itoa(r.ID),
+		Type:  r.Type,
+		Name:  r.Name,
+		Value: r.Data,
+		TTL:   r.TTL,
+	}, nil
+}
+
 func (p *Provider) DeleteRecord(ctx context.Context, domain string, recordID string) error {
 	id, err := strconv.Atoi(recordID)
 	if err != nil {
@@ -0,0 +1,75 @@
+package digitalocean
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/digitalocean/godo"
+	"github.com/roots/trellis-cli/pkg/server/types"
+)
+
+var allAddresses = []string{"0.0.0.0/0", "::/0"}
+
+// ensureFirewall returns the named firewall, creating it first if it doesn't
+// exist yet. Existing firewall rules are left as-is.
+func (p *Provider) ensureFirewall(ctx context.Context, fw *types.Firewall) (*godo.Firewall, error) {
+	existing, err := p.getFirewallByName(ctx, fw.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		return existing, nil
+	}
+
+	inbound := make([]godo.InboundRule, len(fw.AllowedPorts))
+	for i, port := range fw.AllowedPorts {
+		inbound[i] = godo.InboundRule{
+			Protocol:  "tcp",
+			PortRange: strconv.Itoa(port),
+			Sources:   &godo.Sources{Addresses: allAddresses},
+		}
+	}
+
+	outbound := []godo.OutboundRule{
+		{Protocol: "tcp", PortRange: "all", Destinations: &godo.Destinations{Addresses: allAddresses}},
+		{Protocol: "udp", PortRange: "all", Destinations: &godo.Destinations{Addresses: allAddresses}},
+		{Protocol: "icmp", Destinations: &godo.Destinations{Addresses: allAddresses}},
+	}
+
+	// Droplets are attached by ID; firewall tags would apply it to every droplet with the tag
+	firewall, _, err := p.client.Firewalls.Create(ctx, &godo.FirewallRequest{
+		Name:          fw.Name,
+		InboundRules:  inbound,
+		OutboundRules: outbound,
+	})
+
+	return firewall, err
+}
+
+func (p *Provider) getFirewallByName(ctx context.Context, name string) (*godo.Firewall, error) {
+	opts := &godo.ListOptions{Page: 1, PerPage: 100}
+
+	for {
+		firewalls, resp, err := p.client.Firewalls.List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, fw := range firewalls {
+			if fw.Name == name {
+				return &fw, nil
+			}
+		}
+
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			return nil, nil
+		}
+
+		page, err := resp.Links.CurrentPage()
+		if err != nil {
+			return nil, err
+		}
+		opts.Page = page + 1
+	}
+}
@@ -15,7 +15,7 @@ import (
 	"golang.org/x/oauth2"
 )
 
-const baseTag = "trellis"
+const baseTag = types.TrellisTag
 
 // Provider implements the types.Provider interface for DigitalOcean.
 type Provider struct {
@@ -43,13 +43,24 @@ func (p *Provider) CreateServer(ctx context.Context, opts types.CreateServerOpti
 		sshKeys[i] = godo.DropletCreateSSHKey{Fingerprint: id}
 	}
 
+	// The firewall is set up first so a failure doesn't leave behind a droplet
+	var firewall *godo.Firewall
+	if opts.Firewall != nil {
+		fw, err := p.ensureFirewall(ctx, opts.Firewall)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create firewall %s: %w", opts.Firewall.Name, err)
+		}
+		firewall = fw
+	}
+
 	req := &godo.DropletCreateRequest{
-		Name:    opts.Name,
-		Region:  opts.Region,
-		Size:    opts.Size,
-		Image:   godo.DropletCreateImage{Slug: opts.Image},
-		SSHKeys: sshKeys,
-		Tags:    tags,
+		Name:     opts.Name,
+		Region:   opts.Region,
+		Size:     opts.Size,
+		Image:    godo.DropletCreateImage{Slug: opts.Image},
+		SSHKeys:  sshKeys,
+		Tags:     tags,
+		UserData: opts.UserData,
 	}
 
 	droplet, resp, err := p.client.Droplets.Create(ctx, req)
@@ -63,6 +74,12 @@ func (p *Provider) CreateServer(ctx context.Context, opts types.CreateServerOpti
 		srv.ID = fmt.Sprintf("%d:%s", droplet.ID, resp.Links.Actions[0].HREF)
 	}
 
+	if firewall != nil {
+		if _, err := p.client.Firewalls.AddDroplets(ctx, firewall.ID, droplet.ID); err != nil {
+			return srv, fmt.Errorf("could not attach firewall %s: %w", opts.Firewall.Name, err)
+		}
+	}
+
 	return srv, nil
 }
 
@@ -185,6 +202,49 @@ func (p *Provider) GetSizes(ctx context.Context, region string) ([]types.Size, e
 	return result, nil
 }
 
+// GetImages returns the public distribution images (eg: Ubuntu).
+func (p *Provider) GetImages(ctx context.Context) ([]types.Image, error) {
+	opts := &godo.ListOptions{Page: 1, PerPage: 100}
+	result := []types.Image{}
+
+	for {
+		images, resp, err := p.client.Images.ListDistribution(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, img := range images {
+			if img.Slug == "" || !img.Public || img.Status == "deleted" {
+				continue
+			}
+
+			result = append(result, types.Image{
+				Slug:         img.Slug,
+				Name:         fmt.Sprintf("%s %s", img.Distribution, img.Name),
+				Distribution: img.Distribution,
+				Architecture: "x86_64",
+				MinDiskSize:  img.MinDiskSize,
+			})
+		}
+
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			break
+		}
+
+		page, err := resp.Links.CurrentPage()
+		if err != nil {
+			return nil, err
+		}
+		opts.Page = page + 1
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Slug < result[j].Slug
+	})
+
+	return result, nil
+}
+
 func sizeInRegion(regions []string, region string) bool {
 	return slices.Contains(regions, region)
 }
@@ -252,6 +312,7 @@ func (p *Provider) dropletToServer(d *godo.Droplet) *types.Server {
 		Size:         size,
 		CreatedAt:    createdAt,
 		DashboardURL: fmt.Sprintf("https://cloud.digitalocean.com/droplets/%d", d.ID),
+		Tags:         types.ParseTags(d.Tags),
 	}
 }
 
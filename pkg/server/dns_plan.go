@@ -0,0 +1,224 @@
+package server
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sort"
+)
+
+// ManagedRecordTypes are the DNS record types trellis-cli creates and reconciles.
+// Records of any other type (MX, TXT, NS, etc) are never changed.
+var ManagedRecordTypes = []string{"A", "AAAA"}
+
+type DNSChangeAction string
+
+const (
+	DNSChangeCreate DNSChangeAction = "create"
+	DNSChangeUpdate DNSChangeAction = "update"
+	DNSChangeDelete DNSChangeAction = "delete"
+)
+
+// DNSChange is a single planned change to a DNS record.
+// Record is the desired record for creates and updates, and the existing record for deletes.
+// Current is the existing record being replaced by an update.
+type DNSChange struct {
+	Action  DNSChangeAction
+	Domain  string
+	Record  DNSRecord
+	Current *DNSRecord
+}
+
+// DNSPlan contains the zones and records to change to reconcile a provider's DNS with the desired records.
+type DNSPlan struct {
+	CreateZones []string
+	Changes     []DNSChange
+}
+
+func (p *DNSPlan) Empty() bool {
+	return len(p.CreateZones) == 0 && len(p.Changes) == 0
+}
+
+func (p *DNSPlan) Count(action DNSChangeAction) int {
+	count := 0
+	for _, change := range p.Changes {
+		if change.Action == action {
+			count++
+		}
+	}
+
+	return count
+}
+
+/*
+PlanDNS compares the desired records for each domain against the records that
+currently exist with the provider and returns the changes needed.
+
+Only managed record types (A and AAAA) are considered. Existing records which
+aren't desired (eg: other servers' records for the same host) are only deleted
+when prune is true, and only for the record types in the desired records.
+*/
+func PlanDNS(ctx context.Context, provider DNSProvider, desired map[string][]DNSRecord, prune bool) (*DNSPlan, error) {
+	plan := &DNSPlan{}
+
+	domains := make([]string, 0, len(desired))
+	for domain := range desired {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	for _, domain := range domains {
+		_, exists, err := provider.GetZone(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+
+		existing := []DNSRecord{}
+
+		if exists {
+			existing, err = provider.ListRecords(ctx, domain)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			plan.CreateZones = append(plan.CreateZones, domain)
+		}
+
+		plan.Changes = append(plan.Changes, DiffDNSRecords(domain, desired[domain], existing, prune)...)
+	}
+
+	return plan, nil
+}
+
+/*
+DiffDNSRecords returns the changes needed in a single domain to go from the
+existing to the desired records.
+
+Without prune, a host which already has a record with the desired value is left
+as-is even if it has other records too (eg: round-robin DNS across servers). A
+host with a single record of the type has it updated; a host with several gets
+a new record alongside them so none are dropped from rotation.
+*/
+func DiffDNSRecords(domain string, desired []DNSRecord, existing []DNSRecord, prune bool) []DNSChange {
+	changes := []DNSChange{}
+	desiredKeys := map[string]bool{}
+	desiredTypes := []string{}
+
+	for _, record := range desired {
+		key := recordKey(record)
+		desiredKeys[key] = true
+
+		if slices.Contains(ManagedRecordTypes, record.Type) && !slices.Contains(desiredTypes, record.Type) {
+			desiredTypes = append(desiredTypes, record.Type)
+		}
+
+		current := []DNSRecord{}
+		for _, r := range existing {
+			if recordKey(r) == key {
+				current = append(current, r)
+			}
+		}
+
+		if len(current) == 0 {
+			changes = append(changes, DNSChange{Action: DNSChangeCreate, Domain: domain, Record: record})
+			continue
+		}
+
+		// Keep the record which already has the desired value if there is one.
+		keeper := current[0]
+		for _, r := range current {
+			if sameRecordValue(r.Type, r.Value, record.Value) {
+				keeper = r
+				break
+			}
+		}
+
+		needsUpdate := !sameRecordValue(keeper.Type, keeper.Value, record.Value)
+
+		if !prune {
+			if needsUpdate && len(current) > 1 {
+				changes = append(changes, DNSChange{Action: DNSChangeCreate, Domain: domain, Record: record})
+			} else if needsUpdate {
+				record.ID = keeper.ID
+				current := keeper
+				changes = append(changes, DNSChange{Action: DNSChangeUpdate, Domain: domain, Record: record, Current: &current})
+			}
+			continue
+		}
+
+		deletedIDs := []string{}
+
+		for _, r := range current {
+			if r == keeper {
+				continue
+			}
+
+			// Some providers (eg: Hetzner) group multiple values under a single
+			// record ID; updating that record replaces all of its values.
+			if r.ID == keeper.ID {
+				needsUpdate = true
+				continue
+			}
+
+			if !slices.Contains(deletedIDs, r.ID) {
+				deletedIDs = append(deletedIDs, r.ID)
+				changes = append(changes, DNSChange{Action: DNSChangeDelete, Domain: domain, Record: r})
+			}
+		}
+
+		if needsUpdate {
+			record.ID = keeper.ID
+			current := keeper
+			changes = append(changes, DNSChange{Action: DNSChangeUpdate, Domain: domain, Record: record, Current: &current})
+		}
+	}
+
+	if prune {
+		prunedIDs := []string{}
+
+		for _, r := range existing {
+			if !slices.Contains(desiredTypes, r.Type) || desiredKeys[recordKey(r)] || slices.Contains(prunedIDs, r.ID) {
+				continue
+			}
+
+			prunedIDs = append(prunedIDs, r.ID)
+			changes = append(changes, DNSChange{Action: DNSChangeDelete, Domain: domain, Record: r})
+		}
+	}
+
+	return changes
+}
+
+func recordKey(r DNSRecord) string {
+	return r.Type + " " + r.Name
+}
+
+// sameRecordValue compares IP addresses by value so differently formatted IPv6 addresses are equal.
+func sameRecordValue(recordType string, a string, b string) bool {
+	if recordType == "A" || recordType == "AAAA" {
+		ipA := net.ParseIP(a)
+		ipB := net.ParseIP(b)
+
+		if ipA != nil && ipB != nil {
+			return ipA.Equal(ipB)
+		}
+	}
+
+	return a == b
+}
+
+// ApplyDNSChange applies a single planned change with the provider.
+func ApplyDNSChange(ctx context.Context, provider DNSProvider, change DNSChange) error {
+	var err error
+
+	switch change.Action {
+	case DNSChangeCreate:
+		_, err = provider.CreateRecord(ctx, change.Domain, change.Record)
+	case DNSChangeUpdate:
+		_, err = provider.UpdateRecord(ctx, change.Domain, change.Record.ID, change.Record)
+	case DNSChangeDelete:
+		err = provider.DeleteRecord(ctx, change.Domain, change.Record.ID)
+	}
+
+	return err
+}
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type mockDNSProvider struct {
+	zones   map[string][]DNSRecord
+	created []DNSRecord
+	updated []DNSRecord
+	deleted []string
+}
+
+func (m *mockDNSProvider) CreateZone(ctx context.Context, domain string) error {
+	m.zones[domain] = []DNSRecord{}
+	return nil
+}
+
+func (m *mockDNSProvider) GetZone(ctx context.Context, domain string) (*Zone, bool, error) {
+	if _, ok := m.zones[domain]; !ok {
+		return nil, false, nil
+	}
+	return &Zone{Name: domain}, true, nil
+}
+
+func (m *mockDNSProvider) CreateRecord(ctx context.Context, domain string, record DNSRecord) (*DNSRecord, error) {
+	m.created = append(m.created, record)
+	return &record, nil
+}
+
+func (m *mockDNSProvider) UpdateRecord(ctx context.Context, domain string, recordID string, record DNSRecord) (*DNSRecord, error) {
+	m.updated = append(m.updated, record)
+	return &record, nil
+}
+
+func (m *mockDNSProvider) DeleteRecord(ctx context.Context, domain string, recordID string) error {
+	m.deleted = append(m.deleted, recordID)
+	return nil
+}
+
+func (m *mockDNSProvider) ListRecords(ctx context.Context, domain string) ([]DNSRecord, error) {
+	return m.zones[domain], nil
+}
+
+func TestDiffDNSRecords(t *testing.T) {
+	desired := []DNSRecord{
+		{Type: "A", Name: "@", Value: "1.2.3.4"},
+		{Type: "A", Name: "www", Value: "1.2.3.4"},
+		{Type: "AAAA", Name: "@", Value: "2001:db8::1"},
+	}
+
+	cases := []struct {
+		name     string
+		existing []DNSRecord
+		prune    bool
+		expected []DNSChange
+	}{
+		{
+			"no_existing_records",
+			[]DNSRecord{},
+			false,
+			[]DNSChange{
+				{Action: DNSChangeCreate, Domain: "example.com", Record: desired[0]},
+				{Action: DNSChangeCreate, Domain: "example.com", Record: desired[1]},
+				{Action: DNSChangeCreate, Domain: "example.com", Record: desired[2]},
+			},
+		},
+		{
+			"up_to_date",
+			[]DNSRecord{
+				{ID: "1", Type: "A", Name: "@", Value: "1.2.3.4"},
+				{ID: "2", Type: "A", Name: "www", Value: "1.2.3.4"},
+				{ID: "3", Type: "AAAA", Name: "@", Value: "2001:0db8:0000::0001"},
+				{ID: "4", Type: "MX", Name: "@", Value: "mail.example.com"},
+			},
+			true,
+			[]DNSChange{},
+		},
+		{
+			"update_and_unmanaged",
+			[]DNSRecord{
+				{ID: "1", Type: "A", Name: "@", Value: "5.6.7.8"},
+				{ID: "2", Type: "A", Name: "www", Value: "1.2.3.4"},
+				{ID: "3", Type: "AAAA", Name: "@", Value: "2001:db8::1"},
+				{ID: "4", Type: "A", Name: "mail", Value: "9.9.9.9"},
+			},
+			false,
+			[]DNSChange{
+				{
+					Action:  DNSChangeUpdate,
+					Domain:  "example.com",
+					Record:  DNSRecord{ID: "1", Type: "A", Name: "@", Value: "1.2.3.4"},
+					Current: &DNSRecord{ID: "1", Type: "A", Name: "@", Value: "5.6.7.8"},
+				},
+			},
+		},
+		{
+			"prune",
+			[]DNSRecord{
+				{ID: "1", Type: "A", Name: "@", Value: "1.2.3.4"},
+				{ID: "2", Type: "A", Name: "www", Value: "1.2.3.4"},
+				{ID: "3", Type: "AAAA", Name: "@", Value: "2001:db8::1"},
+				{ID: "4", Type: "A", Name: "mail", Value: "9.9.9.9"},
+				{ID: "5", Type: "TXT", Name: "@", Value: "v=spf1"},
+			},
+			true,
+			[]DNSChange{
+				{Action: DNSChangeDelete, Domain: "example.com", Record: DNSRecord{ID: "4", Type: "A", Name: "mail", Value: "9.9.9.9"}},
+			},
+		},
+		{
+			"duplicate_records_kept",
+			[]DNSRecord{
+				{ID: "1", Type: "A", Name: "@", Value: "5.6.7.8"},
+				{ID: "2", Type: "A", Name: "@", Value: "1.2.3.4"},
+				{ID: "3", Type: "A", Name: "www", Value: "1.2.3.4"},
+				{ID: "4", Type: "AAAA", Name: "@", Value: "2001:db8::1"},
+			},
+			false,
+			[]DNSChange{},
+		},
+		{
+			"duplicate_records_pruned",
+			[]DNSRecord{
+				{ID: "1", Type: "A", Name: "@", Value: "5.6.7.8"},
+				{ID: "2", Type: "A", Name: "@", Value: "1.2.3.4"},
+				{ID: "3", Type: "A", Name: "www", Value: "1.2.3.4"},
+				{ID: "4", Type: "AAAA", Name: "@", Value: "2001:db8::1"},
+			},
+			true,
+			[]DNSChange{
+				{Action: DNSChangeDelete, Domain: "example.com", Record: DNSRecord{ID: "1", Type: "A", Name: "@", Value: "5.6.7.8"}},
+			},
+		},
+		{
+			"grouped_record_values_kept",
+			[]DNSRecord{
+				{ID: "rrset-1", Type: "A", Name: "@", Value: "5.6.7.8"},
+				{ID: "rrset-1", Type: "A", Name: "@", Value: "1.2.3.4"},
+				{ID: "rrset-2", Type: "A", Name: "www", Value: "1.2.3.4"},
+				{ID: "rrset-3", Type: "AAAA", Name: "@", Value: "2001:db8::1"},
+			},
+			false,
+			[]DNSChange{},
+		},
+		{
+			"round_robin_records_kept",
+			[]DNSRecord{
+				{ID: "1", Type: "A", Name: "@", Value: "5.6.7.8"},
+				{ID: "2", Type: "A", Name: "@", Value: "9.9.9.9"},
+				{ID: "3", Type: "A", Name: "www", Value: "1.2.3.4"},
+				{ID: "4", Type: "AAAA", Name: "@", Value: "2001:db8::1"},
+			},
+			false,
+			[]DNSChange{
+				{Action: DNSChangeCreate, Domain: "example.com", Record: desired[0]},
+			},
+		},
+		{
+			"grouped_record_values_pruned",
+			[]DNSRecord{
+				{ID: "rrset-1", Type: "A", Name: "@", Value: "5.6.7.8"},
+				{ID: "rrset-1", Type: "A", Name: "@", Value: "1.2.3.4"},
+				{ID: "rrset-2", Type: "A", Name: "www", Value: "1.2.3.4"},
+				{ID: "rrset-3", Type: "AAAA", Name: "@", Value: "2001:db8::1"},
+			},
+			true,
+			[]DNSChange{
+				{
+					Action:  DNSChangeUpdate,
+					Domain:  "example.com",
+					Record:  DNSRecord{ID: "rrset-1", Type: "A", Name: "@", Value: "1.2.3.4"},
+					Current: &DNSRecord{ID: "rrset-1", Type: "A", Name: "@", Value: "1.2.3.4"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			changes := DiffDNSRecords("example.com", desired, tc.existing, tc.prune)
+
+			if !reflect.DeepEqual(changes, tc.expected) {
+				t.Errorf("expected changes\n%+v\ngot\n%+v", tc.expected, changes)
+			}
+		})
+	}
+}
+
+func TestDiffDNSRecordsPruneOnlyDesiredTypes(t *testing.T) {
+	desired := []DNSRecord{
+		{Type: "A", Name: "@", Value: "1.2.3.4"},
+	}
+
+	existing := []DNSRecord{
+		{ID: "1", Type: "A", Name: "@", Value: "1.2.3.4"},
+		{ID: "2", Type: "A", Name: "old", Value: "5.6.7.8"},
+		{ID: "3", Type: "AAAA", Name: "@", Value: "2001:db8::1"},
+	}
+
+	changes := DiffDNSRecords("example.com", desired, existing, true)
+
+	expected := []DNSChange{
+		{Action: DNSChangeDelete, Domain: "example.com", Record: DNSRecord{ID: "2", Type: "A", Name: "old", Value: "5.6.7.8"}},
+	}
+
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected changes\n%+v\ngot\n%+v", expected, changes)
+	}
+}
+
+func TestPlanDNS(t *testing.T) {
+	provider := &mockDNSProvider{
+		zones: map[string][]DNSRecord{
+			"example.com": {
+				{ID: "1", Type: "A", Name: "@", Value: "5.6.7.8"},
+			},
+		},
+	}
+
+	desired := map[string][]DNSRecord{
+		"example.com": {{Type: "A", Name: "@", Value: "1.2.3.4"}},
+		"example.org": {{Type: "A", Name: "@", Value: "1.2.3.4"}},
+	}
+
+	ctx := context.Background()
+
+	plan, err := PlanDNS(ctx, provider, desired, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(plan.CreateZones, []string{"example.org"}) {
+		t.Errorf("expected example.org zone to be created, got %v", plan.CreateZones)
+	}
+
+	if plan.Count(DNSChangeCreate) != 1 || plan.Count(DNSChangeUpdate) != 1 || plan.Count(DNSChangeDelete) != 0 {
+		t.Errorf("expected 1 create and 1 update, got %+v", plan.Changes)
+	}
+
+	for _, change := range plan.Changes {
+		if err := ApplyDNSChange(ctx, provider, change); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(provider.created) != 1 || len(provider.updated) != 1 || provider.updated[0].ID != "1" {
+		t.Errorf("expected changes to be applied, got created=%v updated=%v", provider.created, provider.updated)
+	}
+}
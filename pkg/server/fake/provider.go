@@ -0,0 +1,435 @@
+/*
+Package fake implements an in-process cloud provider which stores its servers,
+SSH keys, and DNS zones in a local JSON file. It's meant for tests, demos, and
+building against the provider interfaces without a cloud account.
+
+Servers are "running" as soon as they're created and get addresses from the
+documentation ranges (203.0.113.0/24 and 2001:db8::/32) so nothing is reachable.
+*/
+package fake
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/roots/trellis-cli/pkg/server/types"
+	"golang.org/x/crypto/ssh"
+)
+
+// Provider implements types.Provider and types.DNSProvider backed by a JSON state file.
+type Provider struct {
+	path string
+	mu   sync.Mutex
+}
+
+type state struct {
+	NextID  int                  `json:"next_id"`
+	NextIP  int                  `json:"next_ip"`
+	Servers []fakeServer         `json:"servers"`
+	SSHKeys []types.SSHKey       `json:"ssh_keys"`
+	Zones   map[string]*fakeZone `json:"zones"`
+}
+
+type fakeServer struct {
+	types.Server
+	SSHKeyIDs []string        `json:"ssh_key_ids"`
+	Firewall  *types.Firewall `json:"firewall,omitempty"`
+	HostKey   string          `json:"host_key"`
+}
+
+type fakeZone struct {
+	Name    string            `json:"name"`
+	Records []types.DNSRecord `json:"records"`
+}
+
+// New creates a fake provider which stores its state at path (created on first write).
+func New(path string) *Provider {
+	return &Provider{path: path}
+}
+
+func (p *Provider) Name() string        { return "fake" }
+func (p *Provider) DisplayName() string { return "Fake" }
+
+// Path returns the location of the provider's state file.
+func (p *Provider) Path() string {
+	return p.path
+}
+
+func (p *Provider) load() (*state, error) {
+	s := &state{Servers: []fakeServer{}, SSHKeys: []types.SSHKey{}, Zones: map[string]*fakeZone{}}
+
+	content, err := os.ReadFile(p.path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, s); err != nil {
+		return nil, fmt.Errorf("could not parse fake provider state %s: %w", p.path, err)
+	}
+
+	if s.Zones == nil {
+		s.Zones = map[string]*fakeZone{}
+	}
+
+	return s, nil
+}
+
+func (p *Provider) save(s *state) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(p.path, content, 0644)
+}
+
+// update loads the state, applies fn, and saves the state if fn succeeds.
+func (p *Provider) update(fn func(s *state) error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, err := p.load()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(s); err != nil {
+		return err
+	}
+
+	return p.save(s)
+}
+
+func (p *Provider) read() (*state, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.load()
+}
+
+func (s *state) nextID() string {
+	s.NextID++
+	return strconv.Itoa(s.NextID)
+}
+
+func (p *Provider) CreateServer(ctx context.Context, opts types.CreateServerOptions) (*types.Server, error) {
+	var created types.Server
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.update(func(s *state) error {
+		for _, srv := range s.Servers {
+			if srv.Name == opts.Name {
+				return fmt.Errorf("server %s already exists", opts.Name)
+			}
+		}
+
+		if s.NextIP >= 254 {
+			return fmt.Errorf("fake provider is limited to 254 servers")
+		}
+
+		s.NextIP++
+		n := s.NextIP
+		id := s.nextID()
+
+		tags := map[string]string{"type": "trellis"}
+		maps.Copy(tags, opts.Tags)
+
+		srv := fakeServer{
+			Server: types.Server{
+				ID:         id,
+				Name:       opts.Name,
+				Status:     types.ServerStatusRunning,
+				PublicIPv4: fmt.Sprintf("203.0.113.%d", n),
+				PublicIPv6: fmt.Sprintf("2001:db8::%x", n),
+				Region:     opts.Region,
+				Size:       opts.Size,
+				Image:      opts.Image,
+				CreatedAt:  time.Now().UTC().Truncate(time.Second),
+				Tags:       tags,
+			},
+			SSHKeyIDs: opts.SSHKeyIDs,
+			Firewall:  opts.Firewall,
+			HostKey:   string(ssh.MarshalAuthorizedKey(signer.PublicKey())),
+		}
+
+		s.Servers = append(s.Servers, srv)
+		created = srv.Server
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (p *Provider) GetServer(ctx context.Context, id string) (*types.Server, error) {
+	srv, err := p.getServer(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &srv.Server, nil
+}
+
+func (p *Provider) getServer(id string) (*fakeServer, error) {
+	s, err := p.read()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, srv := range s.Servers {
+		if srv.ID == id {
+			return &srv, nil
+		}
+	}
+
+	return nil, fmt.Errorf("server %s not found", id)
+}
+
+func (p *Provider) GetServers(ctx context.Context) ([]types.Server, error) {
+	s, err := p.read()
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make([]types.Server, len(s.Servers))
+	for i, srv := range s.Servers {
+		servers[i] = srv.Server
+	}
+
+	return servers, nil
+}
+
+// WaitForServer returns immediately since fake servers are running as soon as they're created.
+func (p *Provider) WaitForServer(ctx context.Context, id string, timeout time.Duration) (*types.Server, error) {
+	return p.GetServer(ctx, id)
+}
+
+// HostKeys returns the SSH host key generated for a server when it was created.
+func (p *Provider) HostKeys(ctx context.Context, id string) ([]ssh.PublicKey, error) {
+	srv, err := p.getServer(id)
+	if err != nil {
+		return nil, err
+	}
+
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(srv.HostKey))
+	if err != nil {
+		return nil, err
+	}
+
+	return []ssh.PublicKey{key}, nil
+}
+
+// DeleteServer removes a server. It's not part of types.Provider but is useful for tests.
+func (p *Provider) DeleteServer(ctx context.Context, id string) error {
+	return p.update(func(s *state) error {
+		n := len(s.Servers)
+		s.Servers = slices.DeleteFunc(s.Servers, func(srv fakeServer) bool { return srv.ID == id })
+
+		if len(s.Servers) == n {
+			return fmt.Errorf("server %s not found", id)
+		}
+
+		return nil
+	})
+}
+
+func (p *Provider) GetRegions(ctx context.Context) ([]types.Region, error) {
+	return []types.Region{
+		{Slug: "fake-1", Name: "Fake Region 1", Country: "CA", Available: true},
+		{Slug: "fake-2", Name: "Fake Region 2", Country: "DE", Available: true},
+	}, nil
+}
+
+func (p *Provider) GetSizes(ctx context.Context, region string) ([]types.Size, error) {
+	return []types.Size{
+		{Slug: "fake-small", VCPUs: 1, Memory: 1024, Disk: 25, PriceMonthly: 5, PriceHourly: 0.007, Available: true},
+		{Slug: "fake-medium", VCPUs: 2, Memory: 4096, Disk: 80, PriceMonthly: 20, PriceHourly: 0.03, Available: true},
+		{Slug: "fake-large", VCPUs: 4, Memory: 8192, Disk: 160, PriceMonthly: 40, PriceHourly: 0.06, Available: true},
+	}, nil
+}
+
+func (p *Provider) GetImages(ctx context.Context) ([]types.Image, error) {
+	return []types.Image{
+		{Slug: "ubuntu-22.04", Name: "Ubuntu 22.04", Distribution: "ubuntu", Architecture: "x86_64", MinDiskSize: 10},
+		{Slug: "ubuntu-24.04", Name: "Ubuntu 24.04", Distribution: "ubuntu", Architecture: "x86_64", MinDiskSize: 10},
+	}, nil
+}
+
+func (p *Provider) GetSSHKey(ctx context.Context, fingerprint string) (*types.SSHKey, error) {
+	s, err := p.read()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range s.SSHKeys {
+		if key.Fingerprint == fingerprint {
+			return &key, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (p *Provider) CreateSSHKey(ctx context.Context, name string, publicKey string) (*types.SSHKey, error) {
+	parsed, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSH public key: %w", err)
+	}
+
+	var created types.SSHKey
+
+	err = p.update(func(s *state) error {
+		created = types.SSHKey{
+			ID:          s.nextID(),
+			Name:        name,
+			Fingerprint: ssh.FingerprintLegacyMD5(parsed),
+			PublicKey:   publicKey,
+		}
+
+		s.SSHKeys = append(s.SSHKeys, created)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (p *Provider) CreateZone(ctx context.Context, domain string) error {
+	return p.update(func(s *state) error {
+		if _, ok := s.Zones[domain]; ok {
+			return fmt.Errorf("zone %s already exists", domain)
+		}
+
+		s.Zones[domain] = &fakeZone{Name: domain, Records: []types.DNSRecord{}}
+		return nil
+	})
+}
+
+func (p *Provider) GetZone(ctx context.Context, domain string) (*types.Zone, bool, error) {
+	s, err := p.read()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if _, ok := s.Zones[domain]; !ok {
+		return nil, false, nil
+	}
+
+	return &types.Zone{ID: domain, Name: domain, TTL: 300}, true, nil
+}
+
+func (p *Provider) CreateRecord(ctx context.Context, domain string, record types.DNSRecord) (*types.DNSRecord, error) {
+	err := p.update(func(s *state) error {
+		zone, ok := s.Zones[domain]
+		if !ok {
+			return fmt.Errorf("zone %s not found", domain)
+		}
+
+		record.ID = s.nextID()
+		if record.TTL == 0 {
+			record.TTL = 300
+		}
+
+		zone.Records = append(zone.Records, record)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (p *Provider) UpdateRecord(ctx context.Context, domain string, recordID string, record types.DNSRecord) (*types.DNSRecord, error) {
+	err := p.update(func(s *state) error {
+		zone, ok := s.Zones[domain]
+		if !ok {
+			return fmt.Errorf("zone %s not found", domain)
+		}
+
+		for i, r := range zone.Records {
+			if r.ID == recordID {
+				record.ID = recordID
+				if record.TTL == 0 {
+					record.TTL = r.TTL
+				}
+
+				zone.Records[i] = record
+				return nil
+			}
+		}
+
+		return fmt.Errorf("record %s not found", recordID)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (p *Provider) DeleteRecord(ctx context.Context, domain string, recordID string) error {
+	return p.update(func(s *state) error {
+		zone, ok := s.Zones[domain]
+		if !ok {
+			return fmt.Errorf("zone %s not found", domain)
+		}
+
+		n := len(zone.Records)
+		zone.Records = slices.DeleteFunc(zone.Records, func(r types.DNSRecord) bool { return r.ID == recordID })
+
+		if len(zone.Records) == n {
+			return fmt.Errorf("record %s not found", recordID)
+		}
+
+		return nil
+	})
+}
+
+func (p *Provider) ListRecords(ctx context.Context, domain string) ([]types.DNSRecord, error) {
+	s, err := p.read()
+	if err != nil {
+		return nil, err
+	}
+
+	zone, ok := s.Zones[domain]
+	if !ok {
+		return []types.DNSRecord{}, nil
+	}
+
+	return slices.Clone(zone.Records), nil
+}
@@ -0,0 +1,222 @@
+package fake
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/roots/trellis-cli/pkg/server/types"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	_ types.Provider        = (*Provider)(nil)
+	_ types.DNSProvider     = (*Provider)(nil)
+	_ types.HostKeyProvider = (*Provider)(nil)
+)
+
+func newTestProvider(t *testing.T) *Provider {
+	return New(filepath.Join(t.TempDir(), "fake.json"))
+}
+
+func TestCreateServer(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProvider(t)
+
+	srv, err := p.CreateServer(ctx, types.CreateServerOptions{Name: "example.com", Region: "fake-1", Size: "fake-small", Image: "ubuntu-24.04"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if srv.Status != types.ServerStatusRunning {
+		t.Errorf("expected status %s, got %s", types.ServerStatusRunning, srv.Status)
+	}
+
+	if srv.PublicIPv4 != "203.0.113.1" || srv.PublicIPv6 != "2001:db8::1" {
+		t.Errorf("unexpected IPs %s %s", srv.PublicIPv4, srv.PublicIPv6)
+	}
+
+	second, err := p.CreateServer(ctx, types.CreateServerOptions{Name: "example.com-2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if second.ID == srv.ID || second.PublicIPv4 != "203.0.113.2" {
+		t.Errorf("expected a new ID and IP, got %s %s", second.ID, second.PublicIPv4)
+	}
+
+	if _, err := p.CreateServer(ctx, types.CreateServerOptions{Name: "example.com"}); err == nil {
+		t.Error("expected an error for a duplicate server name")
+	}
+
+	waited, err := p.WaitForServer(ctx, srv.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if waited.Name != "example.com" {
+		t.Errorf("expected example.com, got %s", waited.Name)
+	}
+
+	keys, err := p.HostKeys(ctx, srv.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 1 || keys[0].Type() != ssh.KeyAlgoED25519 {
+		t.Errorf("expected one ed25519 host key, got %v", keys)
+	}
+
+	if err := p.DeleteServer(ctx, second.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	servers, err := p.GetServers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(servers) != 1 {
+		t.Errorf("expected 1 server, got %d", len(servers))
+	}
+
+	if _, err := p.GetServer(ctx, second.ID); err == nil {
+		t.Error("expected an error for a deleted server")
+	}
+}
+
+func TestStatePersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state", "fake.json")
+
+	srv, err := New(path).CreateServer(ctx, types.CreateServerOptions{Name: "example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := New(path).CreateZone(ctx, "example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	p := New(path)
+
+	found, err := p.GetServer(ctx, srv.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found.PublicIPv4 != srv.PublicIPv4 {
+		t.Errorf("expected %s, got %s", srv.PublicIPv4, found.PublicIPv4)
+	}
+
+	if _, ok, _ := p.GetZone(ctx, "example.com"); !ok {
+		t.Error("expected zone example.com to exist")
+	}
+}
+
+func TestSSHKeys(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProvider(t)
+
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sshKey, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprint := ssh.FingerprintLegacyMD5(sshKey)
+
+	existing, err := p.GetSSHKey(ctx, fingerprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if existing != nil {
+		t.Fatalf("expected no key, got %v", existing)
+	}
+
+	if _, err := p.CreateSSHKey(ctx, "deploy", "not a key"); err == nil {
+		t.Error("expected an error for an invalid key")
+	}
+
+	created, err := p.CreateSSHKey(ctx, "deploy", string(ssh.MarshalAuthorizedKey(sshKey)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	existing, err = p.GetSSHKey(ctx, fingerprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if existing == nil || existing.ID != created.ID {
+		t.Errorf("expected key %s to be found, got %v", created.ID, existing)
+	}
+}
+
+func TestDNSRecords(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProvider(t)
+
+	if _, err := p.CreateRecord(ctx, "example.com", types.DNSRecord{Type: "A", Name: "@", Value: "203.0.113.1"}); err == nil {
+		t.Error("expected an error for a missing zone")
+	}
+
+	if err := p.CreateZone(ctx, "example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.CreateZone(ctx, "example.com"); err == nil {
+		t.Error("expected an error for a duplicate zone")
+	}
+
+	created, err := p.CreateRecord(ctx, "example.com", types.DNSRecord{Type: "A", Name: "@", Value: "203.0.113.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if created.TTL != 300 {
+		t.Errorf("expected default TTL 300, got %d", created.TTL)
+	}
+
+	updated, err := p.UpdateRecord(ctx, "example.com", created.ID, types.DNSRecord{Type: "A", Name: "@", Value: "203.0.113.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if updated.ID != created.ID || updated.TTL != 300 {
+		t.Errorf("expected ID and TTL to be kept, got %v", updated)
+	}
+
+	records, err := p.ListRecords(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 1 || records[0].Value != "203.0.113.2" {
+		t.Errorf("expected updated record, got %v", records)
+	}
+
+	if err := p.DeleteRecord(ctx, "example.com", created.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.DeleteRecord(ctx, "example.com", created.ID); err == nil {
+		t.Error("expected an error for a deleted record")
+	}
+
+	records, err = p.ListRecords(ctx, "missing.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 0 {
+		t.Errorf("expected no records for a missing zone, got %v", records)
+	}
+}
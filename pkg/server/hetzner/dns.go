@@ -34,6 +34,8 @@ func (p *Provider) GetZone(ctx context.Context, domain string) (*types.Zone, boo
 	}, true, nil
 }
 
+// CreateRecord adds the record value to the RRSet for its name and type, creating the RRSet if needed.
+// Existing values are kept (eg: other servers' IPs for round-robin DNS).
 func (p *Provider) CreateRecord(ctx context.Context, domain string, record types.DNSRecord) (*types.DNSRecord, error) {
 	zone, _, err := p.client.Zone.GetByName(ctx, domain)
 	if err != nil {
@@ -43,6 +45,14 @@ func (p *Provider) CreateRecord(ctx context.Context, domain string, record types
 		return nil, fmt.Errorf("zone %s not found", domain)
 	}
 
+	existing, _, err := p.client.Zone.GetRRSetByNameAndType(ctx, zone, record.Name, hcloud.ZoneRRSetType(record.Type))
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return p.addRecordValue(ctx, existing, record)
+	}
+
 	ttl := record.TTL
 	if ttl == 0 {
 		ttl = defaultTTL
@@ -75,6 +85,71 @@ func (p *Provider) CreateRecord(ctx context.Context, domain string, record types
 	}, nil
 }
 
+func (p *Provider) addRecordValue(ctx context.Context, rrset *hcloud.ZoneRRSet, record types.DNSRecord) (*types.DNSRecord, error) {
+	_, _, err := p.client.Zone.AddRRSetRecords(ctx, rrset, hcloud.ZoneRRSetAddRecordsOpts{
+		Records: []hcloud.ZoneRRSetRecord{
+			{Value: record.Value},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ttl int
+	if rrset.TTL != nil {
+		ttl = *rrset.TTL
+	}
+
+	return &types.DNSRecord{
+		ID:    rrset.ID,
+		Type:  string(rrset.Type),
+		Name:  rrset.Name,
+		Value: record.Value,
+		TTL:   ttl,
+	}, nil
+}
+
+// UpdateRecord replaces all values of the record's RRSet with the single record value.
+func (p *Provider) UpdateRecord(ctx context.Context, domain string, recordID string, record types.DNSRecord) (*types.DNSRecord, error) {
+	zone, _, err := p.client.Zone.GetByName(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	if zone == nil {
+		return nil, fmt.Errorf("zone %s not found", domain)
+	}
+
+	rrset, _, err := p.client.Zone.GetRRSetByID(ctx, zone, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if rrset == nil {
+		return nil, fmt.Errorf("record %s not found", recordID)
+	}
+
+	_, _, err = p.client.Zone.SetRRSetRecords(ctx, rrset, hcloud.ZoneRRSetSetRecordsOpts{
+		Records: []hcloud.ZoneRRSetRecord{
+			{Value: record.Value},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ttl int
+	if rrset.TTL != nil {
+		ttl = *rrset.TTL
+	}
+
+	return &types.DNSRecord{
+		ID:    rrset.ID,
+		Type:  string(rrset.Type),
+		Name:  rrset.Name,
+		Value: record.Value,
+		TTL:   ttl,
+	}, nil
+}
+
 func (p *Provider) DeleteRecord(ctx context.Context, domain string, recordID string) error {
 	zone, _, err := p.client.Zone.GetByName(ctx, domain)
 	if err != nil {
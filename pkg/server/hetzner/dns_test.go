@@ -0,0 +1,131 @@
+package hetzner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/roots/trellis-cli/pkg/server/types"
+)
+
+// stubAPI is a minimal in-memory implementation of the Hetzner zone endpoints used by the provider.
+type stubAPI struct {
+	mu     sync.Mutex
+	rrsets map[string][]string
+	// actions holds the RRSet actions run (eg: "add_records www/A"), in order
+	actions []string
+}
+
+func (s *stubAPI) respond(w http.ResponseWriter, status int, result any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+func (s *stubAPI) rrset(id string) map[string]any {
+	name, rrsetType, _ := strings.Cut(id, "/")
+
+	records := []map[string]any{}
+	for _, value := range s.rrsets[id] {
+		records = append(records, map[string]any{"value": value})
+	}
+
+	return map[string]any{"id": id, "name": name, "type": rrsetType, "ttl": 300, "records": records, "zone": 1}
+}
+
+func (s *stubAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/zones/")
+	action := map[string]any{"action": map[string]any{"id": 1, "status": "success"}}
+
+	switch {
+	case r.Method == http.MethodGet && path == "example.com":
+		s.respond(w, http.StatusOK, map[string]any{"zone": map[string]any{"id": 1, "name": "example.com", "ttl": 300}})
+	case r.Method == http.MethodGet && path == "1/rrsets":
+		rrsets := []map[string]any{}
+		for id := range s.rrsets {
+			rrsets = append(rrsets, s.rrset(id))
+		}
+		s.respond(w, http.StatusOK, map[string]any{"rrsets": rrsets, "meta": map[string]any{"pagination": map[string]any{"page": 1, "per_page": 50, "total_entries": len(rrsets)}}})
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "1/rrsets/"):
+		id := strings.TrimPrefix(path, "1/rrsets/")
+		if _, ok := s.rrsets[id]; !ok {
+			s.respond(w, http.StatusNotFound, map[string]any{"error": map[string]any{"code": "not_found", "message": "rrset not found"}})
+			return
+		}
+		s.respond(w, http.StatusOK, map[string]any{"rrset": s.rrset(id)})
+	case r.Method == http.MethodPost && strings.HasPrefix(path, "1/rrsets/"):
+		id, actionName, _ := strings.Cut(strings.TrimPrefix(path, "1/rrsets/"), "/actions/")
+		s.actions = append(s.actions, actionName+" "+id)
+
+		var body struct {
+			Records []struct {
+				Value string `json:"value"`
+			} `json:"records"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		values := []string{}
+		for _, record := range body.Records {
+			values = append(values, record.Value)
+		}
+
+		switch actionName {
+		case "add_records":
+			s.rrsets[id] = append(s.rrsets[id], values...)
+		case "set_records":
+			s.rrsets[id] = values
+		}
+		s.respond(w, http.StatusCreated, action)
+	default:
+		s.respond(w, http.StatusNotFound, map[string]any{"error": map[string]any{"code": "not_found", "message": r.Method + " " + r.URL.Path}})
+	}
+}
+
+func newTestProvider(t *testing.T, api *stubAPI) *Provider {
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	return &Provider{client: hcloud.NewClient(hcloud.WithToken("test"), hcloud.WithEndpoint(srv.URL))}
+}
+
+func TestCreateRecordAddsToExistingRRSet(t *testing.T) {
+	api := &stubAPI{rrsets: map[string][]string{"www/A": {"5.6.7.8", "9.9.9.9"}}}
+	provider := newTestProvider(t, api)
+	ctx := context.Background()
+
+	records, err := provider.ListRecords(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 2 || records[0].ID != records[1].ID {
+		t.Fatalf("expected two values in one RRSet, got %+v", records)
+	}
+
+	record, err := provider.CreateRecord(ctx, "example.com", types.DNSRecord{Type: "A", Name: "www", Value: "1.2.3.4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if record.ID != "www/A" || record.Value != "1.2.3.4" {
+		t.Errorf("expected record www/A with value 1.2.3.4, got %+v", record)
+	}
+
+	expected := []string{"5.6.7.8", "9.9.9.9", "1.2.3.4"}
+	if !reflect.DeepEqual(api.rrsets["www/A"], expected) {
+		t.Errorf("expected RRSet values %v, got %v", expected, api.rrsets["www/A"])
+	}
+
+	if !reflect.DeepEqual(api.actions, []string{"add_records www/A"}) {
+		t.Errorf("expected only an add_records action, got %v", api.actions)
+	}
+}
@@ -0,0 +1,46 @@
+package hetzner
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/roots/trellis-cli/pkg/server/types"
+)
+
+// ensureFirewall returns the named firewall, creating it first if it doesn't
+// exist yet. Existing firewall rules are left as-is.
+func (p *Provider) ensureFirewall(ctx context.Context, fw *types.Firewall) (*hcloud.Firewall, error) {
+	existing, _, err := p.client.Firewall.GetByName(ctx, fw.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	_, ipv4All, _ := net.ParseCIDR("0.0.0.0/0")
+	_, ipv6All, _ := net.ParseCIDR("::/0")
+
+	rules := make([]hcloud.FirewallRule, len(fw.AllowedPorts))
+	for i, port := range fw.AllowedPorts {
+		rules[i] = hcloud.FirewallRule{
+			Direction: hcloud.FirewallRuleDirectionIn,
+			Protocol:  hcloud.FirewallRuleProtocolTCP,
+			Port:      hcloud.Ptr(strconv.Itoa(port)),
+			SourceIPs: []net.IPNet{*ipv4All, *ipv6All},
+		}
+	}
+
+	result, _, err := p.client.Firewall.Create(ctx, hcloud.FirewallCreateOpts{
+		Name:   fw.Name,
+		Labels: map[string]string{"type": "trellis"},
+		Rules:  rules,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Firewall, nil
+}
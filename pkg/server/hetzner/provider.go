@@ -3,6 +3,8 @@ package hetzner
 import (
 	"context"
 	"fmt"
+	"net"
+	"slices"
 	"sort"
 	"strconv"
 	"time"
@@ -68,6 +70,15 @@ func (p *Provider) CreateServer(ctx context.Context, opts types.CreateServerOpti
 		sshKeys[i] = key
 	}
 
+	var firewalls []*hcloud.ServerCreateFirewall
+	if opts.Firewall != nil {
+		fw, err := p.ensureFirewall(ctx, opts.Firewall)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create firewall %s: %w", opts.Firewall.Name, err)
+		}
+		firewalls = append(firewalls, &hcloud.ServerCreateFirewall{Firewall: *fw})
+	}
+
 	result, _, err := p.client.Server.Create(ctx, hcloud.ServerCreateOpts{
 		Name:       opts.Name,
 		ServerType: serverType,
@@ -75,6 +86,8 @@ func (p *Provider) CreateServer(ctx context.Context, opts types.CreateServerOpti
 		Location:   location,
 		SSHKeys:    sshKeys,
 		Labels:     labels,
+		UserData:   opts.UserData,
+		Firewalls:  firewalls,
 	})
 	if err != nil {
 		return nil, err
@@ -236,6 +249,41 @@ func (p *Provider) GetSizes(ctx context.Context, region string) ([]types.Size, e
 	return result, nil
 }
 
+// GetImages returns the available system images (eg: Ubuntu), excluding deprecated ones.
+func (p *Provider) GetImages(ctx context.Context) ([]types.Image, error) {
+	images, err := p.client.Image.AllWithOpts(ctx, hcloud.ImageListOpts{
+		Type:   []hcloud.ImageType{hcloud.ImageTypeSystem},
+		Status: []hcloud.ImageStatus{hcloud.ImageStatusAvailable},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]types.Image, 0, len(images))
+	for _, img := range images {
+		if img.IsDeprecated() {
+			continue
+		}
+
+		result = append(result, types.Image{
+			Slug:         img.Name,
+			Name:         img.Description,
+			Distribution: img.OSFlavor,
+			Architecture: string(img.Architecture),
+			MinDiskSize:  int(img.DiskSize),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Slug == result[j].Slug {
+			return result[i].Architecture < result[j].Architecture
+		}
+		return result[i].Slug < result[j].Slug
+	})
+
+	return result, nil
+}
+
 func (p *Provider) GetSSHKey(ctx context.Context, fingerprint string) (*types.SSHKey, error) {
 	key, _, err := p.client.SSHKey.GetByFingerprint(ctx, fingerprint)
 	if err != nil {
@@ -276,7 +324,7 @@ func (p *Provider) hcloudToServer(s *hcloud.Server) *types.Server {
 		ip = s.PublicNet.IPv4.IP.String()
 	}
 	if s.PublicNet.IPv6.IP != nil {
-		ipv6 = s.PublicNet.IPv6.IP.String()
+		ipv6 = serverIPv6(s.PublicNet.IPv6.IP)
 	}
 
 	var region, size string
@@ -296,9 +344,18 @@ func (p *Provider) hcloudToServer(s *hcloud.Server) *types.Server {
 		Region:     region,
 		Size:       size,
 		CreatedAt:  s.Created,
+		Tags:       s.Labels,
 	}
 }
 
+// Hetzner assigns each server a /64 network and configures the first address
+// in it (::1) on the server by default.
+func serverIPv6(network net.IP) string {
+	ip := slices.Clone(network.To16())
+	ip[len(ip)-1] |= 1
+	return ip.String()
+}
+
 func (p *Provider) parseID(id string) (int64, error) {
 	parts := splitID(id)
 	return strconv.ParseInt(parts[0], 10, 64)
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// hostKeyAlgorithms are requested one at a time to collect each type of host key a server has.
+var hostKeyAlgorithms = []string{
+	ssh.KeyAlgoED25519,
+	ssh.KeyAlgoECDSA256,
+	ssh.KeyAlgoECDSA384,
+	ssh.KeyAlgoECDSA521,
+	ssh.KeyAlgoRSASHA512,
+}
+
+var errHostKeyCaptured = errors.New("host key captured")
+
+/*
+ScanHostKeys completes an SSH handshake with the server at addr (host:port) for
+each supported host key algorithm and returns the host keys it presents.
+No authentication is attempted; the connection is closed as soon as the key is
+received.
+*/
+func ScanHostKeys(ctx context.Context, addr string) ([]ssh.PublicKey, error) {
+	keys := []ssh.PublicKey{}
+	var lastErr error
+
+	for _, algorithm := range hostKeyAlgorithms {
+		key, err := scanHostKey(ctx, addr, algorithm)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if !containsKey(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("could not get SSH host keys from %s: %w", addr, lastErr)
+	}
+
+	return keys, nil
+}
+
+func scanHostKey(ctx context.Context, addr string, algorithm string) (ssh.PublicKey, error) {
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+	}
+
+	var hostKey ssh.PublicKey
+
+	config := &ssh.ClientConfig{
+		User:              "trellis",
+		HostKeyAlgorithms: []string{algorithm},
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+			return errHostKeyCaptured
+		},
+	}
+
+	_, _, _, err = ssh.NewClientConn(conn, addr, config)
+	if hostKey != nil {
+		return hostKey, nil
+	}
+	if err == nil {
+		err = errors.New("no host key received")
+	}
+
+	return nil, err
+}
+
+// WaitForSSH waits for SSH to become available on the given host.
+// It polls port 22 until an SSH handshake succeeds or the context is cancelled,
+// and returns the server's host keys.
+func WaitForSSH(ctx context.Context, host string) ([]ssh.PublicKey, error) {
+	interval := 10 * time.Second
+	addr := net.JoinHostPort(host, "22")
+
+	for {
+		keys, err := ScanHostKeys(ctx, addr)
+		if err == nil {
+			return keys, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// SameHostKeys returns true if both lists contain the same keys (in any order).
+func SameHostKeys(a []ssh.PublicKey, b []ssh.PublicKey) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for _, key := range a {
+		if !containsKey(b, key) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsKey(keys []ssh.PublicKey, key ssh.PublicKey) bool {
+	return slices.ContainsFunc(keys, func(k ssh.PublicKey) bool {
+		return bytes.Equal(k.Marshal(), key.Marshal())
+	})
+}
+
+/*
+KnownHosts is an OpenSSH known_hosts file. Entries are managed per host and any
+other lines (comments, hashed hosts, other hosts) are kept as-is.
+*/
+type KnownHosts struct {
+	lines []string
+}
+
+func ReadKnownHosts(path string) (*KnownHosts, error) {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	k := &KnownHosts{lines: []string{}}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) != "" {
+			k.lines = append(k.lines, line)
+		}
+	}
+
+	return k, nil
+}
+
+// HostKeys returns the keys recorded for a host (as an IP or host name).
+func (k *KnownHosts) HostKeys(host string) []ssh.PublicKey {
+	keys := []ssh.PublicKey{}
+
+	for _, line := range k.lines {
+		if key, ok := parseKnownHostsLine(line, host); ok {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// SetHostKeys replaces all keys recorded for a host.
+func (k *KnownHosts) SetHostKeys(host string, keys []ssh.PublicKey) {
+	k.RemoveHost(host)
+
+	for _, key := range keys {
+		k.lines = append(k.lines, knownhosts.Line([]string{host}, key))
+	}
+}
+
+func (k *KnownHosts) RemoveHost(host string) {
+	k.lines = slices.DeleteFunc(k.lines, func(line string) bool {
+		_, ok := parseKnownHostsLine(line, host)
+		return ok
+	})
+}
+
+func (k *KnownHosts) Write(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	content := strings.Join(k.lines, "\n")
+	if content != "" {
+		content += "\n"
+	}
+
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+func parseKnownHostsLine(line string, host string) (ssh.PublicKey, bool) {
+	marker, hosts, key, _, _, err := ssh.ParseKnownHosts([]byte(line))
+	if err != nil || marker != "" {
+		return nil, false
+	}
+
+	normalized := knownhosts.Normalize(host)
+
+	for _, h := range hosts {
+		if knownhosts.Normalize(h) == normalized {
+			return key, true
+		}
+	}
+
+	return nil, false
+}
+
+// HostKeyFingerprints returns the SHA256 fingerprints of keys prefixed by their type.
+func HostKeyFingerprints(keys []ssh.PublicKey) []string {
+	fingerprints := make([]string, len(keys))
+	for i, key := range keys {
+		fingerprints[i] = fmt.Sprintf("%s %s", key.Type(), ssh.FingerprintSHA256(key))
+	}
+
+	return fingerprints
+}
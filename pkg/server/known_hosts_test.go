@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestScanHostKeys(t *testing.T) {
+	addr, hostKey := StartTestSSHServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	keys, err := ScanHostKeys(ctx, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !SameHostKeys(keys, []ssh.PublicKey{hostKey}) {
+		t.Errorf("expected host key %s, got %v", ssh.FingerprintSHA256(hostKey), HostKeyFingerprints(keys))
+	}
+}
+
+func TestScanHostKeysNoServer(t *testing.T) {
+	_, err := ScanHostKeys(context.Background(), "127.0.0.1:1")
+	if err == nil {
+		t.Error("expected an error when no SSH server is listening")
+	}
+}
+
+func TestKnownHosts(t *testing.T) {
+	_, key1 := StartTestSSHServer(t)
+	_, key2 := StartTestSSHServer(t)
+
+	path := filepath.Join(t.TempDir(), ".trellis", "known_hosts")
+	existing := "# managed by hand\n|1|hashed|entry ssh-ed25519 AAAA\n"
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	knownHosts, err := ReadKnownHosts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	knownHosts.SetHostKeys("1.2.3.4", []ssh.PublicKey{key1})
+	knownHosts.SetHostKeys("5.6.7.8:2222", []ssh.PublicKey{key1})
+	knownHosts.SetHostKeys("1.2.3.4", []ssh.PublicKey{key2})
+
+	if err := knownHosts.Write(path); err != nil {
+		t.Fatal(err)
+	}
+
+	knownHosts, err = ReadKnownHosts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !SameHostKeys(knownHosts.HostKeys("1.2.3.4"), []ssh.PublicKey{key2}) {
+		t.Error("expected 1.2.3.4 host keys to be replaced")
+	}
+
+	if !SameHostKeys(knownHosts.HostKeys("5.6.7.8:2222"), []ssh.PublicKey{key1}) {
+		t.Error("expected [5.6.7.8]:2222 host key to be recorded")
+	}
+
+	if len(knownHosts.HostKeys("9.9.9.9")) != 0 {
+		t.Error("expected no host keys for unknown host")
+	}
+
+	content, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(content), existing) {
+		t.Errorf("expected existing lines to be kept, got:\n%s", content)
+	}
+
+	if !strings.Contains(string(content), "[5.6.7.8]:2222 ssh-ed25519 ") {
+		t.Errorf("expected non-standard port host to be normalized, got:\n%s", content)
+	}
+}
@@ -0,0 +1,192 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/roots/trellis-cli/pkg/server/types"
+)
+
+const defaultTTL = 300
+
+type domain struct {
+	ID     int    `json:"id"`
+	Domain string `json:"domain"`
+	TTL    int    `json:"ttl_sec"`
+}
+
+type domainRecord struct {
+	ID     int    `json:"id,omitempty"`
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Target string `json:"target"`
+	TTL    int    `json:"ttl_sec"`
+}
+
+// CreateZone creates a master zone. Linode requires an SOA email which defaults
+// to the email of the account profile.
+func (p *Provider) CreateZone(ctx context.Context, name string) error {
+	soaEmail := p.SOAEmail
+
+	if soaEmail == "" {
+		var profile struct {
+			Email string `json:"email"`
+		}
+		if err := p.request(ctx, http.MethodGet, "/profile", nil, &profile); err != nil {
+			return err
+		}
+
+		soaEmail = profile.Email
+	}
+
+	body := map[string]string{
+		"domain":    name,
+		"type":      "master",
+		"soa_email": soaEmail,
+	}
+
+	return p.request(ctx, http.MethodPost, "/domains", body, nil)
+}
+
+func (p *Provider) GetZone(ctx context.Context, name string) (*types.Zone, bool, error) {
+	d, err := p.getDomain(ctx, name)
+	if err != nil {
+		return nil, false, err
+	}
+	if d == nil {
+		return nil, false, nil
+	}
+
+	return &types.Zone{
+		ID:   strconv.Itoa(d.ID),
+		Name: d.Domain,
+		TTL:  d.TTL,
+	}, true, nil
+}
+
+func (p *Provider) CreateRecord(ctx context.Context, name string, record types.DNSRecord) (*types.DNSRecord, error) {
+	d, err := p.requireDomain(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var result domainRecord
+	path := fmt.Sprintf("/domains/%d/records", d.ID)
+	if err := p.request(ctx, http.MethodPost, path, toAPIRecord(record), &result); err != nil {
+		return nil, err
+	}
+
+	converted := fromAPIRecord(result)
+	return &converted, nil
+}
+
+func (p *Provider) UpdateRecord(ctx context.Context, name string, recordID string, record types.DNSRecord) (*types.DNSRecord, error) {
+	d, err := p.requireDomain(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var result domainRecord
+	path := fmt.Sprintf("/domains/%d/records/%s", d.ID, recordID)
+	if err := p.request(ctx, http.MethodPut, path, toAPIRecord(record), &result); err != nil {
+		return nil, err
+	}
+
+	converted := fromAPIRecord(result)
+	return &converted, nil
+}
+
+func (p *Provider) DeleteRecord(ctx context.Context, name string, recordID string) error {
+	d, err := p.requireDomain(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return p.request(ctx, http.MethodDelete, fmt.Sprintf("/domains/%d/records/%s", d.ID, recordID), nil, nil)
+}
+
+func (p *Provider) ListRecords(ctx context.Context, name string) ([]types.DNSRecord, error) {
+	d, err := p.getDomain(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return []types.DNSRecord{}, nil
+	}
+
+	records, err := listAll[domainRecord](ctx, p, fmt.Sprintf("/domains/%d/records", d.ID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]types.DNSRecord, len(records))
+	for i, r := range records {
+		result[i] = fromAPIRecord(r)
+	}
+
+	return result, nil
+}
+
+func (p *Provider) getDomain(ctx context.Context, name string) (*domain, error) {
+	domains, err := listAll[domain](ctx, p, "/domains", map[string]any{"domain": name})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range domains {
+		if d.Domain == name {
+			return &d, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (p *Provider) requireDomain(ctx context.Context, name string) (*domain, error) {
+	d, err := p.getDomain(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, fmt.Errorf("zone %s not found", name)
+	}
+
+	return d, nil
+}
+
+// Linode uses an empty name for the zone apex while trellis-cli uses "@".
+func toAPIRecord(record types.DNSRecord) domainRecord {
+	ttl := record.TTL
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+
+	name := record.Name
+	if name == "@" {
+		name = ""
+	}
+
+	return domainRecord{
+		Type:   record.Type,
+		Name:   name,
+		Target: record.Value,
+		TTL:    ttl,
+	}
+}
+
+func fromAPIRecord(r domainRecord) types.DNSRecord {
+	name := r.Name
+	if name == "" {
+		name = "@"
+	}
+
+	return types.DNSRecord{
+		ID:    strconv.Itoa(r.ID),
+		Type:  r.Type,
+		Name:  name,
+		Value: r.Target,
+		TTL:   r.TTL,
+	}
+}
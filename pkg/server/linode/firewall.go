@@ -0,0 +1,61 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/roots/trellis-cli/pkg/server/types"
+)
+
+type firewall struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"`
+}
+
+// ensureFirewall returns the ID of the named firewall, creating it first if it
+// doesn't exist yet. Existing firewall rules are left as-is.
+func (p *Provider) ensureFirewall(ctx context.Context, fw *types.Firewall) (int, error) {
+	firewalls, err := listAll[firewall](ctx, p, "/networking/firewalls", map[string]any{"label": fw.Name})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, existing := range firewalls {
+		if existing.Label == fw.Name {
+			return existing.ID, nil
+		}
+	}
+
+	inbound := make([]map[string]any, len(fw.AllowedPorts))
+	for i, port := range fw.AllowedPorts {
+		inbound[i] = map[string]any{
+			"label":    "allow-tcp-" + strconv.Itoa(port),
+			"action":   "ACCEPT",
+			"protocol": "TCP",
+			"ports":    strconv.Itoa(port),
+			"addresses": map[string][]string{
+				"ipv4": {"0.0.0.0/0"},
+				"ipv6": {"::/0"},
+			},
+		}
+	}
+
+	body := map[string]any{
+		"label": fw.Name,
+		"tags":  []string{baseTag},
+		"rules": map[string]any{
+			"inbound_policy":  "DROP",
+			"outbound_policy": "ACCEPT",
+			"inbound":         inbound,
+			"outbound":        []any{},
+		},
+	}
+
+	var created firewall
+	if err := p.request(ctx, http.MethodPost, "/networking/firewalls", body, &created); err != nil {
+		return 0, err
+	}
+
+	return created.ID, nil
+}
@@ -0,0 +1,520 @@
+package linode
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roots/trellis-cli/pkg/server/types"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	defaultBaseURL = "https://api.linode.com/v4"
+	baseTag        = types.TrellisTag
+)
+
+// Provider implements the types.Provider and types.DNSProvider interfaces for Linode (Akamai).
+type Provider struct {
+	// SOAEmail is the SOA email of new DNS zones. Defaults to the account profile's email.
+	SOAEmail     string
+	baseURL      string
+	client       *http.Client
+	token        string
+	pollInterval time.Duration
+}
+
+// New creates a new Linode provider with the given API token.
+func New(token string) *Provider {
+	return &Provider{
+		baseURL:      defaultBaseURL,
+		client:       http.DefaultClient,
+		token:        token,
+		pollInterval: 5 * time.Second,
+	}
+}
+
+func (p *Provider) Name() string        { return "linode" }
+func (p *Provider) DisplayName() string { return "Linode" }
+
+type apiError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+type page[T any] struct {
+	Data  []T `json:"data"`
+	Page  int `json:"page"`
+	Pages int `json:"pages"`
+}
+
+type instance struct {
+	ID      int      `json:"id"`
+	Label   string   `json:"label"`
+	Status  string   `json:"status"`
+	IPv4    []string `json:"ipv4"`
+	IPv6    string   `json:"ipv6"`
+	Region  string   `json:"region"`
+	Type    string   `json:"type"`
+	Image   string   `json:"image"`
+	Created string   `json:"created"`
+	Tags    []string `json:"tags"`
+}
+
+type price struct {
+	Hourly  float64 `json:"hourly"`
+	Monthly float64 `json:"monthly"`
+}
+
+type linodeType struct {
+	ID           string `json:"id"`
+	Label        string `json:"label"`
+	Class        string `json:"class"`
+	VCPUs        int    `json:"vcpus"`
+	Memory       int    `json:"memory"`
+	Disk         int    `json:"disk"`
+	Transfer     int    `json:"transfer"`
+	Price        price  `json:"price"`
+	RegionPrices []struct {
+		ID string `json:"id"`
+		price
+	} `json:"region_prices"`
+}
+
+type region struct {
+	ID      string `json:"id"`
+	Label   string `json:"label"`
+	Country string `json:"country"`
+	Status  string `json:"status"`
+}
+
+type image struct {
+	ID         string `json:"id"`
+	Label      string `json:"label"`
+	Vendor     string `json:"vendor"`
+	IsPublic   bool   `json:"is_public"`
+	Deprecated bool   `json:"deprecated"`
+	Size       int    `json:"size"`
+	Status     string `json:"status"`
+}
+
+type sshKey struct {
+	ID     int    `json:"id"`
+	Label  string `json:"label"`
+	SSHKey string `json:"ssh_key"`
+}
+
+// CreateServer creates a Linode instance. Linode requires a root password so a
+// random one is generated; it's never stored since logins use SSH keys.
+func (p *Provider) CreateServer(ctx context.Context, opts types.CreateServerOptions) (*types.Server, error) {
+	tags := []string{baseTag}
+	for k, v := range opts.Tags {
+		tags = append(tags, fmt.Sprintf("%s:%s", k, v))
+	}
+	sort.Strings(tags)
+
+	authorizedKeys, err := p.authorizedKeys(ctx, opts.SSHKeyIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rootPass, err := randomPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]any{
+		"label":           opts.Name,
+		"region":          opts.Region,
+		"type":            opts.Size,
+		"image":           opts.Image,
+		"root_pass":       rootPass,
+		"authorized_keys": authorizedKeys,
+		"tags":            tags,
+		"booted":          true,
+	}
+
+	if opts.UserData != "" {
+		body["metadata"] = map[string]string{
+			"user_data": base64.StdEncoding.EncodeToString([]byte(opts.UserData)),
+		}
+	}
+
+	if opts.Firewall != nil {
+		firewallID, err := p.ensureFirewall(ctx, opts.Firewall)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create firewall %s: %w", opts.Firewall.Name, err)
+		}
+		body["firewall_id"] = firewallID
+	}
+
+	var created instance
+	if err := p.request(ctx, http.MethodPost, "/linode/instances", body, &created); err != nil {
+		return nil, err
+	}
+
+	return instanceToServer(&created), nil
+}
+
+func (p *Provider) GetServer(ctx context.Context, id string) (*types.Server, error) {
+	var result instance
+	if err := p.request(ctx, http.MethodGet, "/linode/instances/"+url.PathEscape(id), nil, &result); err != nil {
+		return nil, err
+	}
+
+	return instanceToServer(&result), nil
+}
+
+func (p *Provider) GetServers(ctx context.Context) ([]types.Server, error) {
+	instances, err := listAll[instance](ctx, p, "/linode/instances", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make([]types.Server, len(instances))
+	for i, inst := range instances {
+		servers[i] = *instanceToServer(&inst)
+	}
+
+	return servers, nil
+}
+
+// WaitForServer polls the instance until it's running since Linode doesn't return an action to watch.
+func (p *Provider) WaitForServer(ctx context.Context, id string, timeout time.Duration) (*types.Server, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		srv, err := p.GetServer(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if srv.Status == types.ServerStatusRunning {
+			return srv, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(p.pollInterval):
+		}
+	}
+}
+
+func (p *Provider) GetRegions(ctx context.Context) ([]types.Region, error) {
+	regions, err := listAll[region](ctx, p, "/regions", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]types.Region, 0, len(regions))
+	for _, r := range regions {
+		if r.Status != "ok" {
+			continue
+		}
+
+		result = append(result, types.Region{
+			Slug:      r.ID,
+			Name:      r.Label,
+			Country:   strings.ToUpper(r.Country),
+			Available: true,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+// GetSizes returns the instance types with their price in the region (if given).
+// GPU and accelerated types are excluded.
+func (p *Provider) GetSizes(ctx context.Context, region string) ([]types.Size, error) {
+	linodeTypes, err := listAll[linodeType](ctx, p, "/linode/types", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]types.Size, 0, len(linodeTypes))
+	for _, t := range linodeTypes {
+		if t.Class == "gpu" || t.Class == "accelerated" {
+			continue
+		}
+
+		typePrice := t.Price
+		for _, rp := range t.RegionPrices {
+			if rp.ID == region {
+				typePrice = rp.price
+				break
+			}
+		}
+
+		result = append(result, types.Size{
+			Slug:         t.ID,
+			Name:         t.Label,
+			VCPUs:        t.VCPUs,
+			Memory:       t.Memory,
+			Disk:         t.Disk / 1024,
+			Transfer:     float64(t.Transfer) / 1000,
+			PriceMonthly: typePrice.Monthly,
+			PriceHourly:  typePrice.Hourly,
+			Available:    true,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PriceMonthly < result[j].PriceMonthly
+	})
+
+	return result, nil
+}
+
+// GetImages returns the public distribution images (eg: Ubuntu), excluding deprecated ones.
+func (p *Provider) GetImages(ctx context.Context) ([]types.Image, error) {
+	images, err := listAll[image](ctx, p, "/images", map[string]any{"is_public": true})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]types.Image, 0, len(images))
+	for _, img := range images {
+		if !img.IsPublic || img.Deprecated || (img.Status != "" && img.Status != "available") {
+			continue
+		}
+
+		result = append(result, types.Image{
+			Slug:         img.ID,
+			Name:         img.Label,
+			Distribution: img.Vendor,
+			Architecture: "x86_64",
+			MinDiskSize:  (img.Size + 1023) / 1024,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Slug < result[j].Slug
+	})
+
+	return result, nil
+}
+
+// GetSSHKey finds a key in the account profile by its MD5 fingerprint.
+// Linode doesn't store fingerprints so they're computed from each key.
+func (p *Provider) GetSSHKey(ctx context.Context, fingerprint string) (*types.SSHKey, error) {
+	keys, err := listAll[sshKey](ctx, p, "/profile/sshkeys", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if keyFingerprint(key.SSHKey) == fingerprint {
+			return toSSHKey(key), nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (p *Provider) CreateSSHKey(ctx context.Context, name string, publicKey string) (*types.SSHKey, error) {
+	body := map[string]string{
+		"label":   name,
+		"ssh_key": strings.TrimSpace(publicKey),
+	}
+
+	var key sshKey
+	if err := p.request(ctx, http.MethodPost, "/profile/sshkeys", body, &key); err != nil {
+		return nil, fmt.Errorf("could not create SSH key on Linode: %w", err)
+	}
+
+	return toSSHKey(key), nil
+}
+
+// authorizedKeys returns the public keys for the given fingerprints since
+// Linode instances are created with public keys rather than key IDs.
+func (p *Provider) authorizedKeys(ctx context.Context, fingerprints []string) ([]string, error) {
+	keys := make([]string, len(fingerprints))
+
+	for i, fingerprint := range fingerprints {
+		key, err := p.GetSSHKey(ctx, fingerprint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get SSH key %s: %w", fingerprint, err)
+		}
+		if key == nil {
+			return nil, fmt.Errorf("SSH key with fingerprint %s not found", fingerprint)
+		}
+
+		keys[i] = key.PublicKey
+	}
+
+	return keys, nil
+}
+
+func toSSHKey(key sshKey) *types.SSHKey {
+	return &types.SSHKey{
+		ID:          strconv.Itoa(key.ID),
+		Name:        key.Label,
+		Fingerprint: keyFingerprint(key.SSHKey),
+		PublicKey:   key.SSHKey,
+	}
+}
+
+func keyFingerprint(publicKey string) string {
+	parsed, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return ""
+	}
+
+	return ssh.FingerprintLegacyMD5(parsed)
+}
+
+func randomPassword() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	// the suffix guarantees the character classes Linode's password strength check requires
+	return base64.RawURLEncoding.EncodeToString(b) + "-Aa1", nil
+}
+
+func instanceToServer(inst *instance) *types.Server {
+	var ip string
+	if len(inst.IPv4) > 0 {
+		ip = inst.IPv4[0]
+	}
+
+	ipv6, _, _ := strings.Cut(inst.IPv6, "/")
+
+	// timestamps are UTC without a time zone (eg: 2018-01-01T00:01:01)
+	createdAt, _ := time.Parse("2006-01-02T15:04:05", inst.Created)
+
+	return &types.Server{
+		ID:           strconv.Itoa(inst.ID),
+		Name:         inst.Label,
+		Status:       mapStatus(inst.Status),
+		PublicIPv4:   ip,
+		PublicIPv6:   ipv6,
+		Region:       inst.Region,
+		Size:         inst.Type,
+		Image:        inst.Image,
+		CreatedAt:    createdAt,
+		DashboardURL: fmt.Sprintf("https://cloud.linode.com/linodes/%d", inst.ID),
+		Tags:         types.ParseTags(inst.Tags),
+	}
+}
+
+func mapStatus(s string) types.ServerStatus {
+	switch s {
+	case "provisioning":
+		return types.ServerStatusPending
+	case "booting", "rebooting":
+		return types.ServerStatusStarting
+	case "running":
+		return types.ServerStatusRunning
+	case "offline", "shutting_down":
+		return types.ServerStatusStopped
+	default:
+		return types.ServerStatusUnknown
+	}
+}
+
+// listAll fetches every page of a paginated collection. filter is sent as the X-Filter header.
+func listAll[T any](ctx context.Context, p *Provider, path string, filter map[string]any) ([]T, error) {
+	result := []T{}
+
+	for pageNum := 1; ; pageNum++ {
+		var resp page[T]
+		if err := p.requestWithFilter(ctx, http.MethodGet, fmt.Sprintf("%s?page=%d&page_size=500", path, pageNum), filter, nil, &resp); err != nil {
+			return nil, err
+		}
+
+		result = append(result, resp.Data...)
+
+		if pageNum >= resp.Pages {
+			return result, nil
+		}
+	}
+}
+
+func (p *Provider) request(ctx context.Context, method string, path string, body any, result any) error {
+	return p.requestWithFilter(ctx, method, path, nil, body, result)
+}
+
+func (p *Provider) requestWithFilter(ctx context.Context, method string, path string, filter map[string]any, body any, result any) error {
+	var reqBody io.Reader
+
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	if filter != nil {
+		data, err := json.Marshal(filter)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("X-Filter", string(data))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 400 {
+		var errResp struct {
+			Errors []apiError `json:"errors"`
+		}
+
+		if err := json.Unmarshal(content, &errResp); err != nil || len(errResp.Errors) == 0 {
+			return fmt.Errorf("Linode API error (HTTP %d)", resp.StatusCode)
+		}
+
+		messages := make([]string, len(errResp.Errors))
+		for i, e := range errResp.Errors {
+			messages[i] = e.Reason
+			if e.Field != "" {
+				messages[i] = fmt.Sprintf("%s: %s", e.Field, e.Reason)
+			}
+		}
+
+		return fmt.Errorf("Linode API error (HTTP %d): %s", resp.StatusCode, strings.Join(messages, ", "))
+	}
+
+	if result != nil && len(content) > 0 {
+		if err := json.Unmarshal(content, result); err != nil {
+			return fmt.Errorf("could not parse Linode API response: %w", err)
+		}
+	}
+
+	return nil
+}
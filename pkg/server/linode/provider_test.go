@@ -0,0 +1,497 @@
+package linode
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/roots/trellis-cli/pkg/server/types"
+	"golang.org/x/crypto/ssh"
+)
+
+// stubAPI is a minimal in-memory implementation of the Linode API endpoints used by the provider.
+type stubAPI struct {
+	mu        sync.Mutex
+	nextID    int
+	instances []map[string]any
+	sshKeys   []sshKey
+	firewalls []firewall
+	domains   []domain
+	records   map[int][]domainRecord
+	// created holds the request body of the last created instance
+	created map[string]any
+	// pageSize limits list responses to test pagination
+	pageSize int
+	// bootPolls is the number of GETs an instance stays "provisioning" for
+	bootPolls int
+}
+
+func (s *stubAPI) respond(w http.ResponseWriter, status int, result any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+func (s *stubAPI) fail(w http.ResponseWriter, status int, field string, reason string) {
+	s.respond(w, status, map[string]any{"errors": []apiError{{Field: field, Reason: reason}}})
+}
+
+func paginate[T any](s *stubAPI, r *http.Request, items []T) map[string]any {
+	pageNum, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if pageNum < 1 {
+		pageNum = 1
+	}
+
+	size := s.pageSize
+	if size == 0 {
+		size = 500
+	}
+
+	pages := max(1, (len(items)+size-1)/size)
+	start := min(len(items), (pageNum-1)*size)
+	end := min(len(items), start+size)
+
+	data := items[start:end]
+	if data == nil {
+		data = []T{}
+	}
+
+	return map[string]any{"data": data, "page": pageNum, "pages": pages, "results": len(items)}
+}
+
+func (s *stubAPI) filter(r *http.Request) map[string]any {
+	filter := map[string]any{}
+	_ = json.Unmarshal([]byte(r.Header.Get("X-Filter")), &filter)
+	return filter
+}
+
+func (s *stubAPI) id() int {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *stubAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		s.fail(w, http.StatusUnauthorized, "", "Invalid Token")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	path := r.URL.Path
+
+	switch {
+	case r.Method == http.MethodGet && path == "/regions":
+		s.respond(w, http.StatusOK, paginate(s, r, []region{
+			{ID: "us-east", Label: "Newark, NJ", Country: "us", Status: "ok"},
+			{ID: "ca-central", Label: "Toronto, CA", Country: "ca", Status: "ok"},
+			{ID: "ap-west", Label: "Mumbai, IN", Country: "in", Status: "outage"},
+		}))
+	case r.Method == http.MethodGet && path == "/linode/types":
+		s.respond(w, http.StatusOK, paginate(s, r, []map[string]any{
+			{"id": "g6-standard-1", "label": "Linode 2GB", "class": "standard", "vcpus": 1, "memory": 2048, "disk": 51200, "transfer": 2000, "price": map[string]any{"hourly": 0.018, "monthly": 12}, "region_prices": []map[string]any{{"id": "id-cgk", "hourly": 0.0216, "monthly": 14.4}}},
+			{"id": "g6-nanode-1", "label": "Nanode 1GB", "class": "nanode", "vcpus": 1, "memory": 1024, "disk": 25600, "transfer": 1000, "price": map[string]any{"hourly": 0.0075, "monthly": 5}, "region_prices": []map[string]any{}},
+			{"id": "g1-gpu-rtx6000-1", "label": "Dedicated 32GB + RTX6000 GPU x1", "class": "gpu", "vcpus": 8, "memory": 32768, "disk": 655360, "price": map[string]any{"hourly": 1.5, "monthly": 1000}},
+		}))
+	case r.Method == http.MethodGet && path == "/images":
+		s.respond(w, http.StatusOK, paginate(s, r, []image{
+			{ID: "linode/ubuntu24.04", Label: "Ubuntu 24.04 LTS", Vendor: "Ubuntu", IsPublic: true, Size: 2500, Status: "available"},
+			{ID: "linode/ubuntu16.04lts", Label: "Ubuntu 16.04 LTS", Vendor: "Ubuntu", IsPublic: true, Deprecated: true, Size: 2500, Status: "available"},
+		}))
+	case r.Method == http.MethodGet && path == "/profile":
+		s.respond(w, http.StatusOK, map[string]string{"email": "admin@example.org"})
+	case r.Method == http.MethodGet && path == "/profile/sshkeys":
+		s.respond(w, http.StatusOK, paginate(s, r, s.sshKeys))
+	case r.Method == http.MethodPost && path == "/profile/sshkeys":
+		var key sshKey
+		_ = json.NewDecoder(r.Body).Decode(&key)
+		if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key.SSHKey)); err != nil {
+			s.fail(w, http.StatusBadRequest, "ssh_key", "Invalid SSH key")
+			return
+		}
+		key.ID = s.id()
+		s.sshKeys = append(s.sshKeys, key)
+		s.respond(w, http.StatusOK, key)
+	case r.Method == http.MethodGet && path == "/networking/firewalls":
+		result := []firewall{}
+		for _, fw := range s.firewalls {
+			if label, ok := s.filter(r)["label"]; !ok || label == fw.Label {
+				result = append(result, fw)
+			}
+		}
+		s.respond(w, http.StatusOK, paginate(s, r, result))
+	case r.Method == http.MethodPost && path == "/networking/firewalls":
+		var fw firewall
+		_ = json.NewDecoder(r.Body).Decode(&fw)
+		fw.ID = s.id()
+		s.firewalls = append(s.firewalls, fw)
+		s.respond(w, http.StatusOK, fw)
+	case r.Method == http.MethodGet && path == "/linode/instances":
+		s.respond(w, http.StatusOK, paginate(s, r, s.instances))
+	case r.Method == http.MethodPost && path == "/linode/instances":
+		body := map[string]any{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["root_pass"] == nil || body["root_pass"] == "" {
+			s.fail(w, http.StatusBadRequest, "root_pass", "root_pass is required when creating from an image")
+			return
+		}
+		s.created = body
+		id := s.id()
+		inst := map[string]any{
+			"id":      id,
+			"label":   body["label"],
+			"status":  "provisioning",
+			"ipv4":    []string{fmt.Sprintf("192.0.2.%d", id)},
+			"ipv6":    fmt.Sprintf("2600:3c00::%x/128", id),
+			"region":  body["region"],
+			"type":    body["type"],
+			"image":   body["image"],
+			"created": "2026-01-02T03:04:05",
+			"tags":    body["tags"],
+		}
+		s.instances = append(s.instances, inst)
+		s.respond(w, http.StatusOK, inst)
+	case r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "linode" && parts[1] == "instances":
+		for _, inst := range s.instances {
+			if fmt.Sprint(inst["id"]) == parts[2] {
+				if s.bootPolls > 0 {
+					s.bootPolls--
+				} else {
+					inst["status"] = "running"
+				}
+				s.respond(w, http.StatusOK, inst)
+				return
+			}
+		}
+		s.fail(w, http.StatusNotFound, "", "Not found")
+	case r.Method == http.MethodGet && path == "/domains":
+		result := []domain{}
+		for _, d := range s.domains {
+			if name, ok := s.filter(r)["domain"]; !ok || name == d.Domain {
+				result = append(result, d)
+			}
+		}
+		s.respond(w, http.StatusOK, paginate(s, r, result))
+	case r.Method == http.MethodPost && path == "/domains":
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["soa_email"] == "" {
+			s.fail(w, http.StatusBadRequest, "soa_email", "soa_email required when type=master")
+			return
+		}
+		d := domain{ID: s.id(), Domain: body["domain"]}
+		s.domains = append(s.domains, d)
+		s.respond(w, http.StatusOK, d)
+	case len(parts) >= 3 && parts[0] == "domains" && parts[2] == "records":
+		domainID, _ := strconv.Atoi(parts[1])
+
+		switch r.Method {
+		case http.MethodGet:
+			s.respond(w, http.StatusOK, paginate(s, r, s.records[domainID]))
+		case http.MethodPost:
+			var record domainRecord
+			_ = json.NewDecoder(r.Body).Decode(&record)
+			record.ID = s.id()
+			s.records[domainID] = append(s.records[domainID], record)
+			s.respond(w, http.StatusOK, record)
+		case http.MethodPut:
+			var record domainRecord
+			_ = json.NewDecoder(r.Body).Decode(&record)
+			for i, existing := range s.records[domainID] {
+				if strconv.Itoa(existing.ID) == parts[3] {
+					record.ID = existing.ID
+					s.records[domainID][i] = record
+					s.respond(w, http.StatusOK, record)
+					return
+				}
+			}
+			s.fail(w, http.StatusNotFound, "", "Not found")
+		case http.MethodDelete:
+			for i, existing := range s.records[domainID] {
+				if strconv.Itoa(existing.ID) == parts[3] {
+					s.records[domainID] = append(s.records[domainID][:i], s.records[domainID][i+1:]...)
+					s.respond(w, http.StatusOK, map[string]any{})
+					return
+				}
+			}
+			s.fail(w, http.StatusNotFound, "", "Not found")
+		}
+	default:
+		s.fail(w, http.StatusNotFound, "", "Not found")
+	}
+}
+
+func newTestProvider(t *testing.T, api *stubAPI) *Provider {
+	t.Helper()
+
+	if api.records == nil {
+		api.records = map[int][]domainRecord{}
+	}
+
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	p := New("test-token")
+	p.baseURL = srv.URL
+	p.client = srv.Client()
+	p.pollInterval = time.Millisecond
+
+	return p
+}
+
+func testPublicKey(t *testing.T) (string, string) {
+	t.Helper()
+
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))), ssh.FingerprintLegacyMD5(key)
+}
+
+func TestCreateServer(t *testing.T) {
+	ctx := context.Background()
+	api := &stubAPI{bootPolls: 2}
+	p := newTestProvider(t, api)
+
+	publicKey, fingerprint := testPublicKey(t)
+
+	if _, err := p.CreateServer(ctx, types.CreateServerOptions{Name: "example.com", SSHKeyIDs: []string{fingerprint}}); err == nil {
+		t.Error("expected an error for a missing SSH key")
+	}
+
+	existing, err := p.GetSSHKey(ctx, fingerprint)
+	if err != nil || existing != nil {
+		t.Fatalf("expected no SSH key, got %v (%v)", existing, err)
+	}
+
+	key, err := p.CreateSSHKey(ctx, "deploy", publicKey+"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.Fingerprint != fingerprint {
+		t.Errorf("expected fingerprint %s, got %s", fingerprint, key.Fingerprint)
+	}
+
+	srv, err := p.CreateServer(ctx, types.CreateServerOptions{
+		Name:      "example.com",
+		Region:    "us-east",
+		Size:      "g6-nanode-1",
+		Image:     "linode/ubuntu24.04",
+		SSHKeyIDs: []string{fingerprint},
+		Tags:      map[string]string{"env": "production", "role": "web"},
+		UserData:  "#cloud-config\n",
+		Firewall:  &types.Firewall{Name: "trellis-22-80-443", AllowedPorts: []int{22, 80, 443}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(api.created["authorized_keys"], []any{publicKey}) {
+		t.Errorf("expected authorized_keys to contain the public key, got %v", api.created["authorized_keys"])
+	}
+
+	if !reflect.DeepEqual(api.created["tags"], []any{"env:production", "role:web", "trellis"}) {
+		t.Errorf("unexpected tags %v", api.created["tags"])
+	}
+
+	if api.created["metadata"] == nil {
+		t.Error("expected user data to be sent as metadata")
+	}
+
+	if len(api.firewalls) != 1 || api.created["firewall_id"] != float64(api.firewalls[0].ID) {
+		t.Errorf("expected firewall to be created and attached, got %v", api.created["firewall_id"])
+	}
+
+	if srv.Status != types.ServerStatusPending {
+		t.Errorf("expected status %s, got %s", types.ServerStatusPending, srv.Status)
+	}
+
+	expected := map[string]string{"type": "trellis", "env": "production", "role": "web"}
+	if !reflect.DeepEqual(srv.Tags, expected) {
+		t.Errorf("expected tags %v, got %v", expected, srv.Tags)
+	}
+
+	running, err := p.WaitForServer(ctx, srv.ID, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if running.Status != types.ServerStatusRunning || running.PublicIPv6 != "2600:3c00::3" {
+		t.Errorf("expected running server with IPv6 2600:3c00::3, got %s %s", running.Status, running.PublicIPv6)
+	}
+
+	if running.CreatedAt != time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) {
+		t.Errorf("unexpected created at %s", running.CreatedAt)
+	}
+
+	// the existing firewall is reused
+	if _, err := p.CreateServer(ctx, types.CreateServerOptions{Name: "example.com-2", Firewall: &types.Firewall{Name: "trellis-22-80-443"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(api.firewalls) != 1 {
+		t.Errorf("expected 1 firewall, got %d", len(api.firewalls))
+	}
+}
+
+func TestGetServersPaginates(t *testing.T) {
+	api := &stubAPI{pageSize: 2}
+	p := newTestProvider(t, api)
+
+	for i := range 5 {
+		if _, err := p.CreateServer(context.Background(), types.CreateServerOptions{Name: fmt.Sprintf("server-%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	servers, err := p.GetServers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(servers) != 5 {
+		t.Errorf("expected 5 servers, got %d", len(servers))
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProvider(t, &stubAPI{})
+
+	regions, err := p.GetRegions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedRegions := []types.Region{
+		{Slug: "us-east", Name: "Newark, NJ", Country: "US", Available: true},
+		{Slug: "ca-central", Name: "Toronto, CA", Country: "CA", Available: true},
+	}
+
+	if !reflect.DeepEqual(regions, expectedRegions) {
+		t.Errorf("expected %v, got %v", expectedRegions, regions)
+	}
+
+	sizes, err := p.GetSizes(ctx, "id-cgk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSizes := []types.Size{
+		{Slug: "g6-nanode-1", Name: "Nanode 1GB", VCPUs: 1, Memory: 1024, Disk: 25, Transfer: 1, PriceHourly: 0.0075, PriceMonthly: 5, Available: true},
+		{Slug: "g6-standard-1", Name: "Linode 2GB", VCPUs: 1, Memory: 2048, Disk: 50, Transfer: 2, PriceHourly: 0.0216, PriceMonthly: 14.4, Available: true},
+	}
+
+	if !reflect.DeepEqual(sizes, expectedSizes) {
+		t.Errorf("expected %v, got %v", expectedSizes, sizes)
+	}
+
+	images, err := p.GetImages(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedImages := []types.Image{
+		{Slug: "linode/ubuntu24.04", Name: "Ubuntu 24.04 LTS", Distribution: "Ubuntu", Architecture: "x86_64", MinDiskSize: 3},
+	}
+
+	if !reflect.DeepEqual(images, expectedImages) {
+		t.Errorf("expected %v, got %v", expectedImages, images)
+	}
+}
+
+func TestDNSRecords(t *testing.T) {
+	ctx := context.Background()
+	api := &stubAPI{}
+	p := newTestProvider(t, api)
+
+	if _, found, err := p.GetZone(ctx, "example.com"); err != nil || found {
+		t.Fatalf("expected zone not to exist (err: %v)", err)
+	}
+
+	if _, err := p.CreateRecord(ctx, "example.com", types.DNSRecord{Type: "A", Name: "@", Value: "192.0.2.1"}); err == nil {
+		t.Error("expected an error for a missing zone")
+	}
+
+	if err := p.CreateZone(ctx, "example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found, err := p.GetZone(ctx, "example.com"); err != nil || !found {
+		t.Fatalf("expected zone to exist (err: %v)", err)
+	}
+
+	apex, err := p.CreateRecord(ctx, "example.com", types.DNSRecord{Type: "A", Name: "@", Value: "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if api.records[api.domains[0].ID][0].Name != "" {
+		t.Errorf("expected apex record to be created with an empty name, got %q", api.records[api.domains[0].ID][0].Name)
+	}
+
+	if _, err := p.CreateRecord(ctx, "example.com", types.DNSRecord{Type: "A", Name: "www", Value: "192.0.2.1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.UpdateRecord(ctx, "example.com", apex.ID, types.DNSRecord{Type: "A", Name: "@", Value: "192.0.2.2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := p.ListRecords(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []types.DNSRecord{
+		{ID: apex.ID, Type: "A", Name: "@", Value: "192.0.2.2", TTL: defaultTTL},
+		{ID: records[1].ID, Type: "A", Name: "www", Value: "192.0.2.1", TTL: defaultTTL},
+	}
+
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected %v, got %v", expected, records)
+	}
+
+	if err := p.DeleteRecord(ctx, "example.com", apex.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err = p.ListRecords(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 1 {
+		t.Errorf("expected 1 record, got %v", records)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	p := newTestProvider(t, &stubAPI{})
+	p.token = "invalid"
+
+	_, err := p.GetRegions(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "Linode API error (HTTP 401): Invalid Token") {
+		t.Errorf("expected API error, got %v", err)
+	}
+}
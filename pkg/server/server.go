@@ -1,19 +1,21 @@
 package server
 
 import (
-	"context"
 	"fmt"
-	"net"
 	"os"
+	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/hashicorp/cli"
 	"github.com/mitchellh/go-homedir"
 	"golang.org/x/crypto/ssh"
 
+	"github.com/roots/trellis-cli/app_paths"
+	"github.com/roots/trellis-cli/pkg/server/cloudflare"
 	"github.com/roots/trellis-cli/pkg/server/digitalocean"
+	"github.com/roots/trellis-cli/pkg/server/fake"
 	"github.com/roots/trellis-cli/pkg/server/hetzner"
+	"github.com/roots/trellis-cli/pkg/server/linode"
 	"github.com/roots/trellis-cli/pkg/server/types"
 )
 
@@ -23,11 +25,14 @@ type (
 	Provider            = types.Provider
 	DNSProvider         = types.DNSProvider
 	ProviderWithDNS     = types.ProviderWithDNS
+	HostKeyProvider     = types.HostKeyProvider
 	Server              = types.Server
 	ServerStatus        = types.ServerStatus
 	CreateServerOptions = types.CreateServerOptions
+	Firewall            = types.Firewall
 	Region              = types.Region
 	Size                = types.Size
+	Image               = types.Image
 	SSHKey              = types.SSHKey
 	Zone                = types.Zone
 	DNSRecord           = types.DNSRecord
@@ -37,6 +42,9 @@ type (
 const (
 	ProviderDigitalOcean = types.ProviderDigitalOcean
 	ProviderHetzner      = types.ProviderHetzner
+	ProviderLinode       = types.ProviderLinode
+	ProviderCloudflare   = types.ProviderCloudflare
+	ProviderFake         = types.ProviderFake
 	ServerStatusPending  = types.ServerStatusPending
 	ServerStatusStarting = types.ServerStatusStarting
 	ServerStatusRunning  = types.ServerStatusRunning
@@ -47,24 +55,51 @@ const (
 
 // Re-export functions
 var (
-	SupportedProviders = types.SupportedProviders
-	DefaultImage       = types.DefaultImage
+	SupportedProviders    = types.SupportedProviders
+	SupportedDNSProviders = types.SupportedDNSProviders
+	DefaultImage          = types.DefaultImage
 )
 
 // Token environment variable names for each provider.
 var tokenEnvVars = map[ProviderName]string{
 	ProviderDigitalOcean: "DIGITALOCEAN_ACCESS_TOKEN",
 	ProviderHetzner:      "HCLOUD_TOKEN",
+	ProviderLinode:       "LINODE_TOKEN",
+	ProviderCloudflare:   "CLOUDFLARE_API_TOKEN",
 }
 
-// GetProviderToken retrieves the API token for a provider from environment or prompts the user.
-func GetProviderToken(provider ProviderName, ui cli.Ui) (string, error) {
-	envVar := tokenEnvVars[provider]
-	token := os.Getenv(envVar)
+// FakeProviderStatePath is the JSON file the fake provider stores its state in.
+// It can be overridden with the TRELLIS_FAKE_PROVIDER_STATE environment variable.
+func FakeProviderStatePath() string {
+	if path := os.Getenv("TRELLIS_FAKE_PROVIDER_STATE"); path != "" {
+		return path
+	}
+
+	return filepath.Join(app_paths.DataDir(), "fake-provider.json")
+}
+
+// GetProviderToken retrieves the API token for a provider from the environment,
+// credential helper, or credentials profile (see LookupProviderToken) or prompts the user.
+func GetProviderToken(provider ProviderName, source TokenSource, ui cli.Ui) (string, error) {
+	// providers without a token env var (eg: fake) don't need a token
+	if _, ok := tokenEnvVars[provider]; !ok {
+		return "", nil
+	}
+
+	token, err := LookupProviderToken(provider, source)
+	if err != nil {
+		return "", err
+	}
 
 	if token == "" {
-		ui.Info(fmt.Sprintf("%s environment variable not set.", envVar))
-		var err error
+		profile := source.Profile
+		if profile == "" {
+			profile = DefaultProfile
+		}
+
+		ui.Info(fmt.Sprintf("%s environment variable not set and no %s token saved in the %s profile.", tokenEnvVars[provider], provider, profile))
+		ui.Info(fmt.Sprintf("Run `trellis server login --provider %s` to save one.", provider))
+
 		token, err = ui.Ask(fmt.Sprintf("Enter %s API token:", provider))
 		if err != nil {
 			return "", err
@@ -86,6 +121,12 @@ func NewProvider(name ProviderName, token string) (Provider, error) {
 		return digitalocean.New(token), nil
 	case ProviderHetzner:
 		return hetzner.New(token), nil
+	case ProviderLinode:
+		p := linode.New(token)
+		p.SOAEmail = os.Getenv("LINODE_SOA_EMAIL")
+		return p, nil
+	case ProviderFake:
+		return fake.New(FakeProviderStatePath()), nil
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", name)
 	}
@@ -106,6 +147,19 @@ func NewProviderWithDNS(name ProviderName, token string) (ProviderWithDNS, error
 	return pwd, nil
 }
 
+// NewDNSProvider creates a DNS provider instance based on the provider name.
+// Unlike NewProviderWithDNS, this includes DNS-only providers such as Cloudflare.
+func NewDNSProvider(name ProviderName, token string) (DNSProvider, error) {
+	switch name {
+	case ProviderCloudflare:
+		p := cloudflare.New(token)
+		p.AccountID = os.Getenv("CLOUDFLARE_ACCOUNT_ID")
+		return p, nil
+	default:
+		return NewProviderWithDNS(name, token)
+	}
+}
+
 // DefaultSSHKeyPaths contains the default locations to look for SSH public keys.
 var DefaultSSHKeyPaths = []string{"~/.ssh/id_ed25519.pub", "~/.ssh/id_rsa.pub"}
 
@@ -145,24 +199,3 @@ func loadPublicKey(path string) ([]byte, ssh.PublicKey, error) {
 
 	return key, publicKey, nil
 }
-
-// WaitForSSH waits for SSH to become available on the given host.
-// It polls port 22 until a connection can be established or the context is cancelled.
-func WaitForSSH(ctx context.Context, host string) error {
-	interval := 10 * time.Second
-	addr := net.JoinHostPort(host, "22")
-
-	for {
-		conn, err := net.DialTimeout("tcp", addr, interval)
-		if err == nil {
-			_ = conn.Close()
-			return nil
-		}
-
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(interval):
-		}
-	}
-}
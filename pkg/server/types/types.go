@@ -2,7 +2,10 @@ package types
 
 import (
 	"context"
+	"strings"
 	"time"
+
+	"golang.org/x/crypto/ssh"
 )
 
 // ProviderName identifies a cloud provider.
@@ -11,10 +14,22 @@ type ProviderName string
 const (
 	ProviderDigitalOcean ProviderName = "digitalocean"
 	ProviderHetzner      ProviderName = "hetzner"
+	ProviderLinode       ProviderName = "linode"
+	// ProviderCloudflare only supports DNS management.
+	ProviderCloudflare ProviderName = "cloudflare"
+	// ProviderFake is an in-process provider for tests and demos. It's not
+	// listed as a supported provider but can still be selected (eg: with
+	// `server.provider: fake`), the same way `vm.manager: mock` works.
+	ProviderFake ProviderName = "fake"
 )
 
 func SupportedProviders() []ProviderName {
-	return []ProviderName{ProviderDigitalOcean, ProviderHetzner}
+	return []ProviderName{ProviderDigitalOcean, ProviderHetzner, ProviderLinode}
+}
+
+// SupportedDNSProviders returns the providers which can manage DNS records.
+func SupportedDNSProviders() []ProviderName {
+	return []ProviderName{ProviderDigitalOcean, ProviderHetzner, ProviderLinode, ProviderCloudflare}
 }
 
 // DefaultImage returns the default Ubuntu 24.04 image slug for each provider.
@@ -22,8 +37,10 @@ func DefaultImage(provider ProviderName) string {
 	switch provider {
 	case ProviderDigitalOcean:
 		return "ubuntu-24-04-x64"
-	case ProviderHetzner:
+	case ProviderHetzner, ProviderFake:
 		return "ubuntu-24.04"
+	case ProviderLinode:
+		return "linode/ubuntu24.04"
 	default:
 		return ""
 	}
@@ -34,6 +51,8 @@ type Provider interface {
 	Name() string
 	DisplayName() string
 
+	// CreateServer returns the server along with the error if it was created
+	// but a later step (eg: attaching the firewall) failed.
 	CreateServer(ctx context.Context, opts CreateServerOptions) (*Server, error)
 	GetServer(ctx context.Context, id string) (*Server, error)
 	GetServers(ctx context.Context) ([]Server, error)
@@ -41,6 +60,7 @@ type Provider interface {
 
 	GetRegions(ctx context.Context) ([]Region, error)
 	GetSizes(ctx context.Context, region string) ([]Size, error)
+	GetImages(ctx context.Context) ([]Image, error)
 
 	GetSSHKey(ctx context.Context, fingerprint string) (*SSHKey, error)
 	CreateSSHKey(ctx context.Context, name string, publicKey string) (*SSHKey, error)
@@ -51,10 +71,17 @@ type DNSProvider interface {
 	CreateZone(ctx context.Context, domain string) error
 	GetZone(ctx context.Context, domain string) (*Zone, bool, error)
 	CreateRecord(ctx context.Context, domain string, record DNSRecord) (*DNSRecord, error)
+	UpdateRecord(ctx context.Context, domain string, recordID string, record DNSRecord) (*DNSRecord, error)
 	DeleteRecord(ctx context.Context, domain string, recordID string) error
 	ListRecords(ctx context.Context, domain string) ([]DNSRecord, error)
 }
 
+// HostKeyProvider is optionally implemented by providers which know their
+// servers' SSH host keys without connecting to them.
+type HostKeyProvider interface {
+	HostKeys(ctx context.Context, id string) ([]ssh.PublicKey, error)
+}
+
 // ProviderWithDNS combines both interfaces.
 type ProviderWithDNS interface {
 	Provider
@@ -75,16 +102,42 @@ const (
 
 // Server represents a cloud server instance.
 type Server struct {
-	ID           string
-	Name         string
-	Status       ServerStatus
-	PublicIPv4   string
-	PublicIPv6   string
-	Region       string
-	Size         string
-	Image        string
-	CreatedAt    time.Time
-	DashboardURL string
+	ID           string       `json:"id"`
+	Name         string       `json:"name"`
+	Status       ServerStatus `json:"status"`
+	PublicIPv4   string       `json:"public_ipv4"`
+	PublicIPv6   string       `json:"public_ipv6,omitempty"`
+	Region       string       `json:"region"`
+	Size         string       `json:"size"`
+	Image        string       `json:"image"`
+	CreatedAt    time.Time    `json:"created_at"`
+	DashboardURL string       `json:"dashboard_url,omitempty"`
+	// Tags are the key/value tags (labels) the server was created with.
+	// Servers created by trellis-cli have a "type: trellis" tag.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// TrellisTag is the bare tag which providers without key/value labels (eg:
+// DigitalOcean and Linode) add to servers created by trellis-cli.
+const TrellisTag = "trellis"
+
+// ParseTags converts "key:value" tags to a map. The bare TrellisTag becomes
+// "type: trellis" to match the labels of providers which support key/value labels.
+func ParseTags(tags []string) map[string]string {
+	result := map[string]string{}
+
+	for _, tag := range tags {
+		if tag == TrellisTag {
+			result["type"] = TrellisTag
+			continue
+		}
+
+		if key, value, ok := strings.Cut(tag, ":"); ok {
+			result[key] = value
+		}
+	}
+
+	return result
 }
 
 // CreateServerOptions contains the parameters for creating a new server.
@@ -95,36 +148,56 @@ type CreateServerOptions struct {
 	Image     string
 	SSHKeyIDs []string
 	Tags      map[string]string
+	// UserData is an optional cloud-init document passed to the server on first boot.
+	UserData string
+	// Firewall, when set, is created (or reused if it already exists) and attached to the server.
+	Firewall *Firewall
+}
+
+// Firewall represents a provider-level cloud firewall.
+// Only inbound TCP traffic to AllowedPorts is permitted; all outbound traffic is allowed.
+type Firewall struct {
+	Name         string `json:"name"`
+	AllowedPorts []int  `json:"allowed_ports"`
 }
 
 // Region represents a cloud provider region/location.
 type Region struct {
-	Slug      string
-	Name      string
-	Country   string
-	Available bool
+	Slug      string `json:"slug"`
+	Name      string `json:"name"`
+	Country   string `json:"country,omitempty"`
+	Available bool   `json:"available"`
 }
 
 // Size represents a server size/type.
 type Size struct {
-	Slug         string
-	Name         string
-	Description  string
-	VCPUs        int
-	Memory       int // MB
-	Disk         int // GB
-	Transfer     float64
-	PriceHourly  float64
-	PriceMonthly float64
-	Available    bool
+	Slug         string  `json:"slug"`
+	Name         string  `json:"name,omitempty"`
+	Description  string  `json:"description,omitempty"`
+	VCPUs        int     `json:"vcpus"`
+	Memory       int     `json:"memory_mb"`
+	Disk         int     `json:"disk_gb"`
+	Transfer     float64 `json:"transfer_tb,omitempty"`
+	PriceHourly  float64 `json:"price_hourly"`
+	PriceMonthly float64 `json:"price_monthly"`
+	Available    bool    `json:"available"`
+}
+
+// Image represents a public OS image servers can be created from.
+type Image struct {
+	Slug         string `json:"slug"`
+	Name         string `json:"name"`
+	Distribution string `json:"distribution"`
+	Architecture string `json:"architecture"`
+	MinDiskSize  int    `json:"min_disk_gb"`
 }
 
 // SSHKey represents an SSH public key registered with a provider.
 type SSHKey struct {
-	ID          string
-	Name        string
-	Fingerprint string
-	PublicKey   string
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Fingerprint string `json:"fingerprint"`
+	PublicKey   string `json:"public_key"`
 }
 
 // Zone represents a DNS zone/domain.
@@ -136,9 +209,9 @@ type Zone struct {
 
 // DNSRecord represents a DNS record.
 type DNSRecord struct {
-	ID    string
-	Type  string
-	Name  string
-	Value string
-	TTL   int
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	TTL   int    `json:"ttl"`
 }
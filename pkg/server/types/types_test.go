@@ -0,0 +1,16 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tags := ParseTags([]string{"trellis", "env:production", "other", "site:example.com:8080"})
+
+	expected := map[string]string{"type": "trellis", "env": "production", "site": "example.com:8080"}
+
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("expected %v, got %v", expected, tags)
+	}
+}
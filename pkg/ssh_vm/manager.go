@@ -0,0 +1,400 @@
+package ssh_vm
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/cli_config"
+	"github.com/roots/trellis-cli/command"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+const (
+	configDir   = "ssh"
+	defaultPort = 22
+	defaultSync = "rsync"
+)
+
+//go:embed files/inventory.txt
+var inventoryTemplate string
+
+var (
+	ErrConfigPath  = errors.New("could not create config directory")
+	ErrUnreachable = errors.New("could not connect to the VM over SSH")
+)
+
+// rsyncExcludes are kept on the VM: they're either built there (eg: by
+// `composer install` during provisioning) or shouldn't be overwritten.
+var rsyncExcludes = []string{".git/", "node_modules/", "vendor/", "web/app/uploads/"}
+
+/*
+Manager uses an existing machine reachable over SSH (eg: a remote dev box or a
+Multipass instance) as the development VM. The machine itself is never created
+or destroyed; "creating" the VM writes its Ansible inventory, and starting it
+syncs the site directories and adds the hosts entries.
+*/
+type Manager struct {
+	ConfigPath    string
+	Sites         map[string]*trellis.Site
+	HostsResolver vm.HostsResolver
+	Host          string
+	Port          int
+	User          string
+	Sync          string
+	MountCommand  string
+	ui            cli.Ui
+}
+
+func NewManager(trellis *trellis.Trellis, ui cli.Ui) (manager *Manager, err error) {
+	config := trellis.CliConfig.Vm.Ssh
+
+	if config.Host == "" {
+		return nil, fmt.Errorf("%w: `vm.ssh.host` is required when `vm.manager` is ssh", cli_config.InvalidConfigErr)
+	}
+
+	hostNames := trellis.Environments["development"].AllHosts()
+	hostsResolver, err := vm.NewHostsResolver(trellis.CliConfig.Vm.HostsResolver, hostNames)
+	if err != nil {
+		return nil, err
+	}
+
+	if hostsFile, ok := hostsResolver.(*vm.HostsFileResolver); ok {
+		hostsFile.Manager = "ssh"
+	}
+
+	manager = &Manager{
+		ConfigPath:    filepath.Join(trellis.ConfigPath(), configDir),
+		Sites:         trellis.Environments["development"].WordPressSites,
+		HostsResolver: hostsResolver,
+		Host:          config.Host,
+		Port:          config.Port,
+		User:          config.User,
+		Sync:          config.Sync,
+		MountCommand:  config.MountCommand,
+		ui:            ui,
+	}
+
+	if manager.Port == 0 {
+		manager.Port = defaultPort
+	}
+
+	if manager.Sync == "" {
+		manager.Sync = defaultSync
+	}
+
+	if err = os.MkdirAll(manager.ConfigPath, 0755); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrConfigPath, err)
+	}
+
+	return manager, nil
+}
+
+func (m *Manager) InventoryPath() string {
+	return filepath.Join(m.ConfigPath, "inventory")
+}
+
+// CreateInstance writes the Ansible inventory for the machine after checking it's reachable.
+func (m *Manager) CreateInstance(name string) error {
+	if err := m.checkConnection(); err != nil {
+		return err
+	}
+
+	tpl := template.Must(template.New("ssh").Parse(inventoryTemplate))
+
+	file, err := os.Create(m.InventoryPath())
+	if err != nil {
+		return fmt.Errorf("Could not create Ansible inventory file: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if err = tpl.Execute(file, m); err != nil {
+		return fmt.Errorf("Could not template Ansible inventory file: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteInstance removes the inventory and hosts entries. The machine itself is left untouched.
+func (m *Manager) DeleteInstance(name string) error {
+	if !m.created() {
+		m.ui.Info("VM does not exist for this project. Run `trellis vm start` to create it.")
+		return nil
+	}
+
+	if err := m.HostsResolver.RemoveHosts(name); err != nil {
+		return err
+	}
+
+	if err := os.Remove(m.InventoryPath()); err != nil {
+		return fmt.Errorf("Could not remove Ansible inventory file: %v", err)
+	}
+
+	m.ui.Info(fmt.Sprintf("Removed trellis-cli's configuration for %s. The machine itself was not changed.", m.Destination()))
+	return nil
+}
+
+// StartInstance syncs the site directories to the machine and adds the hosts entries.
+func (m *Manager) StartInstance(name string) error {
+	if !m.created() {
+		return vm.ErrVmNotFound
+	}
+
+	if err := m.checkConnection(); err != nil {
+		return err
+	}
+
+	if err := m.syncSites(); err != nil {
+		return err
+	}
+
+	ip, err := m.IP()
+	if err != nil {
+		return err
+	}
+
+	return m.HostsResolver.AddHosts(name, ip)
+}
+
+// StopInstance only removes the hosts entries since the machine isn't managed by trellis-cli.
+func (m *Manager) StopInstance(name string) error {
+	if err := m.HostsResolver.RemoveHosts(name); err != nil {
+		return err
+	}
+
+	m.ui.Info(fmt.Sprintf("%s Removed hosts entries. %s is still running (it isn't managed by trellis-cli).", color.GreenString("[✓]"), m.Destination()))
+	return nil
+}
+
+func (m *Manager) OpenShell(name string, dir string, commandArgs []string) error {
+	remoteCommand := "exec $SHELL -l"
+	if len(commandArgs) > 0 {
+		remoteCommand = "exec " + command.ShellJoin(commandArgs)
+	}
+
+	if dir != "" {
+		remoteCommand = fmt.Sprintf("cd %s && %s", command.ShellQuote(dir), remoteCommand)
+	}
+
+	args := append(m.sshArgs(), "-t", m.Destination(), remoteCommand)
+
+	return command.WithOptions(
+		command.WithTermOutput(),
+		command.WithLogging(m.ui),
+	).Cmd("ssh", args).Run()
+}
+
+func (m *Manager) RunCommand(args []string, dir string) error {
+	return command.WithOptions(
+		command.WithTermOutput(),
+		command.WithLogging(m.ui),
+	).Cmd("ssh", m.remoteCommandArgs(args, dir)).Run()
+}
+
+func (m *Manager) RunCommandPipe(args []string, dir string) (*exec.Cmd, error) {
+	return command.Cmd("ssh", m.remoteCommandArgs(args, dir)), nil
+}
+
+func (m *Manager) Copy(srcInVm string, dstOnHost string) error {
+	args := []string{"-P", strconv.Itoa(m.Port), fmt.Sprintf("%s:%s", m.Destination(), srcInVm), dstOnHost}
+
+	cmd := command.WithOptions(
+		command.WithLogging(m.ui),
+	).Cmd("scp", args)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (m *Manager) ReadRootFile(remotePath string) ([]byte, error) {
+	cmd, err := m.RunCommandPipe([]string{"sudo", "cat", remotePath}, "")
+	if err != nil {
+		return nil, err
+	}
+	return cmd.Output()
+}
+
+// Destination is the `[user@]host` SSH destination of the machine.
+func (m *Manager) Destination() string {
+	if m.User == "" {
+		return m.Host
+	}
+
+	return m.User + "@" + m.Host
+}
+
+func (m *Manager) InstanceIP(name string) (string, error) {
+	return m.IP()
+}
+
+/*
+IP resolves the machine's host to the IPv4 address used for the hosts entries.
+The host can be an alias from the user's SSH config so the real host name is
+read from `ssh -G` first.
+*/
+func (m *Manager) IP() (string, error) {
+	host := m.Host
+	if ip := net.ParseIP(host); ip == nil {
+		host = m.sshHostName()
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return "", fmt.Errorf("Could not resolve %s: %v", host, err)
+	}
+
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			return ip.String(), nil
+		}
+	}
+
+	return "", fmt.Errorf("Could not resolve %s to an IPv4 address", host)
+}
+
+// sshHostName returns the `hostname` SSH uses for the host (eg: from a `Host`
+// alias in ~/.ssh/config), or the host itself if it can't be determined.
+func (m *Manager) sshHostName() string {
+	output, err := command.Cmd("ssh", append(m.sshArgs(), "-G", m.Host)).Output()
+	if err != nil {
+		return m.Host
+	}
+
+	for line := range strings.SplitSeq(string(output), "\n") {
+		if hostName, ok := strings.CutPrefix(line, "hostname "); ok && strings.TrimSpace(hostName) != "" {
+			return strings.TrimSpace(hostName)
+		}
+	}
+
+	return m.Host
+}
+
+func (m *Manager) created() bool {
+	_, err := os.Stat(m.InventoryPath())
+	return err == nil
+}
+
+func (m *Manager) checkConnection() error {
+	args := append(m.sshArgs(), "-o", "BatchMode=yes", "-o", "ConnectTimeout=10", m.Destination(), "true")
+
+	output, err := command.WithOptions(
+		command.WithLogging(m.ui),
+	).Cmd("ssh", args).CombinedOutput()
+
+	if err != nil {
+		return fmt.Errorf("%w (%s): %v\n%s", ErrUnreachable, m.Destination(), err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+func (m *Manager) syncSites() error {
+	switch m.Sync {
+	case "rsync":
+		for _, name := range m.siteNames() {
+			if err := m.rsyncSite(name, m.Sites[name]); err != nil {
+				return fmt.Errorf("Could not sync %s to the VM: %v", name, err)
+			}
+		}
+	case "mount":
+		for _, name := range m.siteNames() {
+			if err := m.mountSite(name, m.Sites[name]); err != nil {
+				return fmt.Errorf("Could not mount %s in the VM: %v", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// rsyncSite syncs the site as the SSH user so the files aren't owned by root;
+// sudo is only used to create the directory and hand it to the user.
+func (m *Manager) rsyncSite(name string, site *trellis.Site) error {
+	remoteDir := siteMountPoint(name)
+
+	mkdir := fmt.Sprintf(`sudo mkdir -p %s && sudo chown "$(id -u):$(id -g)" %s`, command.ShellQuote(remoteDir), command.ShellQuote(remoteDir))
+	output, err := command.WithOptions(
+		command.WithLogging(m.ui),
+	).Cmd("ssh", append(m.sshArgs(), m.Destination(), mkdir)).CombinedOutput()
+
+	if err != nil {
+		return fmt.Errorf("Could not create %s: %v\n%s", remoteDir, err, strings.TrimSpace(string(output)))
+	}
+
+	args := []string{
+		"-rlptz",
+		"--delete",
+		"-e", fmt.Sprintf("ssh -p %d", m.Port),
+	}
+
+	for _, exclude := range rsyncExcludes {
+		args = append(args, "--exclude", exclude)
+	}
+
+	args = append(args, site.AbsLocalPath+"/", fmt.Sprintf("%s:%s/", m.Destination(), remoteDir))
+
+	m.ui.Info(fmt.Sprintf("Syncing %s to %s...", site.LocalPath, remoteDir))
+
+	return command.WithOptions(
+		command.WithTermOutput(),
+		command.WithLogging(m.ui),
+	).Cmd("rsync", args).Run()
+}
+
+// mountSite runs `vm.ssh.mount_command` on the host with `{local}` and `{remote}`
+// replaced by the site's local path and mount point in the VM.
+func (m *Manager) mountSite(name string, site *trellis.Site) error {
+	mountCommand := strings.NewReplacer(
+		"{local}", command.ShellQuote(site.AbsLocalPath),
+		"{remote}", command.ShellQuote(siteMountPoint(name)),
+	).Replace(m.MountCommand)
+
+	return command.WithOptions(
+		command.WithTermOutput(),
+		command.WithLogging(m.ui),
+	).Cmd("sh", []string{"-c", mountCommand}).Run()
+}
+
+func (m *Manager) siteNames() []string {
+	names := make([]string, 0, len(m.Sites))
+	for name := range m.Sites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (m *Manager) sshArgs() []string {
+	return []string{"-p", strconv.Itoa(m.Port)}
+}
+
+func (m *Manager) remoteCommandArgs(args []string, dir string) []string {
+	remoteCommand := command.ShellJoin(args)
+	if dir != "" {
+		remoteCommand = fmt.Sprintf("cd %s && %s", command.ShellQuote(dir), remoteCommand)
+	}
+
+	return append(m.sshArgs(), m.Destination(), remoteCommand)
+}
+
+func siteMountPoint(name string) string {
+	return "/srv/www/" + name + "/current"
+}
@@ -0,0 +1,216 @@
+package ssh_vm
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/roots/trellis-cli/command"
+	"github.com/roots/trellis-cli/pkg/vm"
+	"github.com/roots/trellis-cli/trellis"
+)
+
+type MockHostsResolver struct {
+	Hosts map[string]string
+}
+
+func (h *MockHostsResolver) AddHosts(name string, ip string) error {
+	h.Hosts[name] = ip
+	return nil
+}
+
+func (h *MockHostsResolver) RemoveHosts(name string) error {
+	delete(h.Hosts, name)
+	return nil
+}
+
+func (h *MockHostsResolver) HasHosts(name string) (bool, error) {
+	_, ok := h.Hosts[name]
+	return ok, nil
+}
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+
+	tp := trellis.NewTrellis()
+	if err := tp.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	tp.CliConfig.Vm.Ssh.Host = "192.168.1.50"
+	tp.CliConfig.Vm.Ssh.User = "dev"
+
+	manager, err := NewManager(tp, cli.NewMockUi())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager.HostsResolver = &MockHostsResolver{Hosts: map[string]string{}}
+	return manager
+}
+
+func TestNewManager(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	manager := newTestManager(t)
+
+	if manager.Port != 22 {
+		t.Errorf("expected default port 22, got %d", manager.Port)
+	}
+
+	if manager.Sync != "rsync" {
+		t.Errorf("expected default sync rsync, got %s", manager.Sync)
+	}
+
+	if manager.Destination() != "dev@192.168.1.50" {
+		t.Errorf("expected destination dev@192.168.1.50, got %s", manager.Destination())
+	}
+}
+
+func TestNewManagerRequiresHost(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	tp := trellis.NewTrellis()
+	if err := tp.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewManager(tp, cli.NewMockUi()); err == nil {
+		t.Error("expected an error without vm.ssh.host")
+	}
+}
+
+func TestCreateAndStartInstance(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	manager := newTestManager(t)
+
+	if err := manager.StartInstance("example.com"); !errors.Is(err, vm.ErrVmNotFound) {
+		t.Fatalf("expected ErrVmNotFound before the VM is created, got %v", err)
+	}
+
+	defer command.MockExecCommands(t, []command.MockCommand{
+		{
+			Command: "ssh",
+			Args:    []string{"-p", "22", "-o", "BatchMode=yes", "-o", "ConnectTimeout=10", "dev@192.168.1.50", "true"},
+		},
+		{
+			Command: "ssh",
+			Args:    []string{"-p", "22", "dev@192.168.1.50", `sudo mkdir -p '/srv/www/example.com/current' && sudo chown "$(id -u):$(id -g)" '/srv/www/example.com/current'`},
+		},
+		{
+			Command: "rsync",
+			Args: []string{
+				"-rlptz", "--delete", "-e", "ssh -p 22",
+				"--exclude", ".git/", "--exclude", "node_modules/", "--exclude", "vendor/", "--exclude", "web/app/uploads/",
+				manager.Sites["example.com"].AbsLocalPath + "/", "dev@192.168.1.50:/srv/www/example.com/current/",
+			},
+		},
+	})()
+
+	if err := manager.CreateInstance("example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	inventory, err := os.ReadFile(manager.InventoryPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(inventory), "default ansible_host=192.168.1.50 ansible_port=22 ansible_user=dev") {
+		t.Errorf("unexpected inventory contents:\n%s", inventory)
+	}
+
+	if err := manager.StartInstance("example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, _ := manager.HostsResolver.HasHosts("example.com"); !ok {
+		t.Error("expected hosts to be added")
+	}
+}
+
+func TestDeleteInstance(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	manager := newTestManager(t)
+
+	if err := os.WriteFile(manager.InventoryPath(), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	_ = manager.HostsResolver.AddHosts("example.com", "192.168.1.50")
+
+	if err := manager.DeleteInstance("example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(manager.InventoryPath()); !os.IsNotExist(err) {
+		t.Error("expected inventory to be removed")
+	}
+
+	if ok, _ := manager.HostsResolver.HasHosts("example.com"); ok {
+		t.Error("expected hosts to be removed")
+	}
+}
+
+func TestRunCommandPipe(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	manager := newTestManager(t)
+	manager.Port = 2222
+
+	cmd, err := manager.RunCommandPipe([]string{"wp", "option", "get", "home"}, "/srv/www/example.com/current")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"ssh", "-p", "2222", "dev@192.168.1.50", "cd '/srv/www/example.com/current' && 'wp' 'option' 'get' 'home'"}
+
+	if !reflect.DeepEqual(cmd.Args, expected) {
+		t.Errorf("expected %v, got %v", expected, cmd.Args)
+	}
+}
+
+func TestMountSite(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	manager := newTestManager(t)
+	manager.MountCommand = "multipass mount {local} trellis:{remote}"
+
+	site := manager.Sites["example.com"]
+
+	defer command.MockExecCommands(t, []command.MockCommand{
+		{
+			Command: "sh",
+			Args:    []string{"-c", "multipass mount '" + site.AbsLocalPath + "' trellis:'/srv/www/example.com/current'"},
+		},
+	})()
+
+	if err := manager.mountSite("example.com", site); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIPResolvesSSHConfigAlias(t *testing.T) {
+	defer trellis.LoadFixtureProject(t)()
+	manager := newTestManager(t)
+	manager.Host = "devbox"
+
+	defer command.MockExecCommands(t, []command.MockCommand{
+		{
+			Command: "ssh",
+			Args:    []string{"-p", "22", "-G", "devbox"},
+			Output:  "user dev\nhostname 10.0.0.5\nport 22\n",
+		},
+	})()
+
+	ip, err := manager.IP()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ip != "10.0.0.5" {
+		t.Errorf("expected 10.0.0.5, got %s", ip)
+	}
+}
+
+func TestCommandHelperProcess(t *testing.T) {
+	command.CommandHelperProcess(t)
+}
@@ -0,0 +1,344 @@
+package vm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/roots/trellis-cli/app_paths"
+	"github.com/roots/trellis-cli/command"
+)
+
+// DnsServerAddress is where the development DNS server (`trellis vm dns-server`) listens.
+const DnsServerAddress = "127.0.0.1:5399"
+
+var ErrDnsServerStart = errors.New("could not start the development DNS server")
+
+type DnsServerInstance struct {
+	IP    string   `json:"ip"`
+	Hosts []string `json:"hosts"`
+}
+
+// DnsServerRecords are the hosts served by the DNS server for each running VM.
+type DnsServerRecords struct {
+	Instances map[string]DnsServerInstance `json:"instances"`
+}
+
+/*
+DnsServerResolver resolves development hosts with a DNS server run by
+trellis-cli in the background. It's started when a VM starts and stopped once
+no VM needs it. The server is shared by all projects, so multiple VMs can run
+at the same time, and it answers for subdomains of each host (eg: multisite).
+
+The development TLDs (eg: `.test`) are registered with the OS resolver once
+(/etc/resolver on macOS, a systemd-resolved drop-in on Linux), which is the
+only step requiring sudo.
+*/
+type DnsServerResolver struct {
+	Hosts         []string
+	recordsPath   string
+	pidPath       string
+	logPath       string
+	tmpDir        string
+	registerDir   string
+	serverCommand func() *exec.Cmd
+}
+
+func NewDnsServerResolver(hosts []string) *DnsServerResolver {
+	registerDir := "/etc/resolver"
+	if runtime.GOOS == "linux" {
+		registerDir = "/etc/systemd/resolved.conf.d"
+	}
+
+	dataDir := filepath.Join(app_paths.DataDir(), "dns")
+
+	return &DnsServerResolver{
+		Hosts:       hosts,
+		recordsPath: DnsServerRecordsPath(),
+		pidPath:     DnsServerPidPath(),
+		logPath:     filepath.Join(dataDir, "server.log"),
+		tmpDir:      dataDir,
+		registerDir: registerDir,
+		serverCommand: func() *exec.Cmd {
+			executable, _ := os.Executable()
+			return exec.Command(executable, "vm", "dns-server")
+		},
+	}
+}
+
+func DnsServerRecordsPath() string {
+	return filepath.Join(app_paths.DataDir(), "dns", "records.json")
+}
+
+func DnsServerPidPath() string {
+	return filepath.Join(app_paths.DataDir(), "dns", "server.pid")
+}
+
+func (r *DnsServerResolver) AddHosts(name string, ip string) error {
+	records, err := ReadDnsServerRecords(r.recordsPath)
+	if err != nil {
+		return fmt.Errorf("%w: %v", HostsAddErr, err)
+	}
+
+	records.Instances[name] = DnsServerInstance{IP: ip, Hosts: r.Hosts}
+
+	if err := r.writeRecords(records); err != nil {
+		return fmt.Errorf("%w: %v", HostsAddErr, err)
+	}
+
+	if err := r.register(); err != nil {
+		return fmt.Errorf("%w: could not register the development DNS server with the OS resolver: %v", HostsAddErr, err)
+	}
+
+	return r.startServer()
+}
+
+func (r *DnsServerResolver) RemoveHosts(name string) error {
+	records, err := ReadDnsServerRecords(r.recordsPath)
+	if err != nil {
+		return fmt.Errorf("%w: %v", HostsRemoveErr, err)
+	}
+
+	delete(records.Instances, name)
+
+	if err := r.writeRecords(records); err != nil {
+		return fmt.Errorf("%w: %v", HostsRemoveErr, err)
+	}
+
+	if len(records.Instances) == 0 {
+		return r.stopServer()
+	}
+
+	return nil
+}
+
+// HasHosts reports whether the DNS server has records for the instance.
+func (r *DnsServerResolver) HasHosts(name string) (bool, error) {
+	records, err := ReadDnsServerRecords(r.recordsPath)
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := records.Instances[name]
+	return ok, nil
+}
+
+func ReadDnsServerRecords(path string) (*DnsServerRecords, error) {
+	records := &DnsServerRecords{Instances: map[string]DnsServerInstance{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, records); err != nil {
+		return nil, fmt.Errorf("Could not parse %s: %v", path, err)
+	}
+
+	if records.Instances == nil {
+		records.Instances = map[string]DnsServerInstance{}
+	}
+
+	return records, nil
+}
+
+// Hosts returns every host of every instance and the IP it resolves to.
+func (r *DnsServerRecords) Hosts() map[string]string {
+	hosts := map[string]string{}
+
+	for _, instance := range r.Instances {
+		for _, host := range instance.Hosts {
+			hosts[strings.ToLower(host)] = instance.IP
+		}
+	}
+
+	return hosts
+}
+
+func (r *DnsServerResolver) writeRecords(records *DnsServerRecords) error {
+	if err := os.MkdirAll(filepath.Dir(r.recordsPath), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Written atomically since the server reads it for every query.
+	tmpPath := r.recordsPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, r.recordsPath)
+}
+
+// registrations returns the OS resolver config file contents for each of the hosts' TLDs.
+func (r *DnsServerResolver) registrations() map[string]string {
+	host, port, _ := net.SplitHostPort(DnsServerAddress)
+	files := map[string]string{}
+
+	for _, tld := range topLevelDomains(r.Hosts) {
+		if runtime.GOOS == "linux" {
+			files[filepath.Join(r.registerDir, "trellis-dns-"+tld+".conf")] = fmt.Sprintf("# Managed by trellis-cli\n[Resolve]\nDNS=%s\nDomains=~%s\n", DnsServerAddress, tld)
+		} else {
+			files[filepath.Join(r.registerDir, tld)] = fmt.Sprintf("# Managed by trellis-cli\nnameserver %s\nport %s\n", host, port)
+		}
+	}
+
+	return files
+}
+
+// register installs the OS resolver config files which are missing or outdated with a single sudo command.
+func (r *DnsServerResolver) register() error {
+	script := []string{}
+
+	paths := []string{}
+	files := r.registrations()
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if existing, err := os.ReadFile(path); err == nil && string(existing) == files[path] {
+			continue
+		}
+
+		tmpPath := filepath.Join(r.tmpDir, filepath.Base(path))
+		if err := os.MkdirAll(r.tmpDir, 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(tmpPath, []byte(files[path]), 0644); err != nil {
+			return err
+		}
+
+		script = append(script, fmt.Sprintf("mkdir -p %s && cp %s %s", command.ShellQuote(r.registerDir), command.ShellQuote(tmpPath), command.ShellQuote(path)))
+	}
+
+	if len(script) == 0 {
+		return nil
+	}
+
+	if runtime.GOOS == "linux" {
+		script = append(script, "systemctl restart systemd-resolved")
+	}
+
+	fmt.Printf("\nRegistering the development DNS server for %s (sudo is required once)\n", strings.Join(paths, ", "))
+
+	return command.WithOptions(
+		command.WithTermOutput(),
+	).Cmd("sudo", []string{"sh", "-c", strings.Join(script, " && ")}).Run()
+}
+
+// startServer starts the DNS server in the background unless it's already running.
+func (r *DnsServerResolver) startServer() error {
+	if _, running := DnsServerPid(r.pidPath); running {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.logPath), 0755); err != nil {
+		return err
+	}
+
+	logFile, err := os.OpenFile(r.logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = logFile.Close() }()
+
+	cmd := r.serverCommand()
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("%w: %v", ErrDnsServerStart, err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+
+	// The server writes its pid file once it's listening.
+	for range 50 {
+		if _, running := DnsServerPid(r.pidPath); running {
+			return nil
+		}
+
+		select {
+		case <-exited:
+			return fmt.Errorf("%w. See %s for details.", ErrDnsServerStart, r.logPath)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	return fmt.Errorf("%w: timed out. See %s for details.", ErrDnsServerStart, r.logPath)
+}
+
+func (r *DnsServerResolver) stopServer() error {
+	pid, running := DnsServerPid(r.pidPath)
+	if !running {
+		return nil
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+
+	return process.Signal(syscall.SIGTERM)
+}
+
+// DnsServerPid returns the DNS server's pid and whether it's running.
+func DnsServerPid(pidPath string) (int, bool) {
+	data, err := os.ReadFile(pidPath)
+	if err != nil {
+		return 0, false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return pid, false
+	}
+
+	return pid, process.Signal(syscall.Signal(0)) == nil
+}
+
+func topLevelDomains(hosts []string) []string {
+	tlds := []string{}
+
+	for _, host := range hosts {
+		labels := strings.Split(strings.ToLower(host), ".")
+		tld := labels[len(labels)-1]
+
+		if !slices.Contains(tlds, tld) {
+			tlds = append(tlds, tld)
+		}
+	}
+
+	sort.Strings(tlds)
+	return tlds
+}
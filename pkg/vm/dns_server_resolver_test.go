@@ -0,0 +1,114 @@
+package vm
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestDnsServerResolver(t *testing.T) *DnsServerResolver {
+	t.Helper()
+	tempDir := t.TempDir()
+	pidPath := filepath.Join(tempDir, "server.pid")
+
+	r := &DnsServerResolver{
+		Hosts:       []string{"example.test", "www.example.test"},
+		recordsPath: filepath.Join(tempDir, "records.json"),
+		pidPath:     pidPath,
+		logPath:     filepath.Join(tempDir, "server.log"),
+		tmpDir:      filepath.Join(tempDir, "tmp"),
+		registerDir: filepath.Join(tempDir, "resolver"),
+		serverCommand: func() *exec.Cmd {
+			return exec.Command("sh", "-c", "echo $$ > '"+pidPath+"'; exec sleep 30")
+		},
+	}
+
+	// Already registered so no sudo command is run
+	if err := os.MkdirAll(r.registerDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range r.registrations() {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return r
+}
+
+func TestDnsServerResolver(t *testing.T) {
+	r := newTestDnsServerResolver(t)
+	other := *r
+	other.Hosts = []string{"other.test"}
+
+	if err := r.AddHosts("example", "192.168.56.5"); err != nil {
+		t.Fatal(err)
+	}
+
+	pid, running := DnsServerPid(r.pidPath)
+	if !running {
+		t.Fatal("expected DNS server to be running")
+	}
+
+	if err := other.AddHosts("other", "192.168.56.6"); err != nil {
+		t.Fatal(err)
+	}
+
+	if otherPid, _ := DnsServerPid(r.pidPath); otherPid != pid {
+		t.Error("expected the running DNS server to be reused")
+	}
+
+	records, err := ReadDnsServerRecords(r.recordsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"example.test":     "192.168.56.5",
+		"www.example.test": "192.168.56.5",
+		"other.test":       "192.168.56.6",
+	}
+
+	if !reflect.DeepEqual(records.Hosts(), expected) {
+		t.Errorf("expected %v, got %v", expected, records.Hosts())
+	}
+
+	if err := r.RemoveHosts("example"); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, _ := r.HasHosts("example"); ok {
+		t.Error("expected example hosts to be removed")
+	}
+
+	if _, running := DnsServerPid(r.pidPath); !running {
+		t.Error("expected DNS server to keep running while another VM uses it")
+	}
+
+	if err := other.RemoveHosts("other"); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 50 {
+		if _, running = DnsServerPid(r.pidPath); !running {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if running {
+		t.Error("expected DNS server to be stopped once no VM uses it")
+	}
+}
+
+func TestDnsServerResolverRegistrations(t *testing.T) {
+	r := newTestDnsServerResolver(t)
+	r.Hosts = []string{"example.test", "example.localhost", "www.example.test"}
+
+	if registrations := r.registrations(); len(registrations) != 2 {
+		t.Errorf("expected a registration per TLD, got %v", registrations)
+	}
+}
@@ -0,0 +1,76 @@
+package vm
+
+import "fmt"
+
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "pass"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+)
+
+// Check is the result of a `trellis vm doctor` diagnostic. Fix suggests how to
+// resolve a warning or failure.
+type Check struct {
+	Name    string
+	Status  CheckStatus
+	Message string
+	Fix     string
+}
+
+func PassCheck(name string, message string) Check {
+	return Check{Name: name, Status: CheckPass, Message: message}
+}
+
+func WarnCheck(name string, message string, fix string) Check {
+	return Check{Name: name, Status: CheckWarn, Message: message, Fix: fix}
+}
+
+func FailCheck(name string, message string, fix string) Check {
+	return Check{Name: name, Status: CheckFail, Message: message, Fix: fix}
+}
+
+/*
+HostsCheck diagnoses the hosts entries of an instance: a running VM needs them
+and a stopped one shouldn't have any left. With the hosts_file resolver, entries
+for the same hosts outside trellis-cli's blocks are reported too.
+*/
+func HostsCheck(resolver HostsResolver, name string, running bool) Check {
+	const checkName = "Hosts resolver"
+
+	hasHosts, err := resolver.HasHosts(name)
+	if err != nil {
+		return FailCheck(checkName, err.Error(), "Check the permissions of the hosts resolver's files.")
+	}
+
+	hostsFile, isHostsFile := resolver.(*HostsFileResolver)
+
+	if running && !hasHosts {
+		fix := "Restart the VM with `trellis vm stop && trellis vm start`."
+		if isHostsFile {
+			fix = "Run `trellis vm hosts sync`."
+		}
+		return FailCheck(checkName, "the running VM has no hosts entries", fix)
+	}
+
+	if !running && hasHosts {
+		fix := "Run `trellis vm stop` to remove them."
+		if isHostsFile {
+			fix = "Run `trellis vm hosts remove`."
+		}
+		return WarnCheck(checkName, "the VM isn't running but its hosts entries remain", fix)
+	}
+
+	if isHostsFile {
+		if conflicts, err := hostsFile.Conflicts(); err == nil && len(conflicts) > 0 {
+			return WarnCheck(checkName, fmt.Sprintf("%s has %d entries for the development hosts outside trellis-cli's blocks (eg: line %d)", hostsFile.Path(), len(conflicts), conflicts[0].Line), "Remove them so the hosts resolve to the VM (see `trellis vm hosts show`).")
+		}
+	}
+
+	if running {
+		return PassCheck(checkName, "hosts entries are present")
+	}
+
+	return PassCheck(checkName, "no hosts entries (the VM isn't running)")
+}
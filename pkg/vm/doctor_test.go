@@ -0,0 +1,51 @@
+package vm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHostsCheck(t *testing.T) {
+	tempDir := t.TempDir()
+	hostsPath := filepath.Join(tempDir, "hosts")
+
+	h := &HostsFileResolver{
+		Hosts:        []string{"example.test"},
+		hostsPath:    hostsPath,
+		tmpHostsPath: filepath.Join(tempDir, "hosts.tmp"),
+	}
+
+	withBlock := `127.0.0.1	localhost
+## trellis-start-example
+192.168.56.5 example.test
+## trellis-end-example
+`
+
+	cases := []struct {
+		name     string
+		content  string
+		running  bool
+		expected CheckStatus
+	}{
+		{"running_with_hosts", withBlock, true, CheckPass},
+		{"running_without_hosts", "127.0.0.1	localhost\n", true, CheckFail},
+		{"stopped_with_hosts", withBlock, false, CheckWarn},
+		{"stopped_without_hosts", "127.0.0.1	localhost\n", false, CheckPass},
+		{"conflict", "127.0.0.1	localhost\n10.0.0.1 example.test\n", false, CheckWarn},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := os.WriteFile(hostsPath, []byte(tc.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			check := HostsCheck(h, "example", tc.running)
+
+			if check.Status != tc.expected {
+				t.Errorf("expected %s got %s (%s)", tc.expected, check.Status, check.Message)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"slices"
 	"strings"
 
 	"github.com/roots/trellis-cli/app_paths"
@@ -17,13 +19,45 @@ var (
 	HostsAddErr    = errors.New("Error adding hosts")
 )
 
+// hostsManagerPrefix starts the line of a block recording the VM manager which wrote it.
+const hostsManagerPrefix = "## trellis-manager: "
+
+// trellisBlockPattern matches a `## trellis-start-NAME` ... `## trellis-end-NAME` block.
+var trellisBlockPattern = regexp.MustCompile(`(?m)^## trellis-start-(\S+)\n[\s\S]*?^## trellis-end-(\S+)\n`)
+
 type HostsResolver interface {
 	AddHosts(name string, ip string) error
 	RemoveHosts(name string) error
+	HasHosts(name string) (bool, error)
+}
+
+// SudoersCommander is implemented by resolvers which run commands with sudo
+// (see `trellis vm sudoers`).
+type SudoersCommander interface {
+	SudoersCommands() [][]string
+}
+
+// HostsBlock is a trellis-cli managed block of the hosts file.
+type HostsBlock struct {
+	Name string
+	// Manager is the VM manager which wrote the block (empty for blocks written
+	// before managers were recorded, which were all Lima's).
+	Manager string
+	Content string
+}
+
+// HostsConflict is an entry outside trellis-cli's blocks for some of the development hosts.
+type HostsConflict struct {
+	Line    int
+	Content string
+	Hosts   []string
 }
 
 type HostsFileResolver struct {
-	Hosts        []string
+	Hosts []string
+	// Manager is recorded in the blocks so `trellis vm hosts prune` knows which
+	// VM manager can tell whether the instance still exists.
+	Manager      string
 	hostsPath    string
 	tmpHostsPath string
 }
@@ -32,6 +66,13 @@ func NewHostsResolver(resolverType string, hosts []string) (resolver HostsResolv
 	switch resolverType {
 	case "hosts_file":
 		return NewHostsFileResolver(hosts), nil
+	case "dnsmasq", "resolved":
+		if runtime.GOOS != "linux" {
+			return nil, fmt.Errorf("The %s hosts resolver is only supported on Linux", resolverType)
+		}
+		return NewSplitDnsResolver(hosts, resolverType == "resolved"), nil
+	case "dns_server":
+		return NewDnsServerResolver(hosts), nil
 	default:
 		return nil, fmt.Errorf("Unknown hosts resolver type: %s", resolverType)
 	}
@@ -64,6 +105,99 @@ func (h *HostsFileResolver) RemoveHosts(name string) error {
 	return h.writeHostsFile(content)
 }
 
+// HasHosts reports whether the hosts file contains the instance's block.
+func (h *HostsFileResolver) HasHosts(name string) (bool, error) {
+	content, err := os.ReadFile(h.hostsPath)
+	if err != nil {
+		return false, fmt.Errorf("Error reading %s file: %v", h.hostsPath, err)
+	}
+
+	return strings.Contains(string(content), fmt.Sprintf("## trellis-start-%s\n", name)), nil
+}
+
+// Path is the hosts file managed by the resolver.
+func (h *HostsFileResolver) Path() string {
+	return h.hostsPath
+}
+
+// Blocks returns the trellis-cli managed blocks of every instance in the hosts file.
+func (h *HostsFileResolver) Blocks() ([]HostsBlock, error) {
+	content, err := os.ReadFile(h.hostsPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading %s file: %v", h.hostsPath, err)
+	}
+
+	blocks := []HostsBlock{}
+	for _, match := range trellisBlockPattern.FindAllStringSubmatch(string(content), -1) {
+		if match[1] == match[2] {
+			blocks = append(blocks, HostsBlock{Name: match[1], Manager: blockManager(match[0]), Content: match[0]})
+		}
+	}
+
+	return blocks, nil
+}
+
+// RemoveBlocks removes the blocks of the given instances with a single update of the hosts file.
+func (h *HostsFileResolver) RemoveBlocks(names []string) error {
+	content, err := os.ReadFile(h.hostsPath)
+	if err != nil {
+		return fmt.Errorf("%w: Error reading %s file: %v", HostsRemoveErr, h.hostsPath, err)
+	}
+
+	content = trellisBlockPattern.ReplaceAllFunc(content, func(block []byte) []byte {
+		match := trellisBlockPattern.FindSubmatch(block)
+		if string(match[1]) == string(match[2]) && slices.Contains(names, string(match[1])) {
+			return []byte{}
+		}
+		return block
+	})
+
+	return h.writeHostsFile(content)
+}
+
+// Conflicts returns entries outside trellis-cli's blocks which map any of the resolver's hosts.
+func (h *HostsFileResolver) Conflicts() ([]HostsConflict, error) {
+	content, err := os.ReadFile(h.hostsPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading %s file: %v", h.hostsPath, err)
+	}
+
+	conflicts := []HostsConflict{}
+	inBlock := false
+
+	for i, line := range strings.Split(string(content), "\n") {
+		switch {
+		case strings.HasPrefix(line, "## trellis-start-"):
+			inBlock = true
+			continue
+		case strings.HasPrefix(line, "## trellis-end-"):
+			inBlock = false
+			continue
+		case inBlock:
+			continue
+		}
+
+		entry, _, _ := strings.Cut(line, "#")
+		fields := strings.Fields(entry)
+		if len(fields) < 2 {
+			continue
+		}
+
+		hosts := []string{}
+		for _, host := range fields[1:] {
+			if slices.ContainsFunc(h.Hosts, func(h string) bool { return strings.EqualFold(h, host) }) {
+				hosts = append(hosts, host)
+			}
+		}
+
+		if len(hosts) > 0 {
+			conflicts = append(conflicts, HostsConflict{Line: i + 1, Content: strings.TrimSpace(line), Hosts: hosts})
+		}
+	}
+
+	return conflicts, nil
+}
+
 func (h *HostsFileResolver) addHostsContent(name string, ip string) (content []byte, err error) {
 	content, err = h.removeHostsContent(name)
 	if err != nil {
@@ -79,6 +213,10 @@ func (h *HostsFileResolver) addHostsContent(name string, ip string) (content []b
 	return content, nil
 }
 
+func (h *HostsFileResolver) SudoersCommands() [][]string {
+	return [][]string{h.SudoersCommand()}
+}
+
 func (h *HostsFileResolver) SudoersCommand() []string {
 	return []string{"/bin/cp", h.tmpHostsPath, h.hostsPath}
 }
@@ -114,10 +252,25 @@ func (h *HostsFileResolver) writeHostsFile(content []byte) error {
 }
 
 func (h *HostsFileResolver) generateHosts(name string, ip string) (string, error) {
+	manager := ""
+	if h.Manager != "" {
+		manager = fmt.Sprintf("%s%s\n", hostsManagerPrefix, h.Manager)
+	}
+
 	content := fmt.Sprintf(`## trellis-start-%s
-%s %s
+%s%s %s
 ## trellis-end-%s
-`, name, ip, strings.Join(h.Hosts, " "), name)
+`, name, manager, ip, strings.Join(h.Hosts, " "), name)
 
 	return content, nil
 }
+
+func blockManager(block string) string {
+	for _, line := range strings.Split(block, "\n") {
+		if manager, ok := strings.CutPrefix(line, hostsManagerPrefix); ok {
+			return strings.TrimSpace(manager)
+		}
+	}
+
+	return ""
+}
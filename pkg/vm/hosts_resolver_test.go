@@ -3,7 +3,11 @@ package vm
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/roots/trellis-cli/command"
 )
 
 func TestRemoveHostsContent(t *testing.T) {
@@ -391,3 +395,117 @@ func TestAddHostsContent(t *testing.T) {
 		})
 	}
 }
+
+func TestHasHosts(t *testing.T) {
+	tempDir := t.TempDir()
+	hostsPath := filepath.Join(tempDir, "hosts")
+
+	h := HostsFileResolver{
+		hostsPath:    hostsPath,
+		tmpHostsPath: filepath.Join(tempDir, "hosts.tmp"),
+	}
+
+	content := `127.0.0.1	localhost
+## trellis-start-foo-bar
+192.168.2.1 example.test www.example.test
+## trellis-end-foo-bar
+`
+
+	if err := os.WriteFile(hostsPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, expected := range map[string]bool{"foo-bar": true, "foo": false, "nope": false} {
+		present, err := h.HasHosts(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if present != expected {
+			t.Errorf("%s: expected %t, got %t", name, expected, present)
+		}
+	}
+}
+
+func TestHostsBlocks(t *testing.T) {
+	tempDir := t.TempDir()
+	hostsPath := filepath.Join(tempDir, "hosts")
+
+	h := HostsFileResolver{
+		Hosts:        []string{"example.test", "www.example.test"},
+		hostsPath:    hostsPath,
+		tmpHostsPath: filepath.Join(tempDir, "hosts.tmp"),
+	}
+
+	content := `127.0.0.1	localhost
+127.0.0.1 example.test # left over from valet
+## trellis-start-foo
+## trellis-manager: ssh
+192.168.2.1 example.test www.example.test
+## trellis-end-foo
+## trellis-start-old
+192.168.2.2 old.test
+## trellis-end-old
+# 10.0.0.1 www.example.test
+10.0.0.1 other.test WWW.example.test
+`
+
+	if err := os.WriteFile(hostsPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	blocks, err := h.Blocks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedBlocks := []HostsBlock{
+		{Name: "foo", Manager: "ssh", Content: "## trellis-start-foo\n## trellis-manager: ssh\n192.168.2.1 example.test www.example.test\n## trellis-end-foo\n"},
+		{Name: "old", Content: "## trellis-start-old\n192.168.2.2 old.test\n## trellis-end-old\n"},
+	}
+
+	if !reflect.DeepEqual(blocks, expectedBlocks) {
+		t.Errorf("expected %v, got %v", expectedBlocks, blocks)
+	}
+
+	conflicts, err := h.Conflicts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedConflicts := []HostsConflict{
+		{Line: 2, Content: "127.0.0.1 example.test # left over from valet", Hosts: []string{"example.test"}},
+		{Line: 11, Content: "10.0.0.1 other.test WWW.example.test", Hosts: []string{"WWW.example.test"}},
+	}
+
+	if !reflect.DeepEqual(conflicts, expectedConflicts) {
+		t.Errorf("expected %v, got %v", expectedConflicts, conflicts)
+	}
+
+	defer command.MockExecCommands(t, []command.MockCommand{
+		{
+			Command: "sudo",
+			Args:    h.SudoersCommand(),
+		},
+	})()
+
+	if err := h.RemoveBlocks([]string{"old"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpContent, err := os.ReadFile(h.tmpHostsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Replace(content, expectedBlocks[1].Content, "", 1)
+	if string(tmpContent) != expected {
+		t.Errorf("expected %q, got %q", expected, string(tmpContent))
+	}
+
+	h.Manager = "ssh"
+	generated, _ := h.generateHosts("foo", "192.168.2.1")
+	if generated != expectedBlocks[0].Content {
+		t.Errorf("expected generated block %q, got %q", expectedBlocks[0].Content, generated)
+	}
+}
@@ -0,0 +1,168 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/roots/trellis-cli/command"
+)
+
+// DataSnapshotsDir is where database and uploads snapshots are stored inside the VM.
+const DataSnapshotsDir = "/var/lib/trellis/snapshots"
+
+var (
+	ErrInvalidSnapshotName = errors.New("invalid snapshot name. Use letters, numbers, dots, dashes and underscores (eg: before-upgrade)")
+	snapshotNamePattern    = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+)
+
+type Snapshot struct {
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// Snapshotter creates and restores checkpoints of a VM instance.
+type Snapshotter interface {
+	// SnapshotKind describes what a snapshot contains (eg: "full VM").
+	SnapshotKind() string
+	CreateSnapshot(instanceName string, name string) error
+	RestoreSnapshot(instanceName string, name string) error
+	DeleteSnapshot(instanceName string, name string) error
+	ListSnapshots(instanceName string) ([]Snapshot, error)
+}
+
+// NativeSnapshotter is implemented by managers which support snapshots of the
+// whole VM for some instances (eg: Lima for QEMU VMs).
+type NativeSnapshotter interface {
+	Snapshotter
+	SnapshotsSupported(instanceName string) bool
+}
+
+func ValidateSnapshotName(name string) error {
+	if !snapshotNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidSnapshotName, name)
+	}
+
+	return nil
+}
+
+/*
+DataSnapshotter is the fallback for VMs without native snapshots. A snapshot
+contains a database export and an uploads archive of each site, stored inside
+the VM in DataSnapshotsDir/NAME. Restoring replaces the sites' databases and
+uploads directories (web/app/uploads) with the snapshot's contents.
+*/
+type DataSnapshotter struct {
+	Manager Manager
+	Sites   []string
+}
+
+func NewDataSnapshotter(manager Manager, sites []string) *DataSnapshotter {
+	return &DataSnapshotter{Manager: manager, Sites: sites}
+}
+
+func (s *DataSnapshotter) SnapshotKind() string {
+	return "database and uploads"
+}
+
+func (s *DataSnapshotter) CreateSnapshot(instanceName string, name string) error {
+	dir := snapshotDir(name)
+
+	script := []string{
+		"set -e",
+		fmt.Sprintf(`if [ -e %s ]; then echo "Snapshot %s already exists." >&2; exit 1; fi`, dir, name),
+		fmt.Sprintf(`sudo mkdir -p %s && sudo chown "$(id -u):$(id -g)" %s`, dir, dir),
+	}
+
+	for _, site := range s.Sites {
+		siteDir := siteDir(site)
+		script = append(script,
+			fmt.Sprintf("wp db export %s --path=%s/web/wp --quiet", snapshotFile(name, site+".sql"), siteDir),
+			fmt.Sprintf("if [ -d %s/web/app/uploads ]; then tar -czf %s -C %s/web/app uploads; fi", siteDir, snapshotFile(name, site+"-uploads.tar.gz"), siteDir),
+		)
+	}
+
+	script = append(script, fmt.Sprintf("date -u +%%Y-%%m-%%dT%%H:%%M:%%SZ > %s", snapshotFile(name, "created_at")))
+
+	return s.run(script)
+}
+
+func (s *DataSnapshotter) RestoreSnapshot(instanceName string, name string) error {
+	dir := snapshotDir(name)
+
+	script := []string{
+		"set -e",
+		fmt.Sprintf(`if [ ! -d %s ]; then echo "Snapshot %s not found." >&2; exit 1; fi`, dir, name),
+	}
+
+	for _, site := range s.Sites {
+		siteDir := siteDir(site)
+		sqlFile := snapshotFile(name, site+".sql")
+		uploadsFile := snapshotFile(name, site+"-uploads.tar.gz")
+
+		script = append(script,
+			fmt.Sprintf("if [ -f %s ]; then wp db reset --yes --path=%s/web/wp --quiet && wp db import %s --path=%s/web/wp --quiet; fi", sqlFile, siteDir, sqlFile, siteDir),
+			fmt.Sprintf("if [ -f %s ]; then rm -rf %s/web/app/uploads && tar -xzf %s -C %s/web/app; fi", uploadsFile, siteDir, uploadsFile, siteDir),
+		)
+	}
+
+	return s.run(script)
+}
+
+func (s *DataSnapshotter) DeleteSnapshot(instanceName string, name string) error {
+	dir := snapshotDir(name)
+
+	return s.run([]string{
+		"set -e",
+		fmt.Sprintf(`if [ ! -d %s ]; then echo "Snapshot %s not found." >&2; exit 1; fi`, dir, name),
+		fmt.Sprintf("sudo rm -rf %s", dir),
+	})
+}
+
+func (s *DataSnapshotter) ListSnapshots(instanceName string) ([]Snapshot, error) {
+	script := fmt.Sprintf(`for dir in %s/*/; do [ -d "$dir" ] || continue; printf '%%s\t%%s\n' "$(basename "$dir")" "$(cat "$dir/created_at" 2>/dev/null)"; done`, DataSnapshotsDir)
+
+	cmd, err := s.Manager.RunCommandPipe([]string{"bash", "-c", script}, "")
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDataSnapshots(string(output)), nil
+}
+
+func (s *DataSnapshotter) run(script []string) error {
+	return s.Manager.RunCommand([]string{"bash", "-c", strings.Join(script, "\n")}, "")
+}
+
+func parseDataSnapshots(output string) []Snapshot {
+	snapshots := []Snapshot{}
+
+	for line := range strings.SplitSeq(output, "\n") {
+		name, createdAt, _ := strings.Cut(strings.TrimSpace(line), "\t")
+		if name == "" {
+			continue
+		}
+
+		snapshots = append(snapshots, Snapshot{Name: name, CreatedAt: strings.TrimSpace(createdAt)})
+	}
+
+	return snapshots
+}
+
+func siteDir(site string) string {
+	return command.ShellQuote("/srv/www/" + site + "/current")
+}
+
+func snapshotDir(name string) string {
+	return command.ShellQuote(DataSnapshotsDir + "/" + name)
+}
+
+func snapshotFile(name string, file string) string {
+	return command.ShellQuote(DataSnapshotsDir + "/" + name + "/" + file)
+}
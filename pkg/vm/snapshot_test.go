@@ -0,0 +1,89 @@
+package vm
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type scriptRecorder struct {
+	MockVmManager
+	scripts []string
+}
+
+func (m *scriptRecorder) RunCommand(args []string, dir string) error {
+	m.scripts = append(m.scripts, args[len(args)-1])
+	return nil
+}
+
+func TestValidateSnapshotName(t *testing.T) {
+	for _, name := range []string{"before-upgrade", "v1.2", "snap_1"} {
+		if err := ValidateSnapshotName(name); err != nil {
+			t.Errorf("expected %q to be valid, got %v", name, err)
+		}
+	}
+
+	for _, name := range []string{"", "-flag", "../etc", "with space", "a/b"} {
+		if err := ValidateSnapshotName(name); !errors.Is(err, ErrInvalidSnapshotName) {
+			t.Errorf("expected %q to be invalid, got %v", name, err)
+		}
+	}
+}
+
+func TestDataSnapshotter(t *testing.T) {
+	manager := &scriptRecorder{}
+	snapshotter := NewDataSnapshotter(manager, []string{"example.com"})
+
+	if err := snapshotter.CreateSnapshot("example", "before-upgrade"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := snapshotter.RestoreSnapshot("example", "before-upgrade"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := snapshotter.DeleteSnapshot("example", "before-upgrade"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(manager.scripts) != 3 {
+		t.Fatalf("expected 3 scripts to run, got %d", len(manager.scripts))
+	}
+
+	expected := [][]string{
+		{
+			"wp db export '/var/lib/trellis/snapshots/before-upgrade/example.com.sql' --path='/srv/www/example.com/current'/web/wp --quiet",
+			"tar -czf '/var/lib/trellis/snapshots/before-upgrade/example.com-uploads.tar.gz' -C '/srv/www/example.com/current'/web/app uploads",
+			"> '/var/lib/trellis/snapshots/before-upgrade/created_at'",
+		},
+		{
+			"wp db reset --yes --path='/srv/www/example.com/current'/web/wp --quiet && wp db import '/var/lib/trellis/snapshots/before-upgrade/example.com.sql'",
+			"rm -rf '/srv/www/example.com/current'/web/app/uploads && tar -xzf '/var/lib/trellis/snapshots/before-upgrade/example.com-uploads.tar.gz'",
+		},
+		{
+			"sudo rm -rf '/var/lib/trellis/snapshots/before-upgrade'",
+		},
+	}
+
+	for i, script := range manager.scripts {
+		for _, line := range expected[i] {
+			if !strings.Contains(script, line) {
+				t.Errorf("expected script to contain %q\ngot %s", line, script)
+			}
+		}
+	}
+}
+
+func TestParseDataSnapshots(t *testing.T) {
+	output := "before-upgrade\t2026-01-02T03:04:05Z\nbroken\t\n\n"
+
+	expected := []Snapshot{
+		{Name: "before-upgrade", CreatedAt: "2026-01-02T03:04:05Z"},
+		{Name: "broken"},
+	}
+
+	if snapshots := parseDataSnapshots(output); !reflect.DeepEqual(snapshots, expected) {
+		t.Errorf("expected %v, got %v", expected, snapshots)
+	}
+}
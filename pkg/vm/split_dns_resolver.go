@@ -0,0 +1,232 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/roots/trellis-cli/app_paths"
+	"github.com/roots/trellis-cli/command"
+)
+
+const (
+	// splitDnsListenAddress is where dnsmasq listens when systemd-resolved
+	// forwards the development TLDs to it (127.0.0.53 is used by resolved itself).
+	splitDnsListenAddress = "127.0.0.153"
+	dnsmasqConfigPath     = "/etc/dnsmasq.d/trellis.conf"
+	resolvedConfigPath    = "/etc/systemd/resolved.conf.d/trellis.conf"
+)
+
+/*
+SplitDnsResolver resolves development hosts with dnsmasq instead of /etc/hosts.
+Each instance gets a block of `address=/HOST/IP` entries in a single dnsmasq
+config file; dnsmasq answers for a host and all of its subdomains, so multisite
+subdomains work without listing them.
+
+With Resolved set (the `resolved` hosts resolver), dnsmasq listens on its own
+address and a systemd-resolved drop-in routes the development TLDs (eg: `.test`)
+to it. Otherwise (the `dnsmasq` hosts resolver) dnsmasq is expected to already
+be the system's resolver.
+*/
+type SplitDnsResolver struct {
+	Hosts           []string
+	Resolved        bool
+	configPath      string
+	tmpConfigPath   string
+	resolvedPath    string
+	tmpResolvedPath string
+	systemctlPath   string
+	installPath     string
+}
+
+func NewSplitDnsResolver(hosts []string, resolved bool) *SplitDnsResolver {
+	return &SplitDnsResolver{
+		Hosts:           hosts,
+		Resolved:        resolved,
+		configPath:      dnsmasqConfigPath,
+		tmpConfigPath:   filepath.Join(app_paths.DataDir(), "dnsmasq.conf"),
+		resolvedPath:    resolvedConfigPath,
+		tmpResolvedPath: filepath.Join(app_paths.DataDir(), "resolved.conf"),
+		systemctlPath:   "/usr/bin/systemctl",
+		installPath:     "/usr/bin/install",
+	}
+}
+
+func (r *SplitDnsResolver) AddHosts(name string, ip string) error {
+	blocks, err := r.readBlocks()
+	if err != nil {
+		return fmt.Errorf("%w: %v", HostsAddErr, err)
+	}
+
+	blocks[name] = r.generateBlock(name, ip)
+
+	if err := r.writeConfig(blocks); err != nil {
+		return fmt.Errorf("%w: %v", HostsAddErr, err)
+	}
+
+	if r.Resolved {
+		if err := r.writeResolvedConfig(); err != nil {
+			return fmt.Errorf("%w: %v", HostsAddErr, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *SplitDnsResolver) RemoveHosts(name string) error {
+	blocks, err := r.readBlocks()
+	if err != nil {
+		return fmt.Errorf("%w: %v", HostsRemoveErr, err)
+	}
+
+	if _, ok := blocks[name]; !ok {
+		return nil
+	}
+
+	delete(blocks, name)
+
+	if err := r.writeConfig(blocks); err != nil {
+		return fmt.Errorf("%w: %v", HostsRemoveErr, err)
+	}
+
+	return nil
+}
+
+// HasHosts reports whether the dnsmasq config contains the instance's block.
+func (r *SplitDnsResolver) HasHosts(name string) (bool, error) {
+	blocks, err := r.readBlocks()
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := blocks[name]
+	return ok, nil
+}
+
+func (r *SplitDnsResolver) SudoersCommands() [][]string {
+	commands := [][]string{
+		r.installCommand(r.tmpConfigPath, r.configPath),
+		r.restartCommand("dnsmasq"),
+	}
+
+	if r.Resolved {
+		commands = append(commands,
+			r.installCommand(r.tmpResolvedPath, r.resolvedPath),
+			r.restartCommand("systemd-resolved"),
+		)
+	}
+
+	return commands
+}
+
+// readBlocks returns the existing instance blocks in the dnsmasq config by instance name.
+func (r *SplitDnsResolver) readBlocks() (map[string]string, error) {
+	blocks := map[string]string{}
+
+	content, err := os.ReadFile(r.configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return blocks, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error reading %s file: %v", r.configPath, err)
+	}
+
+	for _, match := range trellisBlockPattern.FindAllStringSubmatch(string(content), -1) {
+		if match[1] == match[2] {
+			blocks[match[1]] = match[0]
+		}
+	}
+
+	return blocks, nil
+}
+
+func (r *SplitDnsResolver) generateConfig(blocks map[string]string) string {
+	var content strings.Builder
+
+	content.WriteString("# Managed by trellis-cli. Blocks are added by `trellis vm start` and removed by `trellis vm stop`.\n")
+
+	if r.Resolved {
+		content.WriteString(fmt.Sprintf("listen-address=%s\nbind-interfaces\n", splitDnsListenAddress))
+	}
+
+	names := make([]string, 0, len(blocks))
+	for name := range blocks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		content.WriteString(blocks[name])
+	}
+
+	return content.String()
+}
+
+func (r *SplitDnsResolver) generateBlock(name string, ip string) string {
+	var block strings.Builder
+
+	block.WriteString(fmt.Sprintf("## trellis-start-%s\n", name))
+	for _, host := range r.Hosts {
+		block.WriteString(fmt.Sprintf("address=/%s/%s\n", host, ip))
+	}
+	block.WriteString(fmt.Sprintf("## trellis-end-%s\n", name))
+
+	return block.String()
+}
+
+// generateResolvedConfig routes the hosts' top-level domains to dnsmasq.
+func (r *SplitDnsResolver) generateResolvedConfig() string {
+	domains := []string{}
+	for _, tld := range topLevelDomains(r.Hosts) {
+		domains = append(domains, "~"+tld)
+	}
+
+	return fmt.Sprintf(`# Managed by trellis-cli
+[Resolve]
+DNS=%s
+Domains=%s
+`, splitDnsListenAddress, strings.Join(domains, " "))
+}
+
+func (r *SplitDnsResolver) writeConfig(blocks map[string]string) error {
+	return r.install(r.tmpConfigPath, r.configPath, r.generateConfig(blocks), "dnsmasq")
+}
+
+func (r *SplitDnsResolver) writeResolvedConfig() error {
+	return r.install(r.tmpResolvedPath, r.resolvedPath, r.generateResolvedConfig(), "systemd-resolved")
+}
+
+// install copies content to a root-owned config file and restarts the service
+// using it. Nothing is done if the file already has the content.
+func (r *SplitDnsResolver) install(tmpPath string, path string, content string, service string) error {
+	if existing, err := os.ReadFile(path); err == nil && string(existing) == content {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(tmpPath), 0755); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(tmpPath, []byte(content), 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nUpdating %s (sudo may be required, see `trellis vm sudoers` for more details)\n", path)
+
+	if err := command.WithOptions(command.WithTermOutput()).Cmd("sudo", r.installCommand(tmpPath, path)).Run(); err != nil {
+		return err
+	}
+
+	return command.WithOptions(command.WithTermOutput()).Cmd("sudo", r.restartCommand(service)).Run()
+}
+
+func (r *SplitDnsResolver) installCommand(src string, dst string) []string {
+	return []string{r.installPath, "-D", "-m", "0644", src, dst}
+}
+
+func (r *SplitDnsResolver) restartCommand(service string) []string {
+	return []string{r.systemctlPath, "restart", service}
+}
@@ -0,0 +1,147 @@
+package vm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/roots/trellis-cli/command"
+)
+
+func newTestSplitDnsResolver(t *testing.T, resolved bool) *SplitDnsResolver {
+	t.Helper()
+	tempDir := t.TempDir()
+
+	return &SplitDnsResolver{
+		Hosts:           []string{"example.test", "www.example.test"},
+		Resolved:        resolved,
+		configPath:      filepath.Join(tempDir, "trellis.conf"),
+		tmpConfigPath:   filepath.Join(tempDir, "trellis.conf.tmp"),
+		resolvedPath:    filepath.Join(tempDir, "resolved.conf"),
+		tmpResolvedPath: filepath.Join(tempDir, "resolved.conf.tmp"),
+		systemctlPath:   "/usr/bin/systemctl",
+		installPath:     "/usr/bin/install",
+	}
+}
+
+func TestSplitDnsResolverBlocks(t *testing.T) {
+	r := newTestSplitDnsResolver(t, false)
+
+	content := `# Managed by trellis-cli. Blocks are added by ` + "`trellis vm start` and removed by `trellis vm stop`" + `.
+## trellis-start-other
+address=/other.test/192.168.56.6
+## trellis-end-other
+`
+	if err := os.WriteFile(r.configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	blocks, err := r.readBlocks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, _ := r.HasHosts("other"); !ok {
+		t.Error("expected block for other instance")
+	}
+
+	if ok, _ := r.HasHosts("example"); ok {
+		t.Error("expected no block for example instance")
+	}
+
+	blocks["example"] = r.generateBlock("example", "192.168.56.5")
+
+	// blocks are sorted by instance name
+	expected := `# Managed by trellis-cli. Blocks are added by ` + "`trellis vm start` and removed by `trellis vm stop`" + `.
+## trellis-start-example
+address=/example.test/192.168.56.5
+address=/www.example.test/192.168.56.5
+## trellis-end-example
+## trellis-start-other
+address=/other.test/192.168.56.6
+## trellis-end-other
+`
+
+	if config := r.generateConfig(blocks); config != expected {
+		t.Errorf("expected config\n%s\ngot\n%s", expected, config)
+	}
+}
+
+func TestSplitDnsResolverResolvedConfig(t *testing.T) {
+	r := newTestSplitDnsResolver(t, true)
+	r.Hosts = append(r.Hosts, "example.localhost")
+
+	expected := `# Managed by trellis-cli
+[Resolve]
+DNS=127.0.0.153
+Domains=~localhost ~test
+`
+
+	if config := r.generateResolvedConfig(); config != expected {
+		t.Errorf("expected config\n%s\ngot\n%s", expected, config)
+	}
+
+	header := "listen-address=127.0.0.153\nbind-interfaces\n"
+	if config := r.generateConfig(map[string]string{}); config[len(config)-len(header):] != header {
+		t.Errorf("expected dnsmasq config to listen on 127.0.0.153, got\n%s", config)
+	}
+}
+
+func TestSplitDnsResolverAddHosts(t *testing.T) {
+	r := newTestSplitDnsResolver(t, true)
+
+	defer command.MockExecCommands(t, []command.MockCommand{
+		{
+			Command: "sudo",
+			Args:    []string{"/usr/bin/install", "-D", "-m", "0644", r.tmpConfigPath, r.configPath},
+		},
+		{
+			Command: "sudo",
+			Args:    []string{"/usr/bin/systemctl", "restart", "dnsmasq"},
+		},
+		{
+			Command: "sudo",
+			Args:    []string{"/usr/bin/install", "-D", "-m", "0644", r.tmpResolvedPath, r.resolvedPath},
+		},
+		{
+			Command: "sudo",
+			Args:    []string{"/usr/bin/systemctl", "restart", "systemd-resolved"},
+		},
+	})()
+
+	if err := r.AddHosts("example", "192.168.56.5"); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpConfig, err := os.ReadFile(r.tmpConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := map[string]string{"example": r.generateBlock("example", "192.168.56.5")}
+	if string(tmpConfig) != r.generateConfig(blocks) {
+		t.Errorf("unexpected dnsmasq config:\n%s", tmpConfig)
+	}
+
+	if _, err := os.Stat(r.tmpResolvedPath); err != nil {
+		t.Errorf("expected resolved config to be written: %v", err)
+	}
+}
+
+func TestSplitDnsResolverSudoersCommands(t *testing.T) {
+	r := newTestSplitDnsResolver(t, false)
+
+	expected := [][]string{
+		{"/usr/bin/install", "-D", "-m", "0644", r.tmpConfigPath, r.configPath},
+		{"/usr/bin/systemctl", "restart", "dnsmasq"},
+	}
+
+	if commands := r.SudoersCommands(); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected %v, got %v", expected, commands)
+	}
+}
+
+func TestCommandHelperProcess(t *testing.T) {
+	command.CommandHelperProcess(t)
+}
@@ -3,6 +3,8 @@ package vm
 import (
 	"errors"
 	"os/exec"
+
+	"github.com/roots/trellis-cli/pkg/file_sync"
 )
 
 var (
@@ -21,3 +23,33 @@ type Manager interface {
 	Copy(srcInVm string, dstOnHost string) error
 	ReadRootFile(remotePath string) ([]byte, error)
 }
+
+// ConfigUpdater is implemented by managers which can detect and apply changes to
+// the VM settings in the CLI config (eg: CPUs or memory) for an existing VM.
+type ConfigUpdater interface {
+	ConfigChanges(name string) ([]string, error)
+	ApplyConfigChanges(name string) error
+}
+
+// IPProvider is implemented by managers which can look up the IP address of a
+// running VM (eg: to repair its hosts entries).
+type IPProvider interface {
+	InstanceIP(name string) (string, error)
+}
+
+// FileSyncer is implemented by managers which can keep a copy of the site
+// directories inside the VM in sync (`vm.sync_mode: rsync`) instead of mounting them.
+type FileSyncer interface {
+	SyncMode() string
+	SyncExcludes() []string
+	SyncStatus(name string) (*file_sync.Status, error)
+	FlushSync(name string) (*file_sync.Status, error)
+	SyncDaemon(name string) (*file_sync.Daemon, error)
+}
+
+// ConfigGenerator is implemented by managers which generate a config file for
+// the VM (eg: Lima's lima.yaml) so it can be inspected.
+type ConfigGenerator interface {
+	GenerateConfig(name string) ([]byte, error)
+	OverridePath() string
+}
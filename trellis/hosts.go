@@ -1,38 +1,96 @@
 package trellis
 
 import (
-	"html/template"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 )
 
-const Template = `
-[{{ .Environment }}]
-{{ .Host }}
-
-[web]
-{{ .Host }}
-`
-
-type Hosts struct {
-	Environment string
-	Host        string
+func (t *Trellis) InventoryPath(env string) string {
+	return filepath.Join(t.Path, "hosts", env)
 }
 
+// UpdateHosts sets the environment's inventory to a single web server.
 func (t *Trellis) UpdateHosts(env string, ip string) (path string, err error) {
-	path = filepath.Join(t.Path, "hosts", env)
-	hosts := Hosts{env, ip}
-	tpl := template.Must(template.New("hosts").Parse(Template))
+	return t.UpdateHostGroups(env, map[string][]string{"web": {ip}})
+}
+
+/*
+UpdateHostGroups writes the hosts of each group (eg: web, db) into the
+environment's inventory file. The environment group keeps its existing hosts
+which are still listed in another group (eg: web servers when only adding a db
+server) and gets the new hosts. Any other existing groups, host variables, and
+comments are kept.
+*/
+func (t *Trellis) UpdateHostGroups(env string, groups map[string][]string) (path string, err error) {
+	path = t.InventoryPath(env)
 
-	file, err := os.Create(path)
+	inventory, err := ReadInventory(path)
 	if err != nil {
 		return "", err
 	}
 
-	err = tpl.Execute(file, hosts)
-	if err != nil {
+	groupNames := make([]string, 0, len(groups))
+	for group := range groups {
+		groupNames = append(groupNames, group)
+	}
+	sort.Strings(groupNames)
+
+	// web is always listed first to match Trellis' default inventory layout
+	if i := slices.Index(groupNames, "web"); i > 0 {
+		groupNames = append([]string{"web"}, slices.Delete(groupNames, i, i+1)...)
+	}
+
+	// Creates the environment group (first) if it doesn't exist yet
+	previousEnvHosts := inventory.GroupHosts(env)
+	inventory.SetGroupHosts(env, previousEnvHosts)
+
+	for _, group := range groupNames {
+		inventory.SetGroupHosts(group, groups[group])
+	}
+
+	listedHosts := []string{}
+	for _, group := range inventory.Groups() {
+		if group != env {
+			listedHosts = append(listedHosts, inventory.GroupHosts(group)...)
+		}
+	}
+
+	envHosts := []string{}
+	for _, host := range previousEnvHosts {
+		if slices.Contains(listedHosts, host) && !slices.Contains(envHosts, host) {
+			envHosts = append(envHosts, host)
+		}
+	}
+
+	for _, group := range groupNames {
+		for _, host := range groups[group] {
+			if !slices.Contains(envHosts, host) {
+				envHosts = append(envHosts, host)
+			}
+		}
+	}
+
+	inventory.SetGroupHosts(env, envHosts)
+
+	if err := os.WriteFile(path, inventory.Bytes(), 0644); err != nil {
 		return "", err
 	}
 
 	return path, nil
 }
+
+// SetHostVar sets an inline variable for a host in the environment's group (see Inventory.SetHostVar).
+func (t *Trellis) SetHostVar(env string, host string, key string, value string) error {
+	path := t.InventoryPath(env)
+
+	inventory, err := ReadInventory(path)
+	if err != nil {
+		return err
+	}
+
+	inventory.SetHostVar(env, host, key, value)
+
+	return os.WriteFile(path, inventory.Bytes(), 0644)
+}
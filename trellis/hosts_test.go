@@ -6,7 +6,7 @@ import (
 )
 
 func TestUpdateHosts(t *testing.T) {
-	defer TestChdir(t, "testdata/trellis")()
+	defer LoadFixtureProject(t)()
 
 	trellis := NewTrellis()
 
@@ -15,6 +15,10 @@ func TestUpdateHosts(t *testing.T) {
 		t.Fatalf("Could not load Trellis project: %s", err)
 	}
 
+	if err := os.Remove("hosts/production"); err != nil {
+		t.Fatal(err)
+	}
+
 	hostsFile, err := trellis.UpdateHosts("production", "1.2.3.4")
 	if err != nil {
 		t.Fatal(err)
@@ -37,3 +41,127 @@ func TestUpdateHosts(t *testing.T) {
 		t.Errorf("expected hosts contents to be %s, but got %s", hostsContent, string(content))
 	}
 }
+
+func TestUpdateHostGroups(t *testing.T) {
+	defer LoadFixtureProject(t)()
+
+	trellis := NewTrellis()
+
+	err := trellis.LoadProject()
+	if err != nil {
+		t.Fatalf("Could not load Trellis project: %s", err)
+	}
+
+	existing := `# Add each host to the [staging] group and to a "type" group such as [web] or [db].
+# List each machine only once per [group], even if it will host multiple sites.
+
+[staging]
+your_server_hostname
+
+[web]
+# primary web server
+1.1.1.1 ansible_user=admin
+your_server_hostname
+
+[monitoring]
+9.9.9.9
+
+[staging:vars]
+ansible_python_interpreter=/usr/bin/python3
+`
+
+	if err := os.WriteFile("hosts/staging", []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hostsFile, err := trellis.UpdateHostGroups("staging", map[string][]string{
+		"web": {"1.1.1.1", "2.2.2.2"},
+		"db":  {"3.3.3.3"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(hostsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const hostsContent = `# Add each host to the [staging] group and to a "type" group such as [web] or [db].
+# List each machine only once per [group], even if it will host multiple sites.
+
+[staging]
+1.1.1.1
+2.2.2.2
+3.3.3.3
+
+[web]
+# primary web server
+1.1.1.1 ansible_user=admin
+2.2.2.2
+
+[monitoring]
+9.9.9.9
+
+[staging:vars]
+ansible_python_interpreter=/usr/bin/python3
+
+[db]
+3.3.3.3
+`
+
+	if hostsContent != string(content) {
+		t.Errorf("expected hosts contents to be %s, but got %s", hostsContent, string(content))
+	}
+}
+
+func TestUpdateHostGroupsKeepsOtherGroupHosts(t *testing.T) {
+	defer LoadFixtureProject(t)()
+
+	trellis := NewTrellis()
+
+	err := trellis.LoadProject()
+	if err != nil {
+		t.Fatalf("Could not load Trellis project: %s", err)
+	}
+
+	existing := `[production]
+1.1.1.1
+2.2.2.2
+
+[web]
+1.1.1.1
+2.2.2.2
+`
+
+	if err := os.WriteFile("hosts/production", []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hostsFile, err := trellis.UpdateHostGroups("production", map[string][]string{"db": {"3.3.3.3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(hostsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const hostsContent = `[production]
+1.1.1.1
+2.2.2.2
+3.3.3.3
+
+[web]
+1.1.1.1
+2.2.2.2
+
+[db]
+3.3.3.3
+`
+
+	if hostsContent != string(content) {
+		t.Errorf("expected hosts contents to be %s, but got %s", hostsContent, string(content))
+	}
+}
@@ -0,0 +1,256 @@
+package trellis
+
+import (
+	"bytes"
+	"os"
+	"slices"
+	"strings"
+)
+
+/*
+Inventory is a minimal representation of an Ansible INI inventory file (eg: hosts/production).
+It only understands enough of the format to read and replace the hosts of a group;
+everything else (comments, blank lines, `:vars` and `:children` sections) is preserved as-is.
+*/
+type Inventory struct {
+	sections []*inventorySection
+}
+
+type inventorySection struct {
+	// name is empty for the lines before the first section header
+	name  string
+	lines []string
+}
+
+func ParseInventory(content []byte) *Inventory {
+	inventory := &Inventory{
+		sections: []*inventorySection{{}},
+	}
+
+	content = bytes.TrimSuffix(content, []byte("\n"))
+	if len(content) == 0 {
+		return inventory
+	}
+
+	section := inventory.sections[0]
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if name, ok := parseSectionHeader(line); ok {
+			section = &inventorySection{name: name}
+			inventory.sections = append(inventory.sections, section)
+		}
+
+		section.lines = append(section.lines, line)
+	}
+
+	return inventory
+}
+
+func ReadInventory(path string) (*Inventory, error) {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return ParseInventory(content), nil
+}
+
+func (i *Inventory) Groups() []string {
+	groups := []string{}
+
+	for _, section := range i.sections {
+		if section.name != "" && !strings.Contains(section.name, ":") {
+			groups = append(groups, section.name)
+		}
+	}
+
+	return groups
+}
+
+// GroupHosts returns the host names (without any inline host variables) in a group.
+func (i *Inventory) GroupHosts(group string) []string {
+	hosts := []string{}
+
+	section := i.section(group)
+	if section == nil {
+		return hosts
+	}
+
+	for _, line := range section.lines[1:] {
+		if host, ok := parseHostLine(line); ok {
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts
+}
+
+/*
+SetGroupHosts replaces the hosts of a group, creating the group at the end of
+the inventory if it doesn't exist yet.
+Comments are kept and existing host lines are reused for hosts which are still
+present so any inline host variables aren't lost.
+*/
+func (i *Inventory) SetGroupHosts(group string, hosts []string) {
+	section := i.section(group)
+
+	if section == nil {
+		section = i.appendSection(group)
+	}
+
+	existing := map[string]string{}
+	for _, line := range section.lines[1:] {
+		if host, ok := parseHostLine(line); ok {
+			existing[host] = line
+		}
+	}
+
+	hostLines := make([]string, len(hosts))
+	for n, host := range hosts {
+		if line, ok := existing[host]; ok {
+			hostLines[n] = line
+		} else {
+			hostLines[n] = host
+		}
+	}
+
+	// Host lines are inserted where the first old host was, or otherwise
+	// after any leading comments but before trailing blank lines.
+	insertAt := -1
+	lines := []string{section.lines[0]}
+
+	for _, line := range section.lines[1:] {
+		if _, ok := parseHostLine(line); ok {
+			if insertAt == -1 {
+				insertAt = len(lines)
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	if insertAt == -1 {
+		insertAt = len(lines)
+		for insertAt > 1 && strings.TrimSpace(lines[insertAt-1]) == "" {
+			insertAt--
+		}
+	}
+
+	section.lines = slices.Insert(lines, insertAt, hostLines...)
+}
+
+/*
+SetGroupVar sets a variable in a group's `:vars` section (eg: [production:vars]),
+creating the section at the end of the inventory if it doesn't exist yet.
+*/
+func (i *Inventory) SetGroupVar(group string, key string, value string) {
+	name := group + ":vars"
+	line := key + "=" + value
+	section := i.section(name)
+
+	if section == nil {
+		section = i.appendSection(name)
+	}
+
+	for n, existing := range section.lines[1:] {
+		if existingKey, _, ok := strings.Cut(existing, "="); ok && strings.TrimSpace(existingKey) == key {
+			section.lines[n+1] = line
+			return
+		}
+	}
+
+	insertAt := len(section.lines)
+	for insertAt > 1 && strings.TrimSpace(section.lines[insertAt-1]) == "" {
+		insertAt--
+	}
+
+	section.lines = slices.Insert(section.lines, insertAt, line)
+}
+
+/*
+SetHostVar sets an inline variable on a host's line in a group (eg:
+`1.2.3.4 ansible_port=2222`), replacing any existing value. An empty value
+removes the variable. Nothing changes if the host isn't in the group.
+*/
+func (i *Inventory) SetHostVar(group string, host string, key string, value string) {
+	section := i.section(group)
+	if section == nil {
+		return
+	}
+
+	for n, line := range section.lines[1:] {
+		if existing, ok := parseHostLine(line); !ok || existing != host {
+			continue
+		}
+
+		fields := []string{host}
+		for _, field := range strings.Fields(line)[1:] {
+			if existingKey, _, _ := strings.Cut(field, "="); existingKey != key {
+				fields = append(fields, field)
+			}
+		}
+
+		if value != "" {
+			fields = append(fields, key+"="+value)
+		}
+
+		section.lines[n+1] = strings.Join(fields, " ")
+	}
+}
+
+func (i *Inventory) Bytes() []byte {
+	var lines []string
+
+	for _, section := range i.sections {
+		lines = append(lines, section.lines...)
+	}
+
+	if len(lines) == 0 {
+		return []byte{}
+	}
+
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+func (i *Inventory) section(group string) *inventorySection {
+	for _, section := range i.sections {
+		if section.name == group {
+			return section
+		}
+	}
+
+	return nil
+}
+
+// appendSection adds an empty section at the end of the inventory, separated by a blank line.
+func (i *Inventory) appendSection(name string) *inventorySection {
+	last := i.sections[len(i.sections)-1]
+	if len(last.lines) == 0 || strings.TrimSpace(last.lines[len(last.lines)-1]) != "" {
+		last.lines = append(last.lines, "")
+	}
+
+	section := &inventorySection{name: name, lines: []string{"[" + name + "]"}}
+	i.sections = append(i.sections, section)
+
+	return section
+}
+
+func parseSectionHeader(line string) (name string, ok bool) {
+	line = strings.TrimSpace(line)
+
+	if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+		return strings.TrimSpace(line[1 : len(line)-1]), true
+	}
+
+	return "", false
+}
+
+func parseHostLine(line string) (host string, ok bool) {
+	fields := strings.Fields(line)
+
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], ";") {
+		return "", false
+	}
+
+	return fields[0], true
+}
@@ -0,0 +1,39 @@
+package trellis
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/roots/trellis-cli/command"
+)
+
+// InventoryScriptPath is the Ansible dynamic inventory script for an environment.
+func (t *Trellis) InventoryScriptPath(env string) string {
+	return filepath.Join(t.ConfigPath(), "inventory", env)
+}
+
+/*
+WriteInventoryScript writes an executable Ansible dynamic inventory script for
+an environment which runs `trellis inventory ENV` with the given trellis-cli
+executable. Ansible calls it with --list (or --host HOST). Returns the script's path.
+*/
+func (t *Trellis) WriteInventoryScript(env string, executable string) (string, error) {
+	path := t.InventoryScriptPath(env)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	script := fmt.Sprintf(`#!/bin/sh
+# Ansible dynamic inventory for the %s environment. Generated by trellis-cli.
+cd "$(dirname "$0")/../.." || exit 1
+exec %s inventory "$@" %s
+`, env, command.ShellQuote(executable), command.ShellQuote(env))
+
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		return "", err
+	}
+
+	return path, os.Chmod(path, 0755)
+}
@@ -0,0 +1,45 @@
+package trellis
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteInventoryScript(t *testing.T) {
+	defer LoadFixtureProject(t)()
+
+	trellis := NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := trellis.WriteInventoryScript("production", "/opt/it's/trellis")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if path != trellis.InventoryScriptPath("production") {
+		t.Errorf("expected path %s, got %s", trellis.InventoryScriptPath("production"), path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("expected script to be executable, got mode %s", info.Mode())
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `exec '/opt/it'\''s/trellis' inventory "$@" 'production'`
+
+	if !strings.Contains(string(content), expected) {
+		t.Errorf("expected script to contain %q, got:\n%s", expected, content)
+	}
+}
@@ -0,0 +1,183 @@
+package trellis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInventory(t *testing.T) {
+	content := `# comment
+
+[production]
+1.2.3.4 ansible_port=2222
+; another comment
+5.6.7.8
+
+[web]
+1.2.3.4
+
+[production:vars]
+foo=bar
+`
+
+	inventory := ParseInventory([]byte(content))
+
+	groups := inventory.Groups()
+	expectedGroups := []string{"production", "web"}
+
+	if !reflect.DeepEqual(groups, expectedGroups) {
+		t.Errorf("expected groups %v, got %v", expectedGroups, groups)
+	}
+
+	hosts := inventory.GroupHosts("production")
+	expectedHosts := []string{"1.2.3.4", "5.6.7.8"}
+
+	if !reflect.DeepEqual(hosts, expectedHosts) {
+		t.Errorf("expected hosts %v, got %v", expectedHosts, hosts)
+	}
+
+	if hosts := inventory.GroupHosts("missing"); len(hosts) != 0 {
+		t.Errorf("expected no hosts for missing group, got %v", hosts)
+	}
+
+	if string(inventory.Bytes()) != content {
+		t.Errorf("expected unmodified inventory to round trip\n%s\ngot\n%s", content, inventory.Bytes())
+	}
+}
+
+func TestInventorySetGroupHosts(t *testing.T) {
+	cases := []struct {
+		name     string
+		content  string
+		group    string
+		hosts    []string
+		expected string
+	}{
+		{
+			"empty",
+			"",
+			"web",
+			[]string{"1.2.3.4"},
+			"\n[web]\n1.2.3.4\n",
+		},
+		{
+			"replace_hosts",
+			"[web]\n1.1.1.1\n\n[db]\n2.2.2.2\n",
+			"web",
+			[]string{"3.3.3.3", "4.4.4.4"},
+			"[web]\n3.3.3.3\n4.4.4.4\n\n[db]\n2.2.2.2\n",
+		},
+		{
+			"keep_host_vars_and_comments",
+			"[web]\n# comment\n1.1.1.1 ansible_user=admin\n",
+			"web",
+			[]string{"1.1.1.1", "2.2.2.2"},
+			"[web]\n# comment\n1.1.1.1 ansible_user=admin\n2.2.2.2\n",
+		},
+		{
+			"empty_group",
+			"[web]\n\n[db]\n2.2.2.2\n",
+			"web",
+			[]string{"1.1.1.1"},
+			"[web]\n1.1.1.1\n\n[db]\n2.2.2.2\n",
+		},
+		{
+			"new_group",
+			"[web]\n1.1.1.1\n",
+			"db",
+			[]string{"2.2.2.2"},
+			"[web]\n1.1.1.1\n\n[db]\n2.2.2.2\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			inventory := ParseInventory([]byte(tc.content))
+			inventory.SetGroupHosts(tc.group, tc.hosts)
+
+			if string(inventory.Bytes()) != tc.expected {
+				t.Errorf("expected\n%q\ngot\n%q", tc.expected, inventory.Bytes())
+			}
+		})
+	}
+}
+
+func TestInventorySetGroupVar(t *testing.T) {
+	cases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			"new_section",
+			"[production]\n1.1.1.1\n",
+			"[production]\n1.1.1.1\n\n[production:vars]\nansible_host_key_checking=true\n",
+		},
+		{
+			"existing_section",
+			"[production:vars]\nansible_user=admin\n\n[web]\n1.1.1.1\n",
+			"[production:vars]\nansible_user=admin\nansible_host_key_checking=true\n\n[web]\n1.1.1.1\n",
+		},
+		{
+			"replace_value",
+			"[production:vars]\nansible_host_key_checking = false\n",
+			"[production:vars]\nansible_host_key_checking=true\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			inventory := ParseInventory([]byte(tc.content))
+			inventory.SetGroupVar("production", "ansible_host_key_checking", "true")
+
+			if string(inventory.Bytes()) != tc.expected {
+				t.Errorf("expected\n%q\ngot\n%q", tc.expected, inventory.Bytes())
+			}
+		})
+	}
+}
+
+func TestInventorySetHostVar(t *testing.T) {
+	cases := []struct {
+		name     string
+		content  string
+		value    string
+		expected string
+	}{
+		{
+			"add",
+			"[production]\n1.1.1.1\n2.2.2.2\n",
+			"2222",
+			"[production]\n1.1.1.1 ansible_port=2222\n2.2.2.2\n",
+		},
+		{
+			"replace",
+			"[production]\n1.1.1.1 ansible_port=22 ansible_python_interpreter=python3\n",
+			"2222",
+			"[production]\n1.1.1.1 ansible_python_interpreter=python3 ansible_port=2222\n",
+		},
+		{
+			"remove",
+			"[production]\n1.1.1.1 ansible_port=2222\n",
+			"",
+			"[production]\n1.1.1.1\n",
+		},
+		{
+			"missing_host",
+			"[production]\n2.2.2.2\n",
+			"2222",
+			"[production]\n2.2.2.2\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			inventory := ParseInventory([]byte(tc.content))
+			inventory.SetHostVar("production", "1.1.1.1", "ansible_port", tc.value)
+
+			if string(inventory.Bytes()) != tc.expected {
+				t.Errorf("expected\n%q\ngot\n%q", tc.expected, inventory.Bytes())
+			}
+		})
+	}
+}
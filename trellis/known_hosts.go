@@ -0,0 +1,67 @@
+package trellis
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+// KnownHostsPath is the project-managed SSH known_hosts file with the host keys of the project's servers.
+func (t *Trellis) KnownHostsPath() string {
+	return filepath.Join(t.ConfigPath(), "known_hosts")
+}
+
+/*
+ConfigureKnownHosts adds the project's known_hosts file to Ansible's SSH
+arguments (ssh_connection.ssh_common_args in ansible.cfg) alongside the user's
+own known_hosts file and turns on host key checking for the environment.
+
+Trellis's ansible.cfg disables host_key_checking, which makes Ansible pass
+StrictHostKeyChecking=no before any ssh_common_args, so it's re-enabled with
+the ansible_host_key_checking variable in the environment's inventory
+(eg: [production:vars] in hosts/production).
+*/
+func (t *Trellis) ConfigureKnownHosts(env string) error {
+	relPath, err := filepath.Rel(t.Path, t.KnownHostsPath())
+	if err != nil {
+		return err
+	}
+
+	cfg, err := ini.Load(filepath.Join(t.Path, "ansible.cfg"))
+	if err != nil {
+		return err
+	}
+
+	// ansible.cfg is checked first so host key checking is only turned on once
+	// the project's known_hosts file is actually used
+	current := cfg.Section("ssh_connection").Key("ssh_common_args").String()
+	if !strings.Contains(current, relPath) {
+		option := fmt.Sprintf(`-o UserKnownHostsFile="~/.ssh/known_hosts %s"`, relPath)
+
+		if current != "" {
+			return fmt.Errorf("ansible.cfg already sets ssh_common_args in [ssh_connection]. Add %s to it manually", option)
+		}
+
+		if err := t.UpdateAnsibleConfig("ssh_connection", "ssh_common_args", option); err != nil {
+			return err
+		}
+	}
+
+	return t.enableHostKeyChecking(env)
+}
+
+func (t *Trellis) enableHostKeyChecking(env string) error {
+	path := t.InventoryPath(env)
+
+	inventory, err := ReadInventory(path)
+	if err != nil {
+		return err
+	}
+
+	inventory.SetGroupVar(env, "ansible_host_key_checking", "true")
+
+	return os.WriteFile(path, inventory.Bytes(), 0644)
+}
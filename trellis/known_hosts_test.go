@@ -0,0 +1,82 @@
+package trellis
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConfigureKnownHosts(t *testing.T) {
+	defer LoadFixtureProject(t)()
+
+	trellis := NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile("ansible.cfg", []byte("[ssh_connection]\nssh_args = -o ForwardAgent=yes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if err := trellis.ConfigureKnownHosts("production"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := os.ReadFile("ansible.cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `-o UserKnownHostsFile="~/.ssh/known_hosts .trellis/known_hosts"`
+
+	if strings.Count(string(content), expected) != 1 {
+		t.Errorf("expected ansible.cfg to contain %q once, got:\n%s", expected, content)
+	}
+
+	if !strings.Contains(string(content), "-o ForwardAgent=yes") {
+		t.Errorf("expected existing ssh_args to be kept, got:\n%s", content)
+	}
+
+	inventory, err := os.ReadFile(trellis.InventoryPath("production"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Count(string(inventory), "[production:vars]\nansible_host_key_checking=true\n") != 1 {
+		t.Errorf("expected hosts/production to enable host key checking once, got:\n%s", inventory)
+	}
+}
+
+func TestConfigureKnownHostsExistingArgs(t *testing.T) {
+	defer LoadFixtureProject(t)()
+
+	trellis := NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile("ansible.cfg", []byte("[ssh_connection]\nssh_common_args = -o ProxyJump=bastion\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := os.ReadFile(trellis.InventoryPath("production"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = trellis.ConfigureKnownHosts("production")
+	if err == nil || !strings.Contains(err.Error(), "Add -o UserKnownHostsFile") {
+		t.Errorf("expected error about existing ssh_common_args, got %v", err)
+	}
+
+	after, err := os.ReadFile(trellis.InventoryPath("production"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(before) != string(after) {
+		t.Errorf("expected hosts/production to be unchanged, got:\n%s", after)
+	}
+}
@@ -2,6 +2,10 @@ package trellis
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
 )
 
 func (t *Trellis) SshHost(environment string, siteName string, user string) string {
@@ -13,3 +17,33 @@ func (t *Trellis) SshHost(environment string, siteName string, user string) stri
 
 	return fmt.Sprintf("%s@%s", user, host)
 }
+
+/*
+AdminUser returns the `admin_user` Trellis provisions servers with after the
+first run as root. It's read from group_vars/all and group_vars/ENV (which
+takes precedence) and defaults to "admin". Encrypted vault files are skipped.
+*/
+func (t *Trellis) AdminUser(env string) string {
+	user := "admin"
+
+	for _, dir := range []string{"all", env} {
+		paths, _ := filepath.Glob(filepath.Join(t.Path, "group_vars", dir, "*.yml"))
+
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+
+			vars := struct {
+				AdminUser string `yaml:"admin_user"`
+			}{}
+
+			if err := yaml.Unmarshal(content, &vars); err == nil && vars.AdminUser != "" {
+				user = vars.AdminUser
+			}
+		}
+	}
+
+	return user
+}
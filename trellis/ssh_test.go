@@ -0,0 +1,35 @@
+package trellis
+
+import (
+	"os"
+	"testing"
+)
+
+func TestAdminUser(t *testing.T) {
+	defer LoadFixtureProject(t)()
+
+	trellis := NewTrellis()
+	if err := trellis.LoadProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	if user := trellis.AdminUser("production"); user != "admin" {
+		t.Errorf("expected default admin user, got %q", user)
+	}
+
+	if err := os.WriteFile("group_vars/all/users.yml", []byte("admin_user: ubuntu\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile("group_vars/production/users.yml", []byte("admin_user: deployer\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if user := trellis.AdminUser("development"); user != "ubuntu" {
+		t.Errorf("expected admin user from group_vars/all, got %q", user)
+	}
+
+	if user := trellis.AdminUser("production"); user != "deployer" {
+		t.Errorf("expected admin user from group_vars/production, got %q", user)
+	}
+}
@@ -43,6 +43,12 @@ var DefaultCliConfig = cli_config.Config{
 		InstanceName:    "",
 		ForwardHttpPort: true,
 	},
+	Server: cli_config.ServerConfig{
+		Firewall: cli_config.ServerFirewallConfig{
+			Enabled: true,
+			Ports:   []int{22, 80, 443},
+		},
+	},
 }
 
 type Trellis struct {
@@ -393,6 +399,8 @@ func (t *Trellis) VmManagerType() string {
 		return ""
 	case "lima":
 		return "lima"
+	case "ssh":
+		return "ssh"
 	case "mock":
 		return "mock"
 	default: